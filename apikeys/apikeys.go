@@ -0,0 +1,136 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+// Package apikeys manages the API keys callers authenticate with. Keys are
+// only known in full when created: stores keep their SHA-256 hash, along
+// with the scopes they grant.
+package apikeys
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tiagomelo/go-airports-service/auth"
+)
+
+// Header is the header API keys are sent in.
+const Header = "X-API-Key"
+
+// IDPattern is the pattern API key IDs follow.
+const IDPattern = `[0-9a-f]{16}`
+
+// secretPrefix is the prefix of API keys, so that they can be told apart
+// from other secrets, such as by secret scanners.
+const secretPrefix = "ak_"
+
+// ErrNotFound is returned when an API key does not exist, or was already
+// revoked when revoking it.
+var ErrNotFound = errors.New("api key not found")
+
+// For ease of unit testing.
+var (
+	// randRead is a function that fills a byte slice with random bytes.
+	randRead = rand.Read
+	// now is a function that returns the current time.
+	now = time.Now
+)
+
+// Key describes an API key, without its secret.
+type Key struct {
+	ID        string       `json:"id"`
+	Name      string       `json:"name"`
+	Scopes    []auth.Scope `json:"scopes"`
+	CreatedAt time.Time    `json:"created_at"`
+	RevokedAt *time.Time   `json:"revoked_at,omitempty"`
+}
+
+// Store keeps API keys, identified by the hash of their secret.
+type Store interface {
+	// Insert stores a new API key along with the hash of its secret.
+	Insert(ctx context.Context, key Key, hash string) error
+	// List returns all API keys, revoked ones included, oldest first.
+	List(ctx context.Context) ([]Key, error)
+	// Revoke revokes the API key with the given ID, returning
+	// ErrNotFound if there is no such key or it was already revoked.
+	Revoke(ctx context.Context, id string) error
+	// GetByHash returns the API key whose secret has the given hash,
+	// revoked or not, or ErrNotFound.
+	GetByHash(ctx context.Context, hash string) (*Key, error)
+}
+
+// New generates an API key with the given name and scopes, returning it
+// along with its secret, which is not stored anywhere and must be handed
+// over to the caller.
+func New(name string, scopes []auth.Scope) (Key, string) {
+	key := Key{
+		ID:        randomHex(8),
+		Name:      name,
+		Scopes:    scopes,
+		CreatedAt: now().UTC(),
+	}
+	return key, secretPrefix + randomHex(32)
+}
+
+// Hash returns the hash API keys are stored and looked up by.
+func Hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomHex returns n random bytes, hex encoded.
+func randomHex(n int) string {
+	b := make([]byte, n)
+	// crypto/rand never fails on the platforms Go supports.
+	_, _ = randRead(b)
+	return hex.EncodeToString(b)
+}
+
+// Authenticator authenticates requests by the API key in their X-API-Key
+// header.
+type Authenticator struct {
+	store         Store
+	bootstrapHash string
+}
+
+// NewAuthenticator creates an authenticator looking API keys up in the
+// given store. The bootstrap key, if not empty, is accepted as an admin
+// key without being stored, so that the first keys can be created.
+func NewAuthenticator(store Store, bootstrapKey string) *Authenticator {
+	a := &Authenticator{store: store}
+	if bootstrapKey != "" {
+		a.bootstrapHash = Hash(bootstrapKey)
+	}
+	return a
+}
+
+// Authenticate resolves the request's API key to an identity whose subject
+// is api-key:<id>, granted the key's scopes. Unknown and revoked keys are
+// rejected with auth.ErrInvalidCredentials.
+func (a *Authenticator) Authenticate(r *http.Request) (*auth.Identity, error) {
+	secret := r.Header.Get(Header)
+	if secret == "" {
+		return nil, auth.ErrNoCredentials
+	}
+	hash := Hash(secret)
+	if a.bootstrapHash != "" && subtle.ConstantTimeCompare([]byte(hash), []byte(a.bootstrapHash)) == 1 {
+		return &auth.Identity{Subject: "api-key:bootstrap", Scopes: []auth.Scope{auth.ScopeAdmin}}, nil
+	}
+	key, err := a.store.GetByHash(r.Context(), hash)
+	if errors.Is(err, ErrNotFound) {
+		return nil, auth.ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "looking api key up")
+	}
+	if key.RevokedAt != nil {
+		return nil, auth.ErrInvalidCredentials
+	}
+	return &auth.Identity{Subject: "api-key:" + key.ID, Scopes: key.Scopes}, nil
+}
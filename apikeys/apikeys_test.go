@@ -0,0 +1,148 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package apikeys
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tiagomelo/go-airports-service/auth"
+)
+
+func TestNew(t *testing.T) {
+	originalRandRead, originalNow := randRead, now
+	defer func() {
+		randRead, now = originalRandRead, originalNow
+	}()
+	randRead = func(b []byte) (int, error) {
+		for i := range b {
+			b[i] = 0xab
+		}
+		return len(b), nil
+	}
+	now = func() time.Time {
+		return time.Date(2025, 1, 2, 3, 4, 5, 0, time.FixedZone("BRT", -3*60*60))
+	}
+	key, secret := New("feed importer", []auth.Scope{auth.ScopeAirportsWrite})
+	require.Equal(t, Key{
+		ID:        "abababababababab",
+		Name:      "feed importer",
+		Scopes:    []auth.Scope{auth.ScopeAirportsWrite},
+		CreatedAt: time.Date(2025, 1, 2, 6, 4, 5, 0, time.UTC),
+	}, key)
+	require.Equal(t, "ak_abababababababababababababababababababababababababababababababab", secret)
+}
+
+func TestHash(t *testing.T) {
+	require.Equal(t, "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae", Hash("foo"))
+}
+
+func TestAuthenticate(t *testing.T) {
+	revokedAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	testCases := []struct {
+		name             string
+		apiKey           string
+		mockGetByHash    func(ctx context.Context, hash string) (*Key, error)
+		expectedIdentity *auth.Identity
+		expectedError    error
+	}{
+		{
+			name:   "valid key",
+			apiKey: "ak_valid",
+			mockGetByHash: func(ctx context.Context, hash string) (*Key, error) {
+				require.Equal(t, Hash("ak_valid"), hash)
+				return &Key{ID: "0123456789abcdef", Scopes: []auth.Scope{auth.ScopeAirportsRead}}, nil
+			},
+			expectedIdentity: &auth.Identity{Subject: "api-key:0123456789abcdef", Scopes: []auth.Scope{auth.ScopeAirportsRead}},
+		},
+		{
+			name:             "bootstrap key",
+			apiKey:           "bootstrap-secret",
+			expectedIdentity: &auth.Identity{Subject: "api-key:bootstrap", Scopes: []auth.Scope{auth.ScopeAdmin}},
+		},
+		{
+			name:          "no key",
+			expectedError: auth.ErrNoCredentials,
+		},
+		{
+			name:   "unknown key",
+			apiKey: "ak_unknown",
+			mockGetByHash: func(ctx context.Context, hash string) (*Key, error) {
+				return nil, ErrNotFound
+			},
+			expectedError: auth.ErrInvalidCredentials,
+		},
+		{
+			name:   "revoked key",
+			apiKey: "ak_revoked",
+			mockGetByHash: func(ctx context.Context, hash string) (*Key, error) {
+				return &Key{ID: "0123456789abcdef", Scopes: []auth.Scope{auth.ScopeAdmin}, RevokedAt: &revokedAt}, nil
+			},
+			expectedError: auth.ErrInvalidCredentials,
+		},
+		{
+			name:   "store error",
+			apiKey: "ak_valid",
+			mockGetByHash: func(ctx context.Context, hash string) (*Key, error) {
+				return nil, errors.New("store error")
+			},
+			expectedError: errors.New("looking api key up: store error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/airports", nil)
+			if tc.apiKey != "" {
+				req.Header.Set(Header, tc.apiKey)
+			}
+			a := NewAuthenticator(&mockStore{getByHash: tc.mockGetByHash}, "bootstrap-secret")
+			id, err := a.Authenticate(req)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf(`expected no error, got "%v"`, err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf(`expected error "%v", got nil`, tc.expectedError)
+				}
+				require.Equal(t, tc.expectedIdentity, id)
+			}
+		})
+	}
+}
+
+func TestAuthenticateWithoutBootstrapKey(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/airports", nil)
+	req.Header.Set(Header, "ak_unknown")
+	a := NewAuthenticator(NewMemoryStore(), "")
+	_, err := a.Authenticate(req)
+	require.ErrorIs(t, err, auth.ErrInvalidCredentials)
+}
+
+type mockStore struct {
+	getByHash func(ctx context.Context, hash string) (*Key, error)
+}
+
+func (m *mockStore) Insert(ctx context.Context, key Key, hash string) error {
+	return nil
+}
+
+func (m *mockStore) List(ctx context.Context) ([]Key, error) {
+	return nil, nil
+}
+
+func (m *mockStore) Revoke(ctx context.Context, id string) error {
+	return nil
+}
+
+func (m *mockStore) GetByHash(ctx context.Context, hash string) (*Key, error) {
+	return m.getByHash(ctx, hash)
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package apikeys
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// MemoryStore keeps API keys in memory, for the in-memory airport store.
+type MemoryStore struct {
+	mu     sync.RWMutex
+	keys   map[string]Key
+	hashes map[string]string
+}
+
+// NewMemoryStore creates an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		keys:   make(map[string]Key),
+		hashes: make(map[string]string),
+	}
+}
+
+// Insert stores a new API key along with the hash of its secret.
+func (s *MemoryStore) Insert(_ context.Context, key Key, hash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.keys[key.ID]; ok {
+		return errors.Errorf("inserting api key: duplicate id %s", key.ID)
+	}
+	if _, ok := s.hashes[hash]; ok {
+		return errors.New("inserting api key: duplicate hash")
+	}
+	s.keys[key.ID] = key
+	s.hashes[hash] = key.ID
+	return nil
+}
+
+// List returns all API keys, revoked ones included, oldest first.
+func (s *MemoryStore) List(_ context.Context) ([]Key, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys := make([]Key, 0, len(s.keys))
+	for _, key := range s.keys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if !keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+		}
+		return keys[i].ID < keys[j].ID
+	})
+	return keys, nil
+}
+
+// Revoke revokes the API key with the given ID.
+func (s *MemoryStore) Revoke(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key, ok := s.keys[id]
+	if !ok || key.RevokedAt != nil {
+		return ErrNotFound
+	}
+	revokedAt := now().UTC()
+	key.RevokedAt = &revokedAt
+	s.keys[id] = key
+	return nil
+}
+
+// GetByHash returns the API key whose secret has the given hash.
+func (s *MemoryStore) GetByHash(_ context.Context, hash string) (*Key, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	id, ok := s.hashes[hash]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	key := s.keys[id]
+	return &key, nil
+}
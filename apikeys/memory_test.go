@@ -0,0 +1,49 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package apikeys
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tiagomelo/go-airports-service/auth"
+)
+
+func TestMemoryStore(t *testing.T) {
+	originalNow := now
+	defer func() {
+		now = originalNow
+	}()
+	now = func() time.Time {
+		return revokedAt
+	}
+	ctx := context.TODO()
+	s := NewMemoryStore()
+	newer := Key{ID: "fedcba9876543210", Name: "dashboard", Scopes: []auth.Scope{auth.ScopeAirportsRead}, CreatedAt: createdAt.Add(time.Hour)}
+	older := Key{ID: "0123456789abcdef", Name: "feed importer", Scopes: []auth.Scope{auth.ScopeAirportsWrite}, CreatedAt: createdAt}
+	require.NoError(t, s.Insert(ctx, newer, "newer hash"))
+	require.NoError(t, s.Insert(ctx, older, "older hash"))
+	require.EqualError(t, s.Insert(ctx, older, "another hash"), "inserting api key: duplicate id 0123456789abcdef")
+	require.EqualError(t, s.Insert(ctx, Key{ID: "aaaaaaaaaaaaaaaa"}, "older hash"), "inserting api key: duplicate hash")
+
+	keys, err := s.List(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []Key{older, newer}, keys)
+
+	key, err := s.GetByHash(ctx, "older hash")
+	require.NoError(t, err)
+	require.Equal(t, &older, key)
+	_, err = s.GetByHash(ctx, "unknown hash")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, s.Revoke(ctx, older.ID))
+	require.ErrorIs(t, s.Revoke(ctx, older.ID), ErrNotFound)
+	require.ErrorIs(t, s.Revoke(ctx, "aaaaaaaaaaaaaaaa"), ErrNotFound)
+	key, err = s.GetByHash(ctx, "older hash")
+	require.NoError(t, err)
+	require.Equal(t, &revokedAt, key.RevokedAt)
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package apikeys
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pkg/errors"
+	"github.com/tiagomelo/go-airports-service/auth"
+)
+
+// The queries below are understood by both SQLite and PostgreSQL.
+
+const insertQuery = `
+INSERT INTO api_keys (id, name, key_hash, scopes, created_at)
+VALUES ($1, $2, $3, $4, $5)
+`
+
+const listQuery = `
+SELECT id, name, scopes, created_at, revoked_at
+FROM api_keys
+ORDER BY created_at, id
+`
+
+const revokeQuery = `
+UPDATE api_keys
+SET revoked_at = $1
+WHERE id = $2 AND revoked_at IS NULL
+`
+
+const getByHashQuery = `
+SELECT id, name, scopes, created_at, revoked_at
+FROM api_keys
+WHERE key_hash = $1
+`
+
+// scanner is implemented by both *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// SQLStore keeps API keys in the api_keys table of a SQLite or PostgreSQL
+// database.
+type SQLStore struct {
+	writer *sql.DB
+	reader *sql.DB
+}
+
+// NewSQLStore creates a store writing through the writer and reading
+// through the reader, which may be the same database.
+func NewSQLStore(writer, reader *sql.DB) *SQLStore {
+	return &SQLStore{writer: writer, reader: reader}
+}
+
+// Insert stores a new API key along with the hash of its secret.
+func (s *SQLStore) Insert(ctx context.Context, key Key, hash string) error {
+	if _, err := s.writer.ExecContext(ctx, insertQuery,
+		key.ID,
+		key.Name,
+		hash,
+		auth.JoinScopes(key.Scopes),
+		key.CreatedAt,
+	); err != nil {
+		return errors.Wrap(err, "inserting api key")
+	}
+	return nil
+}
+
+// List returns all API keys, revoked ones included, oldest first.
+func (s *SQLStore) List(ctx context.Context) ([]Key, error) {
+	rows, err := s.reader.QueryContext(ctx, listQuery)
+	if err != nil {
+		return nil, errors.Wrap(err, "listing api keys")
+	}
+	defer rows.Close()
+	keys := []Key{}
+	for rows.Next() {
+		key, err := scanKey(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "scanning api key")
+		}
+		keys = append(keys, *key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "iterating api keys")
+	}
+	return keys, nil
+}
+
+// Revoke revokes the API key with the given ID.
+func (s *SQLStore) Revoke(ctx context.Context, id string) error {
+	res, err := s.writer.ExecContext(ctx, revokeQuery, now().UTC(), id)
+	if err != nil {
+		return errors.Wrap(err, "revoking api key")
+	}
+	revoked, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "revoking api key")
+	}
+	if revoked == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// GetByHash returns the API key whose secret has the given hash.
+func (s *SQLStore) GetByHash(ctx context.Context, hash string) (*Key, error) {
+	key, err := scanKey(s.reader.QueryRowContext(ctx, getByHashQuery, hash))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, errors.Wrap(err, "getting api key")
+	}
+	return key, nil
+}
+
+// scanKey scans ID, name, scopes, creation and revocation times into a
+// key.
+func scanKey(row scanner) (*Key, error) {
+	var (
+		key       Key
+		scopes    string
+		revokedAt sql.NullTime
+	)
+	if err := row.Scan(&key.ID, &key.Name, &scopes, &key.CreatedAt, &revokedAt); err != nil {
+		return nil, err
+	}
+	key.Scopes = auth.SplitScopes(scopes)
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+	return &key, nil
+}
@@ -0,0 +1,271 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package apikeys
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+	"github.com/tiagomelo/go-airports-service/auth"
+)
+
+var (
+	createdAt = time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	revokedAt = time.Date(2025, 2, 3, 4, 5, 6, 0, time.UTC)
+)
+
+func TestSQLStoreInsert(t *testing.T) {
+	testCases := []struct {
+		name          string
+		mockClosure   func(mock sqlmock.Sqlmock)
+		expectedError error
+	}{
+		{
+			name: "happy path",
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta(insertQuery)).
+					WithArgs("0123456789abcdef", "feed importer", "hash", "airports:read airports:write", createdAt).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name: "error",
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta(insertQuery)).
+					WithArgs("0123456789abcdef", "feed importer", "hash", "airports:read airports:write", createdAt).
+					WillReturnError(sql.ErrConnDone)
+			},
+			expectedError: errors.New("inserting api key: sql: connection is already closed"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			tc.mockClosure(mock)
+			err = NewSQLStore(db, db).Insert(context.TODO(), Key{
+				ID:        "0123456789abcdef",
+				Name:      "feed importer",
+				Scopes:    []auth.Scope{auth.ScopeAirportsRead, auth.ScopeAirportsWrite},
+				CreatedAt: createdAt,
+			}, "hash")
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf(`expected no error, got "%v"`, err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf(`expected error "%v", got nil`, tc.expectedError)
+				}
+			}
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestSQLStoreList(t *testing.T) {
+	testCases := []struct {
+		name           string
+		mockClosure    func(mock sqlmock.Sqlmock)
+		expectedOutput []Key
+		expectedError  error
+	}{
+		{
+			name: "happy path",
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(listQuery)).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "scopes", "created_at", "revoked_at"}).
+						AddRow("0123456789abcdef", "feed importer", "airports:write", createdAt, nil).
+						AddRow("fedcba9876543210", "old dashboard", "airports:read", createdAt, revokedAt))
+			},
+			expectedOutput: []Key{
+				{ID: "0123456789abcdef", Name: "feed importer", Scopes: []auth.Scope{auth.ScopeAirportsWrite}, CreatedAt: createdAt},
+				{ID: "fedcba9876543210", Name: "old dashboard", Scopes: []auth.Scope{auth.ScopeAirportsRead}, CreatedAt: createdAt, RevokedAt: &revokedAt},
+			},
+		},
+		{
+			name: "none",
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(listQuery)).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "scopes", "created_at", "revoked_at"}))
+			},
+			expectedOutput: []Key{},
+		},
+		{
+			name: "error",
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(listQuery)).
+					WillReturnError(sql.ErrConnDone)
+			},
+			expectedError: errors.New("listing api keys: sql: connection is already closed"),
+		},
+		{
+			name: "scan error",
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(listQuery)).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "scopes", "created_at", "revoked_at"}).
+						AddRow("0123456789abcdef", "feed importer", "airports:write", "not a time", nil))
+			},
+			expectedError: errors.New(`scanning api key: sql: Scan error on column index 3, name "created_at": unsupported Scan, storing driver.Value type string into type *time.Time`),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			tc.mockClosure(mock)
+			output, err := NewSQLStore(db, db).List(context.TODO())
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf(`expected no error, got "%v"`, err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf(`expected error "%v", got nil`, tc.expectedError)
+				}
+				require.Equal(t, tc.expectedOutput, output)
+			}
+		})
+	}
+}
+
+func TestSQLStoreRevoke(t *testing.T) {
+	originalNow := now
+	defer func() {
+		now = originalNow
+	}()
+	now = func() time.Time {
+		return revokedAt
+	}
+	testCases := []struct {
+		name          string
+		mockClosure   func(mock sqlmock.Sqlmock)
+		expectedError error
+	}{
+		{
+			name: "happy path",
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta(revokeQuery)).
+					WithArgs(revokedAt, "0123456789abcdef").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name: "not found or already revoked",
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta(revokeQuery)).
+					WithArgs(revokedAt, "0123456789abcdef").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			expectedError: ErrNotFound,
+		},
+		{
+			name: "error",
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta(revokeQuery)).
+					WithArgs(revokedAt, "0123456789abcdef").
+					WillReturnError(sql.ErrConnDone)
+			},
+			expectedError: errors.New("revoking api key: sql: connection is already closed"),
+		},
+		{
+			name: "rows affected error",
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta(revokeQuery)).
+					WithArgs(revokedAt, "0123456789abcdef").
+					WillReturnResult(sqlmock.NewErrorResult(sql.ErrConnDone))
+			},
+			expectedError: errors.New("revoking api key: sql: connection is already closed"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			tc.mockClosure(mock)
+			err = NewSQLStore(db, db).Revoke(context.TODO(), "0123456789abcdef")
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf(`expected no error, got "%v"`, err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf(`expected error "%v", got nil`, tc.expectedError)
+				}
+			}
+		})
+	}
+}
+
+func TestSQLStoreGetByHash(t *testing.T) {
+	testCases := []struct {
+		name           string
+		mockClosure    func(mock sqlmock.Sqlmock)
+		expectedOutput *Key
+		expectedError  error
+	}{
+		{
+			name: "happy path",
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(getByHashQuery)).
+					WithArgs("hash").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "scopes", "created_at", "revoked_at"}).
+						AddRow("0123456789abcdef", "feed importer", "airports:read airports:write", createdAt, nil))
+			},
+			expectedOutput: &Key{
+				ID:        "0123456789abcdef",
+				Name:      "feed importer",
+				Scopes:    []auth.Scope{auth.ScopeAirportsRead, auth.ScopeAirportsWrite},
+				CreatedAt: createdAt,
+			},
+		},
+		{
+			name: "not found",
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(getByHashQuery)).
+					WithArgs("hash").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "scopes", "created_at", "revoked_at"}))
+			},
+			expectedError: ErrNotFound,
+		},
+		{
+			name: "error",
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(getByHashQuery)).
+					WithArgs("hash").
+					WillReturnError(sql.ErrConnDone)
+			},
+			expectedError: errors.New("getting api key: sql: connection is already closed"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			tc.mockClosure(mock)
+			output, err := NewSQLStore(db, db).GetByHash(context.TODO(), "hash")
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf(`expected no error, got "%v"`, err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf(`expected error "%v", got nil`, tc.expectedError)
+				}
+				require.Equal(t, tc.expectedOutput, output)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+// Package auth defines who callers are and what they are allowed to do:
+// the identities authenticators resolve requests to, and the scopes
+// routes require.
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Scope is a permission granted to a caller.
+type Scope string
+
+// Scopes routes require.
+const (
+	// ScopeAirportsRead allows looking airports up, listing and searching
+	// them.
+	ScopeAirportsRead Scope = "airports:read"
+	// ScopeAirportsWrite allows upserting and deleting airports.
+	ScopeAirportsWrite Scope = "airports:write"
+	// ScopeAdmin allows everything, including reports, backups,
+	// snapshots and API key management.
+	ScopeAdmin Scope = "admin"
+)
+
+var (
+	// ErrNoCredentials is returned by authenticators when the request
+	// carries no credentials of the kind they check.
+	ErrNoCredentials = errors.New("no credentials")
+	// ErrInvalidCredentials is returned by authenticators when the
+	// request carries credentials that are unknown, revoked or otherwise
+	// invalid.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// ParseScopes parses the given scope names, rejecting unknown ones.
+func ParseScopes(names []string) ([]Scope, error) {
+	scopes := make([]Scope, 0, len(names))
+	for _, name := range names {
+		switch s := Scope(name); s {
+		case ScopeAirportsRead, ScopeAirportsWrite, ScopeAdmin:
+			scopes = append(scopes, s)
+		default:
+			return nil, errors.Errorf("invalid scope %q: expected one of %s, %s or %s", name, ScopeAirportsRead, ScopeAirportsWrite, ScopeAdmin)
+		}
+	}
+	return scopes, nil
+}
+
+// JoinScopes joins scopes with spaces, the way they are stored.
+func JoinScopes(scopes []Scope) string {
+	names := make([]string, len(scopes))
+	for i, s := range scopes {
+		names[i] = string(s)
+	}
+	return strings.Join(names, " ")
+}
+
+// SplitScopes splits scopes joined with spaces.
+func SplitScopes(joined string) []Scope {
+	fields := strings.Fields(joined)
+	scopes := make([]Scope, len(fields))
+	for i, f := range fields {
+		scopes[i] = Scope(f)
+	}
+	return scopes
+}
+
+// Identity is a caller an authenticator vouched for.
+type Identity struct {
+	// Subject identifies the caller, such as api-key:<id>.
+	Subject string
+	// Scopes are the permissions granted to the caller.
+	Scopes []Scope
+}
+
+// HasScope reports whether the identity was granted the given scope,
+// which the admin scope implies.
+func (i *Identity) HasScope(scope Scope) bool {
+	for _, s := range i.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// Authenticator resolves requests to the identity of their callers. It
+// returns ErrNoCredentials when the request carries none of the kind it
+// checks, and ErrInvalidCredentials when they cannot be trusted.
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Identity, error)
+}
+
+// Chain is an authenticator that tries each of its authenticators in
+// turn, until one finds credentials of the kind it checks.
+type Chain []Authenticator
+
+// Authenticate returns the result of the first authenticator that finds
+// credentials, or ErrNoCredentials if none does.
+func (c Chain) Authenticate(r *http.Request) (*Identity, error) {
+	for _, a := range c {
+		id, err := a.Authenticate(r)
+		if !errors.Is(err, ErrNoCredentials) {
+			return id, err
+		}
+	}
+	return nil, ErrNoCredentials
+}
+
+// ctxKey is the key identities are stored under in a context.
+type ctxKey struct{}
+
+// callerKey is the key caller slots are stored under in a context.
+type callerKey struct{}
+
+// caller is a slot identities are recorded into as they are stored in a
+// context, for the middlewares that run before authentication to find
+// out who the caller was once the request is served.
+type caller struct {
+	mu sync.Mutex
+	id *Identity
+}
+
+// NewCallerContext returns a copy of ctx carrying an empty caller slot,
+// or ctx itself if it already carries one. The identity later stored with
+// NewContext in a context derived from it is also returned by
+// FromContext(ctx).
+func NewCallerContext(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(callerKey{}).(*caller); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, callerKey{}, &caller{})
+}
+
+// NewContext returns a copy of ctx carrying the given identity, which is
+// also recorded into the caller slot ctx carries, if any.
+func NewContext(ctx context.Context, id *Identity) context.Context {
+	if c, ok := ctx.Value(callerKey{}).(*caller); ok {
+		c.mu.Lock()
+		c.id = id
+		c.mu.Unlock()
+	}
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext returns the identity ctx carries, or the one recorded into
+// its caller slot, or nil.
+func FromContext(ctx context.Context) *Identity {
+	if id, ok := ctx.Value(ctxKey{}).(*Identity); ok {
+		return id
+	}
+	if c, ok := ctx.Value(callerKey{}).(*caller); ok {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return c.id
+	}
+	return nil
+}
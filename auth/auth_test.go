@@ -0,0 +1,146 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseScopes(t *testing.T) {
+	testCases := []struct {
+		name           string
+		input          []string
+		expectedOutput []Scope
+		expectedError  error
+	}{
+		{
+			name:           "happy path",
+			input:          []string{"airports:read", "airports:write", "admin"},
+			expectedOutput: []Scope{ScopeAirportsRead, ScopeAirportsWrite, ScopeAdmin},
+		},
+		{
+			name:           "none",
+			expectedOutput: []Scope{},
+		},
+		{
+			name:          "unknown scope",
+			input:         []string{"airports:read", "airports:delete"},
+			expectedError: errors.New(`invalid scope "airports:delete": expected one of airports:read, airports:write or admin`),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			output, err := ParseScopes(tc.input)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf(`expected no error, got "%v"`, err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf(`expected error "%v", got nil`, tc.expectedError)
+				}
+				require.Equal(t, tc.expectedOutput, output)
+			}
+		})
+	}
+}
+
+func TestJoinAndSplitScopes(t *testing.T) {
+	scopes := []Scope{ScopeAirportsRead, ScopeAirportsWrite}
+	require.Equal(t, "airports:read airports:write", JoinScopes(scopes))
+	require.Equal(t, scopes, SplitScopes("airports:read  airports:write"))
+	require.Empty(t, SplitScopes(""))
+}
+
+func TestHasScope(t *testing.T) {
+	testCases := []struct {
+		name           string
+		scopes         []Scope
+		scope          Scope
+		expectedOutput bool
+	}{
+		{name: "granted", scopes: []Scope{ScopeAirportsRead}, scope: ScopeAirportsRead, expectedOutput: true},
+		{name: "not granted", scopes: []Scope{ScopeAirportsRead}, scope: ScopeAirportsWrite},
+		{name: "implied by admin", scopes: []Scope{ScopeAdmin}, scope: ScopeAirportsWrite, expectedOutput: true},
+		{name: "admin not implied", scopes: []Scope{ScopeAirportsRead, ScopeAirportsWrite}, scope: ScopeAdmin},
+		{name: "no scopes", scope: ScopeAirportsRead},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			id := &Identity{Subject: "test", Scopes: tc.scopes}
+			require.Equal(t, tc.expectedOutput, id.HasScope(tc.scope))
+		})
+	}
+}
+
+func TestContext(t *testing.T) {
+	require.Nil(t, FromContext(context.TODO()))
+	id := &Identity{Subject: "api-key:0123456789abcdef", Scopes: []Scope{ScopeAdmin}}
+	require.Equal(t, id, FromContext(NewContext(context.TODO(), id)))
+}
+
+func TestCallerContext(t *testing.T) {
+	ctx := NewCallerContext(context.TODO())
+	require.Nil(t, FromContext(ctx))
+	require.Equal(t, ctx, NewCallerContext(ctx))
+	id := &Identity{Subject: "jwt:alice", Scopes: []Scope{ScopeAirportsRead}}
+	NewContext(NewCallerContext(ctx), id)
+	require.Equal(t, id, FromContext(ctx))
+}
+
+// authenticatorFunc is an Authenticator backed by a function.
+type authenticatorFunc func(r *http.Request) (*Identity, error)
+
+func (f authenticatorFunc) Authenticate(r *http.Request) (*Identity, error) {
+	return f(r)
+}
+
+func TestChain(t *testing.T) {
+	alice := &Identity{Subject: "jwt:alice"}
+	none := authenticatorFunc(func(*http.Request) (*Identity, error) {
+		return nil, ErrNoCredentials
+	})
+	invalid := authenticatorFunc(func(*http.Request) (*Identity, error) {
+		return nil, ErrInvalidCredentials
+	})
+	valid := authenticatorFunc(func(*http.Request) (*Identity, error) {
+		return alice, nil
+	})
+	testCases := []struct {
+		name           string
+		chain          Chain
+		expectedOutput *Identity
+		expectedError  error
+	}{
+		{name: "first finds credentials", chain: Chain{valid, invalid}, expectedOutput: alice},
+		{name: "later finds credentials", chain: Chain{none, valid}, expectedOutput: alice},
+		{name: "invalid credentials", chain: Chain{none, invalid, valid}, expectedError: ErrInvalidCredentials},
+		{name: "no credentials", chain: Chain{none, none}, expectedError: ErrNoCredentials},
+		{name: "empty", expectedError: ErrNoCredentials},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			output, err := tc.chain.Authenticate(httptest.NewRequest(http.MethodGet, "/", nil))
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf(`expected no error, got "%v"`, err)
+				}
+				require.ErrorIs(t, err, tc.expectedError)
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf(`expected error "%v", got nil`, tc.expectedError)
+				}
+				require.Equal(t, tc.expectedOutput, output)
+			}
+		})
+	}
+}
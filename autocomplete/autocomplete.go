@@ -0,0 +1,347 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+// Package autocomplete provides an in-memory prefix index over airports'
+// IATA codes, names and cities, meant to serve per-keystroke lookups. The
+// indexed airports, along with their localized names, are also handed out
+// to the lookups that have to consider all of them, such as fuzzy matching.
+package autocomplete
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/tiagomelo/go-airports-service/db/airports"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// kind tells which part of an airport a term was extracted from.
+type kind int
+
+const (
+	kindIataCode kind = iota
+	kindName
+	kindCity
+	kindNameWord
+	kindCityWord
+)
+
+// Scores given to a suggestion according to how the query matched it.
+// Exact IATA code matches always come first.
+const (
+	scoreExactIataCode = 1000
+	scoreIataCode      = 500
+	scoreName          = 300
+	scoreCity          = 200
+	scoreNameWord      = 100
+	scoreCityWord      = 50
+)
+
+// Suggestion is an airport suggested for a typed query.
+type Suggestion struct {
+	Name     string `json:"name"`
+	City     string `json:"city"`
+	Country  string `json:"country"`
+	IataCode string `json:"iata_code"`
+	Score    int    `json:"score"`
+}
+
+// ref points from a trie node back to the airport a term belongs to.
+type ref struct {
+	iataCode string
+	kind     kind
+}
+
+// node is a node of the prefix trie.
+type node struct {
+	children map[rune]*node
+	refs     []ref
+}
+
+// term is a normalized key an airport is indexed under.
+type term struct {
+	key  string
+	kind kind
+}
+
+// entry holds an indexed airport and the terms it was indexed under,
+// so they can be removed when the airport changes.
+type entry struct {
+	airport airports.Airport
+	terms   []term
+}
+
+// Index is a concurrency-safe prefix trie over airports.
+type Index struct {
+	mu      sync.RWMutex
+	root    *node
+	entries map[string]*entry
+}
+
+// NewIndex creates an empty index.
+func NewIndex() *Index {
+	return &Index{
+		root:    newNode(),
+		entries: make(map[string]*entry),
+	}
+}
+
+// newNode creates an empty trie node.
+func newNode() *node {
+	return &node{children: make(map[rune]*node)}
+}
+
+// Load adds the given airports to the index.
+func (idx *Index) Load(all []airports.Airport) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	for _, a := range all {
+		idx.add(a)
+	}
+}
+
+// Replace swaps the whole index for the given airports at once, so that
+// searches never see it half loaded.
+func (idx *Index) Replace(all []airports.Airport) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.root = newNode()
+	idx.entries = make(map[string]*entry, len(all))
+	for _, a := range all {
+		idx.add(a)
+	}
+}
+
+// Add indexes an airport, replacing any previous version of it. As when
+// upserting it, the previous coordinates and localized names are kept when
+// it comes without them.
+func (idx *Index) Add(a airports.Airport) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.add(a)
+}
+
+// Remove drops an airport from the index, if present.
+func (idx *Index) Remove(iataCode string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	e, ok := idx.entries[iataCode]
+	if !ok {
+		return
+	}
+	for _, t := range e.terms {
+		idx.root.remove(t.key, iataCode)
+	}
+	delete(idx.entries, iataCode)
+}
+
+// Each calls fn for every indexed airport, in no particular order. fn must
+// not modify the index.
+func (idx *Index) Each(fn func(a airports.Airport)) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	for _, e := range idx.entries {
+		fn(e.airport)
+	}
+}
+
+// Len returns the number of indexed airports.
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.entries)
+}
+
+// Search returns up to limit airports matching the given query, best
+// matches first. Ties are broken by name and then by IATA code.
+func (idx *Index) Search(query string, limit int) []Suggestion {
+	key := normalize(query)
+	suggestions := []Suggestion{}
+	if key == "" || limit < 1 {
+		return suggestions
+	}
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	start := idx.root.find(key)
+	if start == nil {
+		return suggestions
+	}
+	scores := make(map[string]int)
+	for _, r := range start.refs {
+		if r.kind == kindIataCode {
+			scores[r.iataCode] = scoreExactIataCode
+		}
+	}
+	start.walk(func(r ref) {
+		if score := r.kind.score(); score > scores[r.iataCode] {
+			scores[r.iataCode] = score
+		}
+	})
+	for iataCode, score := range scores {
+		a := idx.entries[iataCode].airport
+		suggestions = append(suggestions, Suggestion{
+			Name:     a.Name,
+			City:     a.City,
+			Country:  a.Country,
+			IataCode: a.IataCode,
+			Score:    score,
+		})
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		si, sj := suggestions[i], suggestions[j]
+		if si.Score != sj.Score {
+			return si.Score > sj.Score
+		}
+		if si.Name != sj.Name {
+			return si.Name < sj.Name
+		}
+		return si.IataCode < sj.IataCode
+	})
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions
+}
+
+// add indexes an airport. The caller must hold the write lock.
+func (idx *Index) add(a airports.Airport) {
+	if e, ok := idx.entries[a.IataCode]; ok {
+		for _, t := range e.terms {
+			idx.root.remove(t.key, a.IataCode)
+		}
+		if a.Geoloc == nil {
+			a.Geoloc = e.airport.Geoloc
+		}
+		if len(a.Names) == 0 {
+			a.Names = e.airport.Names
+		}
+	}
+	terms := termsOf(a)
+	for _, t := range terms {
+		idx.root.insert(t.key, ref{iataCode: a.IataCode, kind: t.kind})
+	}
+	idx.entries[a.IataCode] = &entry{airport: a, terms: terms}
+}
+
+// termsOf returns the unique terms an airport is indexed under.
+func termsOf(a airports.Airport) []term {
+	var terms []term
+	seen := make(map[term]bool)
+	appendTerm := func(key string, k kind) {
+		t := term{key: key, kind: k}
+		if key == "" || seen[t] {
+			return
+		}
+		seen[t] = true
+		terms = append(terms, t)
+	}
+	appendTerm(normalize(a.IataCode), kindIataCode)
+	name := normalize(a.Name)
+	appendTerm(name, kindName)
+	for _, w := range otherWords(name) {
+		appendTerm(w, kindNameWord)
+	}
+	city := normalize(a.City)
+	appendTerm(city, kindCity)
+	for _, w := range otherWords(city) {
+		appendTerm(w, kindCityWord)
+	}
+	return terms
+}
+
+// otherWords returns all but the first word of s, since the first one is
+// already reachable through the term for the whole string.
+func otherWords(s string) []string {
+	words := strings.Fields(s)
+	if len(words) < 2 {
+		return nil
+	}
+	return words[1:]
+}
+
+// score returns the score of a prefix match on a term of this kind.
+func (k kind) score() int {
+	switch k {
+	case kindIataCode:
+		return scoreIataCode
+	case kindName:
+		return scoreName
+	case kindCity:
+		return scoreCity
+	case kindNameWord:
+		return scoreNameWord
+	default:
+		return scoreCityWord
+	}
+}
+
+// insert adds a reference under the given key.
+func (n *node) insert(key string, r ref) {
+	cur := n
+	for _, c := range key {
+		next, ok := cur.children[c]
+		if !ok {
+			next = newNode()
+			cur.children[c] = next
+		}
+		cur = next
+	}
+	cur.refs = append(cur.refs, r)
+}
+
+// remove drops all references to the given airport stored under key.
+func (n *node) remove(key, iataCode string) {
+	cur := n.find(key)
+	if cur == nil {
+		return
+	}
+	refs := cur.refs[:0]
+	for _, r := range cur.refs {
+		if r.iataCode != iataCode {
+			refs = append(refs, r)
+		}
+	}
+	cur.refs = refs
+}
+
+// find returns the node reached by following key, or nil if there is none.
+func (n *node) find(key string) *node {
+	cur := n
+	for _, c := range key {
+		next, ok := cur.children[c]
+		if !ok {
+			return nil
+		}
+		cur = next
+	}
+	return cur
+}
+
+// walk calls fn for every reference stored in the subtree rooted at n.
+func (n *node) walk(fn func(r ref)) {
+	for _, r := range n.refs {
+		fn(r)
+	}
+	for _, child := range n.children {
+		child.walk(fn)
+	}
+}
+
+// normalize lowercases s, strips diacritics and collapses anything that
+// is not a letter or a digit into single spaces.
+func normalize(s string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(t, s)
+	if err != nil {
+		folded = s
+	}
+	words := strings.FieldsFunc(strings.ToLower(folded), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	return strings.Join(words, " ")
+}
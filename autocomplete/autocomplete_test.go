@@ -0,0 +1,138 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package autocomplete
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tiagomelo/go-airports-service/db/airports"
+)
+
+func TestSearch(t *testing.T) {
+	idx := NewIndex()
+	idx.Load([]airports.Airport{
+		{Name: "Heathrow", City: "London", Country: "United Kingdom", IataCode: "LHR"},
+		{Name: "Gatwick", City: "London", Country: "United Kingdom", IataCode: "LGW"},
+		{Name: "London City", City: "London", Country: "United Kingdom", IataCode: "LCY"},
+		{Name: "Long Beach", City: "Long Beach", Country: "United States", IataCode: "LGB"},
+		{Name: "Lonorore", City: "Lonorore", Country: "Vanuatu", IataCode: "LNE"},
+		{Name: "Congonhas", City: "São Paulo", Country: "Brazil", IataCode: "CGH"},
+		{Name: "Greater Binghamton Edwin A Link Fld", City: "Binghamton", Country: "United States", IataCode: "BGM"},
+	})
+	testCases := []struct {
+		name           string
+		query          string
+		limit          int
+		expectedOutput []string
+	}{
+		{
+			name:           "exact IATA code comes first",
+			query:          "lne",
+			limit:          10,
+			expectedOutput: []string{"LNE"},
+		},
+		{
+			name:           "names before cities before words",
+			query:          "lon",
+			limit:          10,
+			expectedOutput: []string{"LCY", "LGB", "LNE", "LGW", "LHR"},
+		},
+		{
+			name:           "limit",
+			query:          "lon",
+			limit:          2,
+			expectedOutput: []string{"LCY", "LGB"},
+		},
+		{
+			name:           "word inside the name",
+			query:          "link",
+			limit:          10,
+			expectedOutput: []string{"BGM"},
+		},
+		{
+			name:           "diacritics and case are ignored",
+			query:          "SAO PAU",
+			limit:          10,
+			expectedOutput: []string{"CGH"},
+		},
+		{
+			name:           "no match",
+			query:          "xyz",
+			limit:          10,
+			expectedOutput: []string{},
+		},
+		{
+			name:           "empty query",
+			query:          " ",
+			limit:          10,
+			expectedOutput: []string{},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			output := []string{}
+			for _, s := range idx.Search(tc.query, tc.limit) {
+				output = append(output, s.IataCode)
+			}
+			require.Equal(t, tc.expectedOutput, output)
+		})
+	}
+}
+
+func TestAddReplacesPreviousVersion(t *testing.T) {
+	idx := NewIndex()
+	idx.Add(airports.Airport{Name: "Chicago Ohare Intl", City: "Chicago", Country: "United States", IataCode: "ORD"})
+	idx.Add(airports.Airport{Name: "O'Hare International", City: "Chicago", Country: "United States", IataCode: "ORD"})
+	require.Equal(t, 1, idx.Len())
+	require.Empty(t, idx.Search("ohare intl", 10))
+	suggestions := idx.Search("o hare", 10)
+	require.Len(t, suggestions, 1)
+	require.Equal(t, "O'Hare International", suggestions[0].Name)
+	require.Equal(t, scoreName, suggestions[0].Score)
+}
+
+func TestRemove(t *testing.T) {
+	idx := NewIndex()
+	idx.Add(airports.Airport{Name: "Chicago Ohare Intl", City: "Chicago", Country: "United States", IataCode: "ORD"})
+	idx.Add(airports.Airport{Name: "Chicago Midway Intl", City: "Chicago", Country: "United States", IataCode: "MDW"})
+	idx.Remove("ORD")
+	idx.Remove("XXX")
+	require.Equal(t, 1, idx.Len())
+	require.Empty(t, idx.Search("ord", 10))
+	suggestions := idx.Search("chicago", 10)
+	require.Len(t, suggestions, 1)
+	require.Equal(t, "MDW", suggestions[0].IataCode)
+}
+
+func TestReplace(t *testing.T) {
+	idx := NewIndex()
+	idx.Add(airports.Airport{Name: "Chicago Ohare Intl", City: "Chicago", Country: "United States", IataCode: "ORD"})
+	idx.Replace([]airports.Airport{
+		{Name: "Guarulhos", City: "Sao Paulo", Country: "Brazil", IataCode: "GRU"},
+		{Name: "Congonhas", City: "Sao Paulo", Country: "Brazil", IataCode: "CGH"},
+	})
+	require.Equal(t, 2, idx.Len())
+	require.Empty(t, idx.Search("chicago", 10))
+	require.Len(t, idx.Search("sao", 10), 2)
+}
+
+func TestEach(t *testing.T) {
+	idx := NewIndex()
+	names := []airports.LocalizedName{{Lang: "fr", Value: "Roissy", Type: "alias"}}
+	geoloc := &airports.Geoloc{Lat: 49.0097, Lng: 2.5479}
+	idx.Add(airports.Airport{Name: "Charles De Gaulle", City: "Paris", Country: "France", IataCode: "CDG", Geoloc: geoloc, Names: names})
+	idx.Add(airports.Airport{Name: "Orly", City: "Paris", Country: "France", IataCode: "ORY"})
+	// upserted again without coordinates nor names, which are kept.
+	idx.Add(airports.Airport{Name: "Paris Charles de Gaulle", City: "Paris", Country: "France", IataCode: "CDG"})
+	var all []airports.Airport
+	idx.Each(func(a airports.Airport) {
+		all = append(all, a)
+	})
+	require.ElementsMatch(t, []airports.Airport{
+		{Name: "Paris Charles de Gaulle", City: "Paris", Country: "France", IataCode: "CDG", Geoloc: geoloc, Names: names},
+		{Name: "Orly", City: "Paris", Country: "France", IataCode: "ORY"},
+	}, all)
+}
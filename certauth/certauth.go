@@ -0,0 +1,85 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+// Package certauth authenticates callers by the client certificates they
+// send over mutual TLS, mapping the subjects of the certificates to the
+// scopes they are granted.
+package certauth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/tiagomelo/go-airports-service/auth"
+)
+
+// Authenticator authenticates requests by the client certificate their
+// connection was established with, once verified by the server. The
+// subjects of certificates, as in CN=airport-admin,O=Example, are mapped
+// to scopes by a JSON file such as
+//
+//	{"CN=airport-admin,O=Example": ["admin"]}
+type Authenticator struct {
+	path string
+
+	mu         sync.RWMutex
+	identities map[string][]auth.Scope
+}
+
+// NewAuthenticator creates an authenticator mapping certificate subjects
+// to scopes as the given file does.
+func NewAuthenticator(path string) (*Authenticator, error) {
+	a := &Authenticator{path: path}
+	if err := a.Reload(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Reload loads the file mapping certificate subjects to scopes again. When
+// it cannot be loaded, the previous mapping is kept.
+func (a *Authenticator) Reload() error {
+	b, err := os.ReadFile(a.path)
+	if err != nil {
+		return errors.Wrapf(err, "reading identities file %s", a.path)
+	}
+	var names map[string][]string
+	if err := json.Unmarshal(b, &names); err != nil {
+		return errors.Wrapf(err, "parsing identities file %s", a.path)
+	}
+	identities := make(map[string][]auth.Scope, len(names))
+	for subject, scopeNames := range names {
+		scopes, err := auth.ParseScopes(scopeNames)
+		if err != nil {
+			return errors.Wrapf(err, "parsing identities file %s: subject %q", a.path, subject)
+		}
+		identities[subject] = scopes
+	}
+	a.mu.Lock()
+	a.identities = identities
+	a.mu.Unlock()
+	return nil
+}
+
+// Authenticate resolves the request's verified client certificate to an
+// identity whose subject is cert:<subject>, granted the scopes the subject
+// is mapped to. Certificates whose subject is not mapped are rejected with
+// auth.ErrInvalidCredentials.
+func (a *Authenticator) Authenticate(r *http.Request) (*auth.Identity, error) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil, auth.ErrNoCredentials
+	}
+	subject := r.TLS.VerifiedChains[0][0].Subject.String()
+	a.mu.RLock()
+	scopes, ok := a.identities[subject]
+	a.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown certificate subject %q", auth.ErrInvalidCredentials, subject)
+	}
+	return &auth.Identity{Subject: "cert:" + subject, Scopes: scopes}, nil
+}
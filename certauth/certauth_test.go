@@ -0,0 +1,172 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package certauth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tiagomelo/go-airports-service/auth"
+)
+
+// writeIdentities writes the given identities file and returns its path.
+func writeIdentities(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "identities.json")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestNewAuthenticator(t *testing.T) {
+	var (
+		missing      = filepath.Join(t.TempDir(), "missing.json")
+		invalidJSON  = writeIdentities(t, `["admin"]`)
+		unknownScope = writeIdentities(t, `{"CN=airport-admin,O=Example": ["root"]}`)
+	)
+	testCases := []struct {
+		name          string
+		path          string
+		expectedError error
+	}{
+		{
+			name: "happy path",
+			path: writeIdentities(t, `{"CN=airport-admin,O=Example": ["admin"]}`),
+		},
+		{
+			name:          "missing file",
+			path:          missing,
+			expectedError: fmt.Errorf("reading identities file %s: open %s: no such file or directory", missing, missing),
+		},
+		{
+			name:          "invalid JSON",
+			path:          invalidJSON,
+			expectedError: fmt.Errorf("parsing identities file %s: json: cannot unmarshal array into Go value of type map[string][]string", invalidJSON),
+		},
+		{
+			name:          "unknown scope",
+			path:          unknownScope,
+			expectedError: fmt.Errorf(`parsing identities file %s: subject "CN=airport-admin,O=Example": invalid scope "root": expected one of airports:read, airports:write or admin`, unknownScope),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			a, err := NewAuthenticator(tc.path)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf(`expected no error, got "%v"`, err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf(`expected error "%v", got nil`, tc.expectedError)
+				}
+				require.Equal(t, map[string][]auth.Scope{"CN=airport-admin,O=Example": {auth.ScopeAdmin}}, a.identities)
+			}
+		})
+	}
+}
+
+// withClientCert returns a request sent over a connection established
+// with a verified client certificate of the given subject.
+func withClientCert(subject pkix.Name) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/airports", nil)
+	req.TLS = &tls.ConnectionState{
+		VerifiedChains: [][]*x509.Certificate{{{Subject: subject}}},
+	}
+	return req
+}
+
+func TestAuthenticate(t *testing.T) {
+	a, err := NewAuthenticator(writeIdentities(t, `{
+		"CN=airport-admin,O=Example": ["admin"],
+		"CN=feed-importer,OU=Feeds,O=Example": ["airports:read", "airports:write"]
+	}`))
+	require.NoError(t, err)
+	noCert := httptest.NewRequest(http.MethodGet, "/api/v1/airports", nil)
+	noCert.TLS = &tls.ConnectionState{}
+	unverified := httptest.NewRequest(http.MethodGet, "/api/v1/airports", nil)
+	unverified.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: "airport-admin", Organization: []string{"Example"}}}}}
+	testCases := []struct {
+		name             string
+		req              *http.Request
+		expectedIdentity *auth.Identity
+		expectedError    error
+	}{
+		{
+			name:             "admin",
+			req:              withClientCert(pkix.Name{CommonName: "airport-admin", Organization: []string{"Example"}}),
+			expectedIdentity: &auth.Identity{Subject: "cert:CN=airport-admin,O=Example", Scopes: []auth.Scope{auth.ScopeAdmin}},
+		},
+		{
+			name:             "read and write",
+			req:              withClientCert(pkix.Name{CommonName: "feed-importer", OrganizationalUnit: []string{"Feeds"}, Organization: []string{"Example"}}),
+			expectedIdentity: &auth.Identity{Subject: "cert:CN=feed-importer,OU=Feeds,O=Example", Scopes: []auth.Scope{auth.ScopeAirportsRead, auth.ScopeAirportsWrite}},
+		},
+		{
+			name:          "unknown subject",
+			req:           withClientCert(pkix.Name{CommonName: "stranger", Organization: []string{"Example"}}),
+			expectedError: fmt.Errorf(`%w: unknown certificate subject "CN=stranger,O=Example"`, auth.ErrInvalidCredentials),
+		},
+		{
+			name:          "plain HTTP",
+			req:           httptest.NewRequest(http.MethodGet, "/api/v1/airports", nil),
+			expectedError: auth.ErrNoCredentials,
+		},
+		{
+			name:          "no client certificate",
+			req:           noCert,
+			expectedError: auth.ErrNoCredentials,
+		},
+		{
+			name:          "unverified client certificate",
+			req:           unverified,
+			expectedError: auth.ErrNoCredentials,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			id, err := a.Authenticate(tc.req)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf(`expected no error, got "%v"`, err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf(`expected error "%v", got nil`, tc.expectedError)
+				}
+				require.Equal(t, tc.expectedIdentity, id)
+			}
+		})
+	}
+}
+
+func TestReload(t *testing.T) {
+	path := writeIdentities(t, `{"CN=airport-admin,O=Example": ["admin"]}`)
+	a, err := NewAuthenticator(path)
+	require.NoError(t, err)
+	req := withClientCert(pkix.Name{CommonName: "feed-importer", Organization: []string{"Example"}})
+	_, err = a.Authenticate(req)
+	require.ErrorIs(t, err, auth.ErrInvalidCredentials)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"CN=feed-importer,O=Example": ["airports:write"]}`), 0o600))
+	require.NoError(t, a.Reload())
+	id, err := a.Authenticate(req)
+	require.NoError(t, err)
+	require.Equal(t, &auth.Identity{Subject: "cert:CN=feed-importer,O=Example", Scopes: []auth.Scope{auth.ScopeAirportsWrite}}, id)
+
+	// broken, the previous mapping is kept.
+	require.NoError(t, os.WriteFile(path, []byte(`{`), 0o600))
+	require.Error(t, a.Reload())
+	_, err = a.Authenticate(req)
+	require.NoError(t, err)
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+// Package certs holds the certificates the server is served over TLS
+// with, along with the CA bundle client certificates are verified against,
+// and reloads them without dropping connections, so that they can be
+// rotated.
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+)
+
+// Config holds the files certificates are loaded from, PEM encoded.
+type Config struct {
+	// CertFile is the certificate served, followed by its intermediates.
+	CertFile string
+	// KeyFile is the private key of the certificate.
+	KeyFile string
+	// ClientCAFile, if not empty, is the CA bundle client certificates
+	// are verified against. Clients not sending any are let through
+	// unless RequireClientCert is set.
+	ClientCAFile string
+	// RequireClientCert tells whether clients must send a certificate.
+	RequireClientCert bool
+}
+
+// Reloader serves the certificates loaded from the files of its config,
+// until they are reloaded. It is safe for concurrent use.
+type Reloader struct {
+	c      Config
+	config atomic.Pointer[tls.Config]
+}
+
+// NewReloader loads the certificates from the files of the given config.
+func NewReloader(c Config) (*Reloader, error) {
+	r := &Reloader{c: c}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload loads the certificates from the files again. New connections are
+// served with them from then on, while established ones go on with the
+// previous ones. When they cannot be loaded, the previous ones are kept.
+func (r *Reloader) Reload() error {
+	cert, err := tls.LoadX509KeyPair(r.c.CertFile, r.c.KeyFile)
+	if err != nil {
+		return errors.Wrapf(err, "loading certificate %s and key %s", r.c.CertFile, r.c.KeyFile)
+	}
+	config := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+	if r.c.ClientCAFile != "" {
+		pool, err := loadCAs(r.c.ClientCAFile)
+		if err != nil {
+			return err
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+		if r.c.RequireClientCert {
+			config.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+	r.config.Store(config)
+	return nil
+}
+
+// loadCAs loads the certificates of the given CA bundle.
+func loadCAs(path string) (*x509.CertPool, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading client CA bundle %s", path)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(b) {
+		return nil, errors.Errorf("reading client CA bundle %s: no certificates found", path)
+	}
+	return pool, nil
+}
+
+// TLSConfig returns the TLS config servers are set up with, which hands
+// each handshake the certificates loaded last.
+func (r *Reloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return r.config.Load(), nil
+		},
+	}
+}
@@ -0,0 +1,261 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testCert is a certificate along with its key and the files they were
+// written to.
+type testCert struct {
+	cert     *x509.Certificate
+	key      *ecdsa.PrivateKey
+	certFile string
+	keyFile  string
+}
+
+// newTestCert creates a certificate for the given common name, signed by
+// parent, or self-signed as a CA when nil, and writes it to dir.
+func newTestCert(t *testing.T, dir, name string, serial int64, parent *testCert) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: name, Organization: []string{"Example"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	c := &testCert{
+		cert:     cert,
+		key:      key,
+		certFile: filepath.Join(dir, name+".crt"),
+		keyFile:  filepath.Join(dir, name+".key"),
+	}
+	require.NoError(t, os.WriteFile(c.certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(c.keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600))
+	return c
+}
+
+// tlsCert returns the certificate and its key for TLS clients to send.
+func (c *testCert) tlsCert() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.cert.Raw}, PrivateKey: c.key, Leaf: c.cert}
+}
+
+func TestNewReloader(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, dir, "ca", 1, nil)
+	server := newTestCert(t, dir, "server", 2, ca)
+	empty := filepath.Join(dir, "empty.pem")
+	require.NoError(t, os.WriteFile(empty, []byte("no certificates here"), 0o600))
+	testCases := []struct {
+		name          string
+		config        Config
+		expectedError error
+	}{
+		{
+			name:   "happy path",
+			config: Config{CertFile: server.certFile, KeyFile: server.keyFile},
+		},
+		{
+			name:   "happy path, with client CA",
+			config: Config{CertFile: server.certFile, KeyFile: server.keyFile, ClientCAFile: ca.certFile, RequireClientCert: true},
+		},
+		{
+			name:          "key not matching certificate",
+			config:        Config{CertFile: server.certFile, KeyFile: ca.keyFile},
+			expectedError: fmt.Errorf("loading certificate %s and key %s: tls: private key does not match public key", server.certFile, ca.keyFile),
+		},
+		{
+			name:          "missing client CA bundle",
+			config:        Config{CertFile: server.certFile, KeyFile: server.keyFile, ClientCAFile: filepath.Join(dir, "missing.pem")},
+			expectedError: fmt.Errorf("reading client CA bundle %s: open %s: no such file or directory", filepath.Join(dir, "missing.pem"), filepath.Join(dir, "missing.pem")),
+		},
+		{
+			name:          "empty client CA bundle",
+			config:        Config{CertFile: server.certFile, KeyFile: server.keyFile, ClientCAFile: empty},
+			expectedError: fmt.Errorf("reading client CA bundle %s: no certificates found", empty),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := NewReloader(tc.config)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf(`expected no error, got "%v"`, err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf(`expected error "%v", got nil`, tc.expectedError)
+				}
+				require.NotNil(t, r.TLSConfig().GetConfigForClient)
+			}
+		})
+	}
+}
+
+func TestReload(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, dir, "ca", 1, nil)
+	server := newTestCert(t, dir, "server", 2, ca)
+	r, err := NewReloader(Config{CertFile: server.certFile, KeyFile: server.keyFile})
+	require.NoError(t, err)
+	url := serve(t, r)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}}
+
+	require.Equal(t, int64(2), servedSerial(t, client, url))
+
+	// rotated, the new certificate is served to new connections, while
+	// established ones go on.
+	newTestCert(t, dir, "server", 3, ca)
+	require.NoError(t, r.Reload())
+	require.Equal(t, int64(2), servedSerial(t, client, url))
+	newClient := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}}
+	require.Equal(t, int64(3), servedSerial(t, newClient, url))
+
+	// broken, the previous certificate is kept.
+	require.NoError(t, os.WriteFile(server.keyFile, []byte("broken"), 0o600))
+	require.Error(t, r.Reload())
+	newClient = &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}}
+	require.Equal(t, int64(3), servedSerial(t, newClient, url))
+}
+
+func TestClientCerts(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, dir, "ca", 1, nil)
+	server := newTestCert(t, dir, "server", 2, ca)
+	client := newTestCert(t, dir, "client", 3, ca)
+	otherCA := newTestCert(t, dir, "other-ca", 4, nil)
+	stranger := newTestCert(t, dir, "stranger", 5, otherCA)
+	testCases := []struct {
+		name              string
+		requireClientCert bool
+		clientCert        *testCert
+		expectedSubject   string
+		expectedError     bool
+	}{
+		{
+			name:            "verified certificate",
+			clientCert:      client,
+			expectedSubject: "CN=client,O=Example",
+		},
+		{
+			name: "no certificate",
+		},
+		{
+			name:              "no certificate, required",
+			requireClientCert: true,
+			expectedError:     true,
+		},
+		{
+			name:          "certificate signed by another CA",
+			clientCert:    stranger,
+			expectedError: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := NewReloader(Config{CertFile: server.certFile, KeyFile: server.keyFile, ClientCAFile: ca.certFile, RequireClientCert: tc.requireClientCert})
+			require.NoError(t, err)
+			url := serve(t, r)
+			roots := x509.NewCertPool()
+			roots.AddCert(ca.cert)
+			tlsConfig := &tls.Config{RootCAs: roots}
+			if tc.clientCert != nil {
+				cert := tc.clientCert.tlsCert()
+				// sent even when not signed by a CA the server accepts.
+				tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+					return &cert, nil
+				}
+			}
+			resp, err := (&http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}).Get(url)
+			if err != nil {
+				if !tc.expectedError {
+					t.Fatalf(`expected no error, got "%v"`, err)
+				}
+			} else {
+				if tc.expectedError {
+					t.Fatal("expected error, got nil")
+				}
+				defer resp.Body.Close()
+				require.Equal(t, tc.expectedSubject, resp.Header.Get("X-Client-Subject"))
+			}
+		})
+	}
+}
+
+// serve serves over TLS with the reloader's certificates, telling clients
+// the subject of the verified certificate they sent, if any, and returns
+// the URL to reach the server at.
+func serve(t *testing.T, r *Reloader) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if len(req.TLS.VerifiedChains) > 0 {
+				w.Header().Set("X-Client-Subject", req.TLS.VerifiedChains[0][0].Subject.String())
+			}
+		}),
+		TLSConfig: r.TLSConfig(),
+		ErrorLog:  log.New(io.Discard, "", 0),
+	}
+	go func() {
+		_ = srv.ServeTLS(ln, "", "")
+	}()
+	t.Cleanup(func() {
+		srv.Close()
+	})
+	return "https://" + ln.Addr().String()
+}
+
+// servedSerial returns the serial number of the certificate the server
+// was reached with.
+func servedSerial(t *testing.T, client *http.Client, url string) int64 {
+	t.Helper()
+	resp, err := client.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	return resp.TLS.PeerCertificates[0].SerialNumber.Int64()
+}
@@ -6,61 +6,582 @@ package main
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/jessevdk/go-flags"
 	"github.com/pkg/errors"
+	"github.com/tiagomelo/go-airports-service/apikeys"
+	"github.com/tiagomelo/go-airports-service/auth"
+	"github.com/tiagomelo/go-airports-service/autocomplete"
+	"github.com/tiagomelo/go-airports-service/certauth"
+	"github.com/tiagomelo/go-airports-service/certs"
 	"github.com/tiagomelo/go-airports-service/db"
+	"github.com/tiagomelo/go-airports-service/db/airports"
 	"github.com/tiagomelo/go-airports-service/handlers"
+	v1 "github.com/tiagomelo/go-airports-service/handlers/v1"
+	airportshandlers "github.com/tiagomelo/go-airports-service/handlers/v1/airports"
+	"github.com/tiagomelo/go-airports-service/jwtauth"
+	"github.com/tiagomelo/go-airports-service/logger"
+	"github.com/tiagomelo/go-airports-service/metrics"
+	"github.com/tiagomelo/go-airports-service/middleware"
+	"github.com/tiagomelo/go-airports-service/ratelimit"
+	"github.com/tiagomelo/go-airports-service/snapshots"
+	"github.com/tiagomelo/go-airports-service/tracing"
 )
 
 type options struct {
-	Port int `short:"p" long:"port" description:"server's port" required:"true"`
+	Port    int    `short:"p" long:"port" description:"server's port" required:"true"`
+	Dsn     string `long:"dsn" description:"PostgreSQL DSN (postgres://...); SQLite is used when empty"`
+	Store   string `long:"store" description:"where airports are stored: sql for the database --dsn points at, memory for an ephemeral in-memory store" choice:"sql" choice:"memory" default:"sql"`
+	Migrate string `long:"migrate" description:"what to do about pending database migrations at startup: auto applies them, check refuses to serve when there are any, off skips both" choice:"auto" choice:"check" choice:"off" default:"auto"`
+
+	SnapshotsDir string `long:"snapshots-dir" env:"AIRPORTS_SNAPSHOTS_DIR" description:"directory dataset snapshots are kept in" default:"db/snapshots"`
+
+	Database  databaseOptions  `group:"Database options"`
+	Logging   loggingOptions   `group:"Logging options"`
+	Tracing   tracingOptions   `group:"Tracing options"`
+	Auth      authOptions      `group:"Authentication options"`
+	RateLimit rateLimitOptions `group:"Rate limiting options"`
+	Limits    limitsOptions    `group:"Request limits"`
+	Server    serverOptions    `group:"Server options"`
+	CORS      corsOptions      `group:"CORS options"`
+	TLS       tlsOptions       `group:"TLS options"`
+}
+
+// validate checks all options, so that mistakes are reported before
+// anything is set up.
+func (o options) validate() error {
+	if rate := o.Logging.AccessLogSampleRate; rate < 0 || rate > 1 {
+		return errors.Errorf("invalid access log sample rate %v: expected a number between 0 and 1", rate)
+	}
+	if o.Store != "memory" {
+		if err := o.Database.validate(o.Dsn); err != nil {
+			return errors.Wrap(err, "invalid database options")
+		}
+	}
+	if err := o.TLS.validate(); err != nil {
+		return err
+	}
+	if err := o.Auth.validate(o.TLS); err != nil {
+		return err
+	}
+	if err := o.RateLimit.validate(); err != nil {
+		return err
+	}
+	if err := o.Limits.validate(); err != nil {
+		return err
+	}
+	if err := o.Server.validate(); err != nil {
+		return err
+	}
+	return o.CORS.validate()
+}
+
+// authOptions select how callers are authenticated.
+type authOptions struct {
+	Methods      []string `long:"auth" env:"AIRPORTS_AUTH" env-delim:"," description:"how callers are authenticated, repeatable: none lets everyone in, api-key requires an API key, jwt a bearer token and mtls a client certificate granting the scope each route requires" choice:"none" choice:"api-key" choice:"jwt" choice:"mtls" default:"none"`
+	BootstrapKey string   `long:"bootstrap-api-key" env:"AIRPORTS_BOOTSTRAP_API_KEY" description:"API key accepted with the admin scope without being stored, so that the first keys can be created"`
+
+	JWKS                string        `long:"jwks" env:"AIRPORTS_JWKS" description:"file or http(s) URL of the JWKS document bearer tokens are verified against, required by jwt"`
+	JWTIssuer           string        `long:"jwt-issuer" env:"AIRPORTS_JWT_ISSUER" description:"iss claim bearer tokens must carry, not checked when empty"`
+	JWTAudience         string        `long:"jwt-audience" env:"AIRPORTS_JWT_AUDIENCE" description:"aud claim bearer tokens must carry, not checked when empty"`
+	JWTScopesClaim      string        `long:"jwt-scopes-claim" env:"AIRPORTS_JWT_SCOPES_CLAIM" description:"claim of bearer tokens scopes are read from, either a space-separated string or an array" default:"scope"`
+	JWKSRefreshInterval time.Duration `long:"jwks-refresh-interval" env:"AIRPORTS_JWKS_REFRESH_INTERVAL" description:"how often the JWKS document is reloaded; it is also reloaded, at most once a minute, when tokens are signed with unknown keys" default:"1h"`
+
+	MTLSIdentities string `long:"mtls-identities" env:"AIRPORTS_MTLS_IDENTITIES" description:"JSON file mapping client certificate subjects to the scopes they are granted, required by mtls; reloaded on SIGHUP"`
+}
+
+// enabled reports whether callers may authenticate with the given method.
+func (o authOptions) enabled(method string) bool {
+	for _, m := range o.Methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// validate checks that the enabled methods have what they require.
+func (o authOptions) validate(tls tlsOptions) error {
+	if o.enabled("jwt") && o.JWKS == "" {
+		return errors.New("--jwks is required by jwt authentication")
+	}
+	if o.enabled("mtls") {
+		if tls.ClientCAFile == "" {
+			return errors.New("--tls-client-ca is required by mtls authentication")
+		}
+		if o.MTLSIdentities == "" {
+			return errors.New("--mtls-identities is required by mtls authentication")
+		}
+	}
+	return nil
+}
+
+// jwt returns the bearer token settings.
+func (o authOptions) jwt() jwtauth.Config {
+	return jwtauth.Config{
+		Issuer:      o.JWTIssuer,
+		Audience:    o.JWTAudience,
+		ScopesClaim: o.JWTScopesClaim,
+	}
+}
+
+// rateLimitOptions limit how fast each client, identified by its API key,
+// bearer token subject or IP address, may send requests and ingest
+// airports, and how fast each IP address may send requests, whether they
+// carry valid credentials or not.
+type rateLimitOptions struct {
+	Rate        float64 `long:"rate-limit" env:"AIRPORTS_RATE_LIMIT" description:"requests per second each client may send, unlimited when 0" default:"0"`
+	Burst       int     `long:"rate-limit-burst" env:"AIRPORTS_RATE_LIMIT_BURST" description:"requests each client may send at once, before being held to --rate-limit" default:"20"`
+	IPRate      float64 `long:"ip-rate-limit" env:"AIRPORTS_IP_RATE_LIMIT" description:"requests per second each IP address may send, checked before authentication, unlimited when 0" default:"0"`
+	IPBurst     int     `long:"ip-rate-limit-burst" env:"AIRPORTS_IP_RATE_LIMIT_BURST" description:"requests each IP address may send at once, before being held to --ip-rate-limit" default:"20"`
+	IngestQuota int     `long:"ingest-quota" env:"AIRPORTS_INGEST_QUOTA" description:"airports per minute each client may ingest through the upsert endpoints, unlimited when 0" default:"0"`
+}
+
+// validate checks the limits.
+func (o rateLimitOptions) validate() error {
+	if o.Rate < 0 {
+		return errors.Errorf("invalid rate limit %v: expected a positive number, or 0 for unlimited", o.Rate)
+	}
+	if o.Rate > 0 && o.Burst < 1 {
+		return errors.Errorf("invalid rate limit burst %d: expected at least 1", o.Burst)
+	}
+	if o.IPRate < 0 {
+		return errors.Errorf("invalid IP rate limit %v: expected a positive number, or 0 for unlimited", o.IPRate)
+	}
+	if o.IPRate > 0 && o.IPBurst < 1 {
+		return errors.Errorf("invalid IP rate limit burst %d: expected at least 1", o.IPBurst)
+	}
+	if o.IngestQuota < 0 {
+		return errors.Errorf("invalid ingest quota %d: expected a positive number, or 0 for unlimited", o.IngestQuota)
+	}
+	return nil
+}
+
+// limiters returns the limiters of the requests each client and each IP
+// address sends and of the airports each client ingests, nil when
+// unlimited.
+func (o rateLimitOptions) limiters() (requests, ipRequests, ingest *ratelimit.Limiter) {
+	if o.Rate > 0 {
+		requests = ratelimit.NewLimiter(o.Rate, o.Burst)
+	}
+	if o.IPRate > 0 {
+		ipRequests = ratelimit.NewLimiter(o.IPRate, o.IPBurst)
+	}
+	if o.IngestQuota > 0 {
+		ingest = ratelimit.NewLimiter(float64(o.IngestQuota)/60, o.IngestQuota)
+	}
+	return requests, ipRequests, ingest
+}
+
+// limitsOptions bound the size of request bodies and of the airports
+// upsert requests carry.
+type limitsOptions struct {
+	MaxBodySize             int64 `long:"max-body-size" env:"AIRPORTS_MAX_BODY_SIZE" description:"maximum size in bytes of request bodies, but upserts', unlimited when 0" default:"1048576"`
+	MaxUpsertBodySize       int64 `long:"max-upsert-body-size" env:"AIRPORTS_MAX_UPSERT_BODY_SIZE" description:"maximum size in bytes of streaming upsert request bodies, unlimited when 0" default:"0"`
+	MaxNonStreamingBodySize int64 `long:"max-nonstreaming-body-size" env:"AIRPORTS_MAX_NONSTREAMING_BODY_SIZE" description:"maximum size in bytes of non-streaming upsert request bodies, which are read into memory, unlimited when 0" default:"67108864"`
+	MaxAirportSize          int64 `long:"max-airport-size" env:"AIRPORTS_MAX_AIRPORT_SIZE" description:"maximum size in bytes of each airport in upsert requests, unlimited when 0" default:"65536"`
+	MaxAirportsPerRequest   int   `long:"max-airports-per-request" env:"AIRPORTS_MAX_AIRPORTS_PER_REQUEST" description:"maximum number of airports per upsert request, unlimited when 0" default:"0"`
+	StrictJSON              bool  `long:"strict-json" env:"AIRPORTS_STRICT_JSON" description:"reject airports with unknown fields in upsert requests, which are ignored otherwise"`
+}
+
+// validate checks the limits.
+func (o limitsOptions) validate() error {
+	sizes := []struct {
+		name string
+		size int64
+	}{
+		{"max body size", o.MaxBodySize},
+		{"max upsert body size", o.MaxUpsertBodySize},
+		{"max non-streaming body size", o.MaxNonStreamingBodySize},
+		{"max airport size", o.MaxAirportSize},
+	}
+	for _, s := range sizes {
+		if s.size < 0 {
+			return errors.Errorf("invalid %s %d: expected a positive number of bytes, or 0 for unlimited", s.name, s.size)
+		}
+	}
+	if o.MaxAirportsPerRequest < 0 {
+		return errors.Errorf("invalid max airports per request %d: expected a positive number, or 0 for unlimited", o.MaxAirportsPerRequest)
+	}
+	return nil
+}
+
+// body returns the maximum sizes of request bodies.
+func (o limitsOptions) body() v1.BodyLimits {
+	return v1.BodyLimits{
+		Upsert:             o.MaxUpsertBodySize,
+		NonStreamingUpsert: o.MaxNonStreamingBodySize,
+		Default:            o.MaxBodySize,
+	}
+}
+
+// upsert returns the limits of the airports upsert requests carry.
+func (o limitsOptions) upsert() airportshandlers.Limits {
+	return airportshandlers.Limits{
+		MaxAirportBytes: o.MaxAirportSize,
+		MaxAirports:     o.MaxAirportsPerRequest,
+		Strict:          o.StrictJSON,
+	}
+}
+
+// serverOptions protect the server from slow and idle clients.
+type serverOptions struct {
+	ReadHeaderTimeout   time.Duration `long:"read-header-timeout" env:"AIRPORTS_READ_HEADER_TIMEOUT" description:"how long clients may take to send request headers, unlimited when 0" default:"10s"`
+	ReadTimeout         time.Duration `long:"read-timeout" env:"AIRPORTS_READ_TIMEOUT" description:"how long clients may take to send whole requests, but streaming uploads when --min-upload-rate is set, unlimited when 0" default:"1m"`
+	IdleTimeout         time.Duration `long:"idle-timeout" env:"AIRPORTS_IDLE_TIMEOUT" description:"how long keep-alive connections may stay idle, --read-timeout when 0" default:"2m"`
+	MaxHeaderBytes      int           `long:"max-header-bytes" env:"AIRPORTS_MAX_HEADER_BYTES" description:"maximum size in bytes of request headers" default:"1048576"`
+	MinUploadRate       int64         `long:"min-upload-rate" env:"AIRPORTS_MIN_UPLOAD_RATE" description:"bytes per second streaming uploads must keep up with, or be aborted, unchecked when 0" default:"1024"`
+	MinUploadRateWindow time.Duration `long:"min-upload-rate-window" env:"AIRPORTS_MIN_UPLOAD_RATE_WINDOW" description:"how long streaming uploads are measured over to check they keep up with --min-upload-rate" default:"30s"`
+}
+
+// validate checks the server settings.
+func (o serverOptions) validate() error {
+	timeouts := []struct {
+		name    string
+		timeout time.Duration
+	}{
+		{"read header timeout", o.ReadHeaderTimeout},
+		{"read timeout", o.ReadTimeout},
+		{"idle timeout", o.IdleTimeout},
+	}
+	for _, t := range timeouts {
+		if t.timeout < 0 {
+			return errors.Errorf("invalid %s %v: expected a positive duration, or 0 for unlimited", t.name, t.timeout)
+		}
+	}
+	if o.MaxHeaderBytes < 1 {
+		return errors.Errorf("invalid max header bytes %d: expected a positive number", o.MaxHeaderBytes)
+	}
+	if o.MinUploadRate < 0 {
+		return errors.Errorf("invalid min upload rate %d: expected a positive number, or 0 for unchecked", o.MinUploadRate)
+	}
+	if o.MinUploadRate > 0 && o.MinUploadRateWindow <= 0 {
+		return errors.Errorf("invalid min upload rate window %v: expected a positive duration", o.MinUploadRateWindow)
+	}
+	return nil
+}
+
+// corsOptions tell which cross-origin requests browsers are allowed to send,
+// so that web apps served from other origins can call the API.
+type corsOptions struct {
+	AllowedOrigins   []string      `long:"cors-allowed-origin" env:"AIRPORTS_CORS_ALLOWED_ORIGINS" env-delim:"," description:"origin browsers may send requests from, repeatable, * allowing any; cross-origin requests are not allowed when none is given"`
+	AllowedMethods   []string      `long:"cors-allowed-method" env:"AIRPORTS_CORS_ALLOWED_METHODS" env-delim:"," description:"method cross-origin requests may use, repeatable" default:"GET" default:"POST" default:"DELETE"`
+	AllowedHeaders   []string      `long:"cors-allowed-header" env:"AIRPORTS_CORS_ALLOWED_HEADERS" env-delim:"," description:"header cross-origin requests may carry, repeatable, * allowing any" default:"Authorization" default:"Content-Type" default:"X-API-Key" default:"X-Request-ID"`
+	AllowCredentials bool          `long:"cors-allow-credentials" env:"AIRPORTS_CORS_ALLOW_CREDENTIALS" description:"let cross-origin requests carry credentials, such as cookies"`
+	ExposedHeaders   []string      `long:"cors-exposed-header" env:"AIRPORTS_CORS_EXPOSED_HEADERS" env-delim:"," description:"response header pages sending cross-origin requests may read, repeatable" default:"X-Request-ID" default:"Retry-After" default:"RateLimit-Limit" default:"RateLimit-Remaining" default:"RateLimit-Reset"`
+	MaxAge           time.Duration `long:"cors-max-age" env:"AIRPORTS_CORS_MAX_AGE" description:"how long browsers may cache preflight responses, left to them when 0" default:"10m"`
+}
+
+// validate checks the CORS settings.
+func (o corsOptions) validate() error {
+	for _, origin := range o.AllowedOrigins {
+		if origin == "*" && o.AllowCredentials {
+			return errors.New("invalid CORS allowed origin *: credentials are only allowed from explicit origins")
+		}
+	}
+	if o.MaxAge < 0 {
+		return errors.Errorf("invalid CORS max age %v: expected a positive duration, or 0 to leave it to browsers", o.MaxAge)
+	}
+	return nil
+}
+
+// cors returns the CORS settings, nil when cross-origin requests are not
+// allowed.
+func (o corsOptions) cors() *middleware.CORSOptions {
+	if len(o.AllowedOrigins) == 0 {
+		return nil
+	}
+	return &middleware.CORSOptions{
+		AllowedOrigins:   o.AllowedOrigins,
+		AllowedMethods:   o.AllowedMethods,
+		AllowedHeaders:   o.AllowedHeaders,
+		AllowCredentials: o.AllowCredentials,
+		ExposedHeaders:   o.ExposedHeaders,
+		MaxAge:           o.MaxAge,
+	}
+}
+
+// tlsOptions set up serving over HTTPS, and optionally verifying client
+// certificates.
+type tlsOptions struct {
+	CertFile          string `long:"tls-cert" env:"AIRPORTS_TLS_CERT" description:"PEM encoded certificate served over HTTPS along with --tls-key, followed by its intermediates; plain HTTP is served when empty; reloaded on SIGHUP"`
+	KeyFile           string `long:"tls-key" env:"AIRPORTS_TLS_KEY" description:"PEM encoded private key of --tls-cert; reloaded on SIGHUP"`
+	ClientCAFile      string `long:"tls-client-ca" env:"AIRPORTS_TLS_CLIENT_CA" description:"PEM encoded CA bundle client certificates are verified against, required by mtls; reloaded on SIGHUP"`
+	RequireClientCert bool   `long:"tls-require-client-cert" env:"AIRPORTS_TLS_REQUIRE_CLIENT_CERT" description:"refuse connections from clients not sending a certificate signed by --tls-client-ca"`
+}
+
+// enabled reports whether HTTPS is served.
+func (o tlsOptions) enabled() bool {
+	return o.CertFile != ""
+}
+
+// validate checks the TLS settings.
+func (o tlsOptions) validate() error {
+	if (o.CertFile == "") != (o.KeyFile == "") {
+		return errors.New("--tls-cert and --tls-key must be set together")
+	}
+	if o.ClientCAFile != "" && !o.enabled() {
+		return errors.New("--tls-client-ca requires --tls-cert and --tls-key")
+	}
+	if o.RequireClientCert && o.ClientCAFile == "" {
+		return errors.New("--tls-require-client-cert requires --tls-client-ca")
+	}
+	return nil
+}
+
+// certs returns the files certificates are loaded from.
+func (o tlsOptions) certs() certs.Config {
+	return certs.Config{
+		CertFile:          o.CertFile,
+		KeyFile:           o.KeyFile,
+		ClientCAFile:      o.ClientCAFile,
+		RequireClientCert: o.RequireClientCert,
+	}
 }
 
-func run(port int, log *slog.Logger) error {
+// loggingOptions tune what is logged.
+type loggingOptions struct {
+	Level               string  `long:"log-level" env:"AIRPORTS_LOG_LEVEL" description:"minimum level of the records logged" choice:"debug" choice:"info" choice:"warn" choice:"error" default:"info"`
+	AccessLogSampleRate float64 `long:"access-log-sample-rate" env:"AIRPORTS_ACCESS_LOG_SAMPLE_RATE" description:"fraction of successful requests logged, between 0 and 1; failed ones are always logged" default:"1"`
+}
+
+// tracingOptions select where OpenTelemetry spans are exported to.
+type tracingOptions struct {
+	Exporter     string  `long:"trace-exporter" env:"AIRPORTS_TRACE_EXPORTER" description:"where spans are exported to: none, stdout, or an OTLP/HTTP collector" choice:"none" choice:"stdout" choice:"otlp" default:"none"`
+	OTLPEndpoint string  `long:"otlp-endpoint" env:"AIRPORTS_OTLP_ENDPOINT" description:"URL of the OTLP/HTTP collector, such as http://localhost:4318; the standard OTEL_EXPORTER_OTLP_* variables apply when empty"`
+	SampleRatio  float64 `long:"trace-sample-ratio" env:"AIRPORTS_TRACE_SAMPLE_RATIO" description:"fraction of traces started by the service that are sampled, between 0 and 1; traces started by callers follow their decision" default:"1"`
+}
+
+// config returns the tracing settings.
+func (o tracingOptions) config() tracing.Config {
+	return tracing.Config{
+		Exporter:     o.Exporter,
+		OTLPEndpoint: o.OTLPEndpoint,
+		SampleRatio:  o.SampleRatio,
+	}
+}
+
+// databaseOptions locate and tune the database. The pool settings apply to
+// PostgreSQL as well.
+type databaseOptions struct {
+	Path            string        `long:"db-path" env:"AIRPORTS_DB_PATH" description:"SQLite database file, used when --dsn is empty" default:"db/airportsRestApi.db"`
+	JournalMode     string        `long:"sqlite-journal-mode" env:"AIRPORTS_SQLITE_JOURNAL_MODE" description:"SQLite journal mode: DELETE, TRUNCATE, PERSIST, MEMORY, WAL or OFF" default:"WAL"`
+	Synchronous     string        `long:"sqlite-synchronous" env:"AIRPORTS_SQLITE_SYNCHRONOUS" description:"SQLite synchronous setting: OFF, NORMAL, FULL or EXTRA" default:"NORMAL"`
+	BusyTimeout     time.Duration `long:"sqlite-busy-timeout" env:"AIRPORTS_SQLITE_BUSY_TIMEOUT" description:"how long to wait for a locked SQLite database before failing" default:"5s"`
+	MaxOpenConns    int           `long:"db-max-open-conns" env:"AIRPORTS_DB_MAX_OPEN_CONNS" description:"maximum number of open database connections, unlimited when 0; on SQLite, applies to the reader pool only, the writer always having a single connection" default:"0"`
+	MaxIdleConns    int           `long:"db-max-idle-conns" env:"AIRPORTS_DB_MAX_IDLE_CONNS" description:"maximum number of idle database connections, database/sql's default when 0; on SQLite, applies to the reader pool only, the writer always having a single connection" default:"0"`
+	ConnMaxLifetime time.Duration `long:"db-conn-max-lifetime" env:"AIRPORTS_DB_CONN_MAX_LIFETIME" description:"how long a database connection may be reused, forever when 0" default:"0"`
+}
+
+// pool returns the connection pool settings.
+func (o databaseOptions) pool() db.PoolConfig {
+	return db.PoolConfig{
+		MaxOpenConns:    o.MaxOpenConns,
+		MaxIdleConns:    o.MaxIdleConns,
+		ConnMaxLifetime: o.ConnMaxLifetime,
+	}
+}
+
+// sqlite returns the SQLite settings.
+func (o databaseOptions) sqlite() db.SqliteConfig {
+	return db.SqliteConfig{
+		Path:        o.Path,
+		JournalMode: o.JournalMode,
+		Synchronous: o.Synchronous,
+		BusyTimeout: o.BusyTimeout,
+		Pool:        o.pool(),
+	}
+}
+
+// validate checks the settings of the database the options point at.
+func (o databaseOptions) validate(dsn string) error {
+	if dsn == "" {
+		return o.sqlite().Validate()
+	}
+	return o.pool().Validate()
+}
+
+func run(opts options, log *slog.Logger) error {
 	ctx := context.Background()
 	defer log.InfoContext(ctx, "Completed")
 
+	// =========================================================================
+	// Options
+
+	if err := opts.validate(); err != nil {
+		return err
+	}
+
+	// =========================================================================
+	// Tracing
+
+	shutdownTracing, err := tracing.Setup(ctx, opts.Tracing.config())
+	if err != nil {
+		return errors.Wrap(err, "setting up tracing")
+	}
+	defer func() {
+		// Flush the spans not exported yet.
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			log.ErrorContext(ctx, "could not shut tracing down", slog.Any("err", err))
+		}
+	}()
+
+	// =========================================================================
+	// Database migrations
+
+	if err := migrateDatabase(opts, log); err != nil {
+		return err
+	}
+
 	// =========================================================================
 	// Database support
 
-	const sqliteDbFile = "db/airportsRestApi.db"
-	db, err := db.ConnectToSqlite(sqliteDbFile)
+	db, store, apiKeyStore, err := openStore(opts)
+	if err != nil {
+		return err
+	}
+	// db is nil for the in-memory store.
+	if db != nil {
+		defer db.Close()
+	}
+	store = metrics.InstrumentStore(store)
+
+	// =========================================================================
+	// Autocomplete index
+
+	// Exported rather than listed, so that fuzzy matching gets their
+	// localized names along with them.
+	var allAirports []airports.Airport
+	if err := store.Export(ctx, func(a airports.Airport) error {
+		allAirports = append(allAirports, a)
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "loading airports into the autocomplete index")
+	}
+	index := autocomplete.NewIndex()
+	index.Load(allAirports)
+	log.InfoContext(ctx, "autocomplete index loaded", slog.Int("airports", index.Len()))
+
+	// =========================================================================
+	// Snapshots
+
+	snapshotStore, err := snapshots.NewStore(opts.SnapshotsDir)
 	if err != nil {
-		return errors.Wrapf(err, "opening database file %s", sqliteDbFile)
+		return errors.Wrap(err, "opening snapshots store")
+	}
+
+	// =========================================================================
+	// Authentication
+
+	// Callers may authenticate with any of the enabled methods. API key
+	// routes are only served along with API key authentication.
+	var authenticators auth.Chain
+	if opts.Auth.enabled("api-key") {
+		authenticators = append(authenticators, apikeys.NewAuthenticator(apiKeyStore, opts.Auth.BootstrapKey))
+	} else {
+		apiKeyStore = nil
+	}
+	if opts.Auth.enabled("jwt") {
+		keys, err := jwtauth.NewKeySet(ctx, opts.Auth.JWKS, opts.Auth.JWKSRefreshInterval, log)
+		if err != nil {
+			return errors.Wrap(err, "loading JWKS")
+		}
+		authenticators = append(authenticators, jwtauth.NewAuthenticator(keys, opts.Auth.jwt()))
+	}
+	var certAuthenticator *certauth.Authenticator
+	if opts.Auth.enabled("mtls") {
+		certAuthenticator, err = certauth.NewAuthenticator(opts.Auth.MTLSIdentities)
+		if err != nil {
+			return errors.Wrap(err, "loading mTLS identities")
+		}
+		authenticators = append(authenticators, certAuthenticator)
+	}
+	var authenticator auth.Authenticator
+	if len(authenticators) > 0 {
+		authenticator = authenticators
 	}
-	defer db.Close()
+
+	// =========================================================================
+	// Rate limiting
+
+	rateLimiter, ipRateLimiter, ingestQuota := opts.RateLimit.limiters()
 
 	// =========================================================================
 	// API Service
 
 	apiMux := handlers.NewApiMux(&handlers.ApiMuxConfig{
-		Db:  db,
-		Log: log,
+		Store:               store,
+		Index:               index,
+		Snapshots:           snapshotStore,
+		Log:                 log,
+		AccessLogSampleRate: opts.Logging.AccessLogSampleRate,
+		Authenticator:       authenticator,
+		APIKeys:             apiKeyStore,
+		RateLimiter:         rateLimiter,
+		IPRateLimiter:       ipRateLimiter,
+		IngestQuota:         ingestQuota,
+		BodyLimits:          opts.Limits.body(),
+		UpsertLimits:        opts.Limits.upsert(),
+		MinUploadRate:       opts.Server.MinUploadRate,
+		MinUploadRateWindow: opts.Server.MinUploadRateWindow,
+		CORS:                opts.CORS.cors(),
 	})
 
 	// Server to service the requests against the mux.
 	srv := http.Server{
-		Addr:    fmt.Sprintf(":%d", port),
-		Handler: apiMux,
+		Addr:              fmt.Sprintf(":%d", opts.Port),
+		Handler:           apiMux,
+		ReadHeaderTimeout: opts.Server.ReadHeaderTimeout,
+		ReadTimeout:       opts.Server.ReadTimeout,
+		IdleTimeout:       opts.Server.IdleTimeout,
+		MaxHeaderBytes:    opts.Server.MaxHeaderBytes,
+	}
+
+	// Certificates served over HTTPS.
+	var certReloader *certs.Reloader
+	if opts.TLS.enabled() {
+		certReloader, err = certs.NewReloader(opts.TLS.certs())
+		if err != nil {
+			return err
+		}
+		srv.TLSConfig = certReloader.TLSConfig()
 	}
 
 	// Channel to listen for an interrupt or terminate signal from the OS.
 	shutdown := make(chan os.Signal, 1)
 	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
 
+	// Channel to listen for a hangup signal from the OS, upon which
+	// certificates are reloaded.
+	if certReloader != nil || certAuthenticator != nil {
+		reload := make(chan os.Signal, 1)
+		signal.Notify(reload, syscall.SIGHUP)
+		defer signal.Stop(reload)
+		go reloadCerts(ctx, log, reload, certReloader, certAuthenticator)
+	}
+
 	// Channel to listen for errors coming from the listener.
 	serverErrors := make(chan error, 1)
 
 	// Start the service listening for api requests.
 	go func() {
+		if certReloader != nil {
+			log.Info(fmt.Sprintf("API listening on %s over TLS", srv.Addr))
+			serverErrors <- srv.ListenAndServeTLS("", "")
+			return
+		}
 		log.Info(fmt.Sprintf("API listening on %s", srv.Addr))
 		serverErrors <- srv.ListenAndServe()
 	}()
@@ -82,6 +603,9 @@ func run(port int, log *slog.Logger) error {
 			return errors.Wrap(err, "could not stop server gracefully")
 		}
 		// Close the database connection.
+		if db == nil {
+			return nil
+		}
 		if err := db.Close(); err != nil {
 			return errors.Wrap(err, "could not close database connection")
 		}
@@ -89,6 +613,115 @@ func run(port int, log *slog.Logger) error {
 	return nil
 }
 
+// reloadCerts reloads the served certificates and the mTLS identities,
+// those of them in use, each time a signal is received, keeping the
+// previous ones when they cannot be loaded.
+func reloadCerts(ctx context.Context, log *slog.Logger, signals <-chan os.Signal, certReloader *certs.Reloader, certAuthenticator *certauth.Authenticator) {
+	for range signals {
+		if certReloader != nil {
+			if err := certReloader.Reload(); err != nil {
+				log.ErrorContext(ctx, "reloading certificates", slog.Any("err", err))
+			} else {
+				log.InfoContext(ctx, "certificates reloaded")
+			}
+		}
+		if certAuthenticator != nil {
+			if err := certAuthenticator.Reload(); err != nil {
+				log.ErrorContext(ctx, "reloading mTLS identities", slog.Any("err", err))
+			} else {
+				log.InfoContext(ctx, "mTLS identities reloaded")
+			}
+		}
+	}
+}
+
+// openStore creates the airport and API key stores selected by the given
+// options: in-memory ones, or ones on top of the database the DSN points
+// at, that is, PostgreSQL for postgres:// and postgresql:// URLs, or the
+// SQLite file when it is empty.
+func openStore(opts options) (io.Closer, airports.AirportStore, apikeys.Store, error) {
+	dsn := opts.Dsn
+	switch {
+	case opts.Store == "memory":
+		if dsn != "" {
+			return nil, nil, nil, errors.New("--dsn cannot be used along with --store=memory")
+		}
+		return nil, airports.NewMemoryStore(), apikeys.NewMemoryStore(), nil
+	case dsn == "":
+		pools, err := db.ConnectToSqlite(opts.Database.sqlite())
+		if err != nil {
+			return nil, nil, nil, errors.Wrapf(err, "opening database file %s", opts.Database.Path)
+		}
+		if err := db.CheckSqliteFTS5(pools.Writer); err != nil {
+			pools.Close()
+			return nil, nil, nil, err
+		}
+		if err := registerDBStats(map[string]*sql.DB{"sqlite_writer": pools.Writer, "sqlite_reader": pools.Reader}); err != nil {
+			pools.Close()
+			return nil, nil, nil, err
+		}
+		return pools, airports.NewSqliteStore(pools.Writer, pools.Reader), apikeys.NewSQLStore(pools.Writer, pools.Reader), nil
+	case isPostgresDsn(dsn):
+		db, err := db.ConnectToPostgres(dsn)
+		if err != nil {
+			return nil, nil, nil, errors.Wrap(err, "opening postgres database")
+		}
+		opts.Database.pool().Apply(db)
+		if err := registerDBStats(map[string]*sql.DB{"postgres": db}); err != nil {
+			db.Close()
+			return nil, nil, nil, err
+		}
+		return db, airports.NewPostgresStore(db), apikeys.NewSQLStore(db, db), nil
+	default:
+		return nil, nil, nil, errors.New("unsupported DSN: expected a postgres:// or postgresql:// URL")
+	}
+}
+
+// registerDBStats exposes the statistics of the given connection pools,
+// keyed by the name they are labeled with, as Prometheus metrics.
+func registerDBStats(pools map[string]*sql.DB) error {
+	for name, pool := range pools {
+		if err := metrics.RegisterDBStats(name, pool); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateDatabase applies or checks the embedded migrations of the
+// database the options point at, according to --migrate. The in-memory
+// store has none.
+func migrateDatabase(opts options, log *slog.Logger) error {
+	if opts.Store == "memory" || opts.Migrate == db.MigrateOff {
+		return nil
+	}
+	var (
+		version uint
+		err     error
+	)
+	switch {
+	case opts.Dsn == "":
+		version, err = db.MigrateSqlite(opts.Database.sqlite(), opts.Migrate)
+	case isPostgresDsn(opts.Dsn):
+		version, err = db.MigratePostgres(opts.Dsn, opts.Migrate)
+	default:
+		return errors.New("unsupported DSN: expected a postgres:// or postgresql:// URL")
+	}
+	if errors.Is(err, db.ErrSchemaBehind) {
+		return errors.Wrap(err, "refusing to serve: run with --migrate=auto to apply pending migrations")
+	}
+	if err != nil {
+		return errors.Wrap(err, "migrating database")
+	}
+	log.Info("database schema is up to date", slog.Uint64("version", uint64(version)))
+	return nil
+}
+
+// isPostgresDsn tells whether the DSN points at a PostgreSQL database.
+func isPostgresDsn(dsn string) bool {
+	return strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://")
+}
+
 func main() {
 	var opts options
 	parser := flags.NewParser(&opts, flags.Default)
@@ -96,8 +729,13 @@ func main() {
 	if err != nil {
 		os.Exit(1)
 	}
-	log := slog.New(slog.NewJSONHandler(os.Stdout, nil))
-	if err := run(opts.Port, log); err != nil {
+	level, err := logger.ParseLevel(opts.Logging.Level)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	log := logger.New(os.Stdout, level)
+	if err := run(opts, log); err != nil {
 		log.Error("error", slog.Any("err", err))
 		os.Exit(1)
 	}
@@ -0,0 +1,310 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jessevdk/go-flags"
+	"github.com/stretchr/testify/require"
+)
+
+// defaultOptions returns the options the service starts with when given
+// no more than a port.
+func defaultOptions(t *testing.T) options {
+	var opts options
+	_, err := flags.NewParser(&opts, flags.None).ParseArgs([]string{"-p", "4444"})
+	require.NoError(t, err)
+	return opts
+}
+
+func TestOptionsValidate(t *testing.T) {
+	testCases := []struct {
+		name          string
+		opts          func(o *options)
+		expectedError error
+	}{
+		{
+			name: "happy path",
+			opts: func(o *options) {},
+		},
+		{
+			name:          "invalid access log sample rate",
+			opts:          func(o *options) { o.Logging.AccessLogSampleRate = 2 },
+			expectedError: errors.New("invalid access log sample rate 2: expected a number between 0 and 1"),
+		},
+		{
+			name:          "invalid database options",
+			opts:          func(o *options) { o.Database.JournalMode = "fast" },
+			expectedError: errors.New(`invalid database options: invalid sqlite journal mode "fast": expected one of DELETE, TRUNCATE, PERSIST, MEMORY, WAL, OFF`),
+		},
+		{
+			name: "database options ignored by the in-memory store",
+			opts: func(o *options) {
+				o.Store = "memory"
+				o.Database.JournalMode = "fast"
+			},
+		},
+		{
+			name:          "invalid TLS options",
+			opts:          func(o *options) { o.TLS.CertFile = "cert.pem" },
+			expectedError: errors.New("--tls-cert and --tls-key must be set together"),
+		},
+		{
+			name:          "invalid auth options",
+			opts:          func(o *options) { o.Auth.Methods = []string{"jwt"} },
+			expectedError: errors.New("--jwks is required by jwt authentication"),
+		},
+		{
+			name:          "invalid server options",
+			opts:          func(o *options) { o.Server.ReadTimeout = -time.Second },
+			expectedError: errors.New("invalid read timeout -1s: expected a positive duration, or 0 for unlimited"),
+		},
+		{
+			name:          "invalid CORS options",
+			opts:          func(o *options) { o.CORS.MaxAge = -time.Second },
+			expectedError: errors.New("invalid CORS max age -1s: expected a positive duration, or 0 to leave it to browsers"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := defaultOptions(t)
+			tc.opts(&opts)
+			err := opts.validate()
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf(`expected no error, got "%v"`, err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else if tc.expectedError != nil {
+				t.Fatalf(`expected error "%v", got nil`, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestServerOptionsValidate(t *testing.T) {
+	testCases := []struct {
+		name          string
+		opts          func(o *serverOptions)
+		expectedError error
+	}{
+		{
+			name: "happy path",
+			opts: func(o *serverOptions) {},
+		},
+		{
+			name: "unlimited timeouts and unchecked upload rate",
+			opts: func(o *serverOptions) {
+				o.ReadHeaderTimeout = 0
+				o.ReadTimeout = 0
+				o.IdleTimeout = 0
+				o.MinUploadRate = 0
+				o.MinUploadRateWindow = 0
+			},
+		},
+		{
+			name:          "negative read header timeout",
+			opts:          func(o *serverOptions) { o.ReadHeaderTimeout = -time.Second },
+			expectedError: errors.New("invalid read header timeout -1s: expected a positive duration, or 0 for unlimited"),
+		},
+		{
+			name:          "negative idle timeout",
+			opts:          func(o *serverOptions) { o.IdleTimeout = -time.Second },
+			expectedError: errors.New("invalid idle timeout -1s: expected a positive duration, or 0 for unlimited"),
+		},
+		{
+			name:          "no header bytes",
+			opts:          func(o *serverOptions) { o.MaxHeaderBytes = 0 },
+			expectedError: errors.New("invalid max header bytes 0: expected a positive number"),
+		},
+		{
+			name:          "negative min upload rate",
+			opts:          func(o *serverOptions) { o.MinUploadRate = -1 },
+			expectedError: errors.New("invalid min upload rate -1: expected a positive number, or 0 for unchecked"),
+		},
+		{
+			name:          "no min upload rate window",
+			opts:          func(o *serverOptions) { o.MinUploadRateWindow = 0 },
+			expectedError: errors.New("invalid min upload rate window 0s: expected a positive duration"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := defaultOptions(t).Server
+			tc.opts(&opts)
+			err := opts.validate()
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf(`expected no error, got "%v"`, err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else if tc.expectedError != nil {
+				t.Fatalf(`expected error "%v", got nil`, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestCorsOptionsValidate(t *testing.T) {
+	testCases := []struct {
+		name          string
+		opts          func(o *corsOptions)
+		expectedError error
+	}{
+		{
+			name: "happy path",
+			opts: func(o *corsOptions) {
+				o.AllowedOrigins = []string{"https://example.com"}
+				o.AllowCredentials = true
+			},
+		},
+		{
+			name: "any origin without credentials",
+			opts: func(o *corsOptions) { o.AllowedOrigins = []string{"*"} },
+		},
+		{
+			name: "any origin with credentials",
+			opts: func(o *corsOptions) {
+				o.AllowedOrigins = []string{"https://example.com", "*"}
+				o.AllowCredentials = true
+			},
+			expectedError: errors.New("invalid CORS allowed origin *: credentials are only allowed from explicit origins"),
+		},
+		{
+			name:          "negative max age",
+			opts:          func(o *corsOptions) { o.MaxAge = -time.Second },
+			expectedError: errors.New("invalid CORS max age -1s: expected a positive duration, or 0 to leave it to browsers"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := defaultOptions(t).CORS
+			tc.opts(&opts)
+			err := opts.validate()
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf(`expected no error, got "%v"`, err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else if tc.expectedError != nil {
+				t.Fatalf(`expected error "%v", got nil`, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestTlsOptionsValidate(t *testing.T) {
+	testCases := []struct {
+		name          string
+		opts          tlsOptions
+		expectedError error
+	}{
+		{
+			name: "plain HTTP",
+		},
+		{
+			name: "HTTPS verifying client certificates",
+			opts: tlsOptions{
+				CertFile:          "cert.pem",
+				KeyFile:           "key.pem",
+				ClientCAFile:      "ca.pem",
+				RequireClientCert: true,
+			},
+		},
+		{
+			name:          "certificate without key",
+			opts:          tlsOptions{CertFile: "cert.pem"},
+			expectedError: errors.New("--tls-cert and --tls-key must be set together"),
+		},
+		{
+			name:          "key without certificate",
+			opts:          tlsOptions{KeyFile: "key.pem"},
+			expectedError: errors.New("--tls-cert and --tls-key must be set together"),
+		},
+		{
+			name:          "client CA over plain HTTP",
+			opts:          tlsOptions{ClientCAFile: "ca.pem"},
+			expectedError: errors.New("--tls-client-ca requires --tls-cert and --tls-key"),
+		},
+		{
+			name: "client certificate required without client CA",
+			opts: tlsOptions{
+				CertFile:          "cert.pem",
+				KeyFile:           "key.pem",
+				RequireClientCert: true,
+			},
+			expectedError: errors.New("--tls-require-client-cert requires --tls-client-ca"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.opts.validate()
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf(`expected no error, got "%v"`, err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else if tc.expectedError != nil {
+				t.Fatalf(`expected error "%v", got nil`, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestAuthOptionsValidate(t *testing.T) {
+	testCases := []struct {
+		name          string
+		opts          authOptions
+		tls           tlsOptions
+		expectedError error
+	}{
+		{
+			name: "no authentication",
+			opts: authOptions{Methods: []string{"none"}},
+		},
+		{
+			name: "every method",
+			opts: authOptions{
+				Methods:        []string{"api-key", "jwt", "mtls"},
+				JWKS:           "https://example.com/.well-known/jwks.json",
+				MTLSIdentities: "identities.json",
+			},
+			tls: tlsOptions{CertFile: "cert.pem", KeyFile: "key.pem", ClientCAFile: "ca.pem"},
+		},
+		{
+			name:          "jwt without JWKS",
+			opts:          authOptions{Methods: []string{"jwt"}},
+			expectedError: errors.New("--jwks is required by jwt authentication"),
+		},
+		{
+			name:          "mtls without client CA",
+			opts:          authOptions{Methods: []string{"mtls"}, MTLSIdentities: "identities.json"},
+			tls:           tlsOptions{CertFile: "cert.pem", KeyFile: "key.pem"},
+			expectedError: errors.New("--tls-client-ca is required by mtls authentication"),
+		},
+		{
+			name:          "mtls without identities",
+			opts:          authOptions{Methods: []string{"mtls"}},
+			tls:           tlsOptions{CertFile: "cert.pem", KeyFile: "key.pem", ClientCAFile: "ca.pem"},
+			expectedError: errors.New("--mtls-identities is required by mtls authentication"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.opts.validate(tc.tls)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf(`expected no error, got "%v"`, err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else if tc.expectedError != nil {
+				t.Fatalf(`expected error "%v", got nil`, tc.expectedError)
+			}
+		})
+	}
+}
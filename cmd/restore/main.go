@@ -0,0 +1,50 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+// Command restore swaps the SQLite database for a backup taken through
+// the backup endpoint, once its schema version is validated. The service
+// must be stopped while it runs.
+package main
+
+import (
+	"log/slog"
+	"os"
+
+	"github.com/jessevdk/go-flags"
+	"github.com/pkg/errors"
+	"github.com/tiagomelo/go-airports-service/db"
+)
+
+type options struct {
+	Backup string `short:"b" long:"backup" description:"backup file to restore" required:"true"`
+	Path   string `long:"db-path" env:"AIRPORTS_DB_PATH" description:"SQLite database file to replace" default:"db/airportsRestApi.db"`
+}
+
+func run(opts options, log *slog.Logger) error {
+	version, previous, err := db.RestoreSqlite(opts.Backup, opts.Path)
+	if err != nil {
+		return errors.Wrapf(err, "restoring %s", opts.Backup)
+	}
+	log.Info("database restored",
+		slog.String("backup", opts.Backup),
+		slog.String("path", opts.Path),
+		slog.String("previous", previous),
+		slog.Uint64("version", uint64(version)),
+	)
+	return nil
+}
+
+func main() {
+	var opts options
+	parser := flags.NewParser(&opts, flags.Default)
+	_, err := parser.Parse()
+	if err != nil {
+		os.Exit(1)
+	}
+	log := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	if err := run(opts, log); err != nil {
+		log.Error("error", slog.Any("err", err))
+		os.Exit(1)
+	}
+}
@@ -1,34 +1,86 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
 package airports
 
 import (
 	"context"
-	"database/sql"
 
 	"github.com/pkg/errors"
 )
 
+// Name types an airport can be known by.
+const (
+	NameTypeOfficial = "official"
+	NameTypeAlias    = "alias"
+)
+
+// ErrNotFound is returned when an airport does not exist.
+var ErrNotFound = errors.New("airport not found")
+
+// AirportStore is implemented by the storage backends airports are kept in.
+type AirportStore interface {
+	// Upsert inserts or updates an airport.
+	Upsert(ctx context.Context, airport *Airport) error
+	// UpsertBatch inserts or updates several airports atomically.
+	UpsertBatch(ctx context.Context, airports []*Airport) error
+	// Get returns the airport with the given IATA code along with its
+	// localized names, or ErrNotFound.
+	Get(ctx context.Context, iataCode string) (*Airport, error)
+	// List returns the airports matching the given filter ordered by IATA
+	// code, without their localized names.
+	List(ctx context.Context, filter ListFilter) ([]Airport, error)
+	// Delete deletes the airport with the given IATA code along with its
+	// localized names, or returns ErrNotFound.
+	Delete(ctx context.Context, iataCode string) error
+	// Search performs a full-text search over airports, best matches first.
+	Search(ctx context.Context, query string, limit int) ([]SearchResult, error)
+	// FindDuplicates calls fn for each group of probable duplicate airports.
+	FindDuplicates(ctx context.Context, radiusMeters float64, fn func(Duplicate) error) error
+	// Export calls fn for every airport along with its localized names,
+	// ordered by IATA code, as seen at a single point in time.
+	Export(ctx context.Context, fn func(Airport) error) error
+	// ReplaceAll atomically replaces all airports with the given ones.
+	ReplaceAll(ctx context.Context, airports []*Airport) error
+	// PreviewUpsert returns how upserting the given airports would change
+	// the stored ones, without changing them.
+	PreviewUpsert(ctx context.Context, airports []*Airport) (Diff, error)
+}
+
 type Airport struct {
-	Name     string `json:"name"`
-	City     string `json:"city"`
-	Country  string `json:"country"`
-	IataCode string `json:"iata_code"`
+	Name     string          `json:"name"`
+	City     string          `json:"city"`
+	Country  string          `json:"country"`
+	IataCode string          `json:"iata_code"`
+	Geoloc   *Geoloc         `json:"geoloc,omitempty"`
+	Names    []LocalizedName `json:"names,omitempty"`
+}
+
+// Geoloc holds an airport's coordinates, in decimal degrees.
+type Geoloc struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// LocalizedName is a name an airport is known by in a given language,
+// either its official name or an alias (e.g. "Roissy" for CDG).
+type LocalizedName struct {
+	Lang  string `json:"lang"`
+	Value string `json:"value"`
+	Type  string `json:"type"`
+}
+
+// ListFilter narrows down the airports returned by List. Empty fields
+// match any value; non-empty ones are compared case-insensitively.
+type ListFilter struct {
+	Country string
+	City    string
 }
 
-const upsertQuery = `
-INSERT INTO airports (name, city, country, iata_code)
-VALUES ($1, $2, $3, $4)
-ON CONFLICT (iata_code) DO UPDATE
-SET name = $1, city = $2, country = $3
-`
-
-func Upsert(ctx context.Context, db *sql.DB, airport *Airport) error {
-	if _, err := db.ExecContext(ctx, upsertQuery,
-		airport.Name,
-		airport.City,
-		airport.Country,
-		airport.IataCode,
-	); err != nil {
-		return errors.Wrap(err, "upserting airport")
-	}
-	return nil
+// SearchResult is an airport matching a full-text search, along with
+// its relevance score (the higher, the better).
+type SearchResult struct {
+	Airport
+	Score float64 `json:"score"`
 }
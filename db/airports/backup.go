@@ -0,0 +1,69 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package airports
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// Backuper is implemented by stores able to take consistent backups of
+// their database while it keeps serving reads and writes.
+type Backuper interface {
+	// Backup takes a consistent snapshot of the database and returns it
+	// along with its size in bytes. Closing it discards the snapshot.
+	Backup(ctx context.Context) (io.ReadCloser, int64, error)
+}
+
+// backupQuery writes a consistent, compacted copy of the database into a
+// new file.
+const backupQuery = `VACUUM INTO $1`
+
+// Backup takes a consistent snapshot of the SQLite database with VACUUM
+// INTO. It goes through the reader pool, so writes are not blocked while
+// it runs.
+func (s *SqliteStore) Backup(ctx context.Context) (io.ReadCloser, int64, error) {
+	dir, err := os.MkdirTemp("", "airports-backup-")
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "creating backup directory")
+	}
+	path := filepath.Join(dir, "airports.db")
+	if _, err := s.reader.ExecContext(ctx, backupQuery, path); err != nil {
+		os.RemoveAll(dir)
+		return nil, 0, errors.Wrap(err, "backing up database")
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, 0, errors.Wrap(err, "opening backup")
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		os.RemoveAll(dir)
+		return nil, 0, errors.Wrap(err, "getting backup size")
+	}
+	return &tempFile{File: f, dir: dir}, info.Size(), nil
+}
+
+// tempFile is a file within a temporary directory, which is removed along
+// with it once the file is closed.
+type tempFile struct {
+	*os.File
+	dir string
+}
+
+// Close closes the file and removes its directory.
+func (f *tempFile) Close() error {
+	err := f.File.Close()
+	if rmErr := os.RemoveAll(f.dir); err == nil {
+		err = rmErr
+	}
+	return err
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package airports
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackup(t *testing.T) {
+	testCases := []struct {
+		name          string
+		mockClosure   func() *sql.DB
+		expectedRows  int
+		expectedError error
+	}{
+		{
+			name: "happy path",
+			mockClosure: func() *sql.DB {
+				db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+				require.NoError(t, err)
+				_, err = db.Exec(`CREATE TABLE airports (iata_code TEXT); INSERT INTO airports VALUES ('CDG'), ('GRU')`)
+				require.NoError(t, err)
+				return db
+			},
+			expectedRows: 2,
+		},
+		{
+			name: "error",
+			mockClosure: func() *sql.DB {
+				db, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				mock.ExpectExec(regexp.QuoteMeta(backupQuery)).
+					WithArgs(sqlmock.AnyArg()).
+					WillReturnError(errors.New("disk I/O error"))
+				return db
+			},
+			expectedError: errors.New("backing up database: disk I/O error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := tc.mockClosure()
+			defer db.Close()
+			backup, size, err := NewSqliteStore(db, db).Backup(context.TODO())
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf(`expected no error, got "%v"`, err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+				return
+			}
+			if tc.expectedError != nil {
+				t.Fatalf(`expected error "%v", got nil`, tc.expectedError)
+			}
+			path := filepath.Join(t.TempDir(), "backup.db")
+			f, err := os.Create(path)
+			require.NoError(t, err)
+			n, err := io.Copy(f, backup)
+			require.NoError(t, err)
+			require.Equal(t, size, n)
+			require.NoError(t, f.Close())
+			require.NoError(t, backup.Close())
+			require.NoDirExists(t, filepath.Dir(backup.(*tempFile).Name()))
+
+			restored, err := sql.Open("sqlite3", path)
+			require.NoError(t, err)
+			defer restored.Close()
+			var rows int
+			require.NoError(t, restored.QueryRow(`SELECT COUNT(*) FROM airports`).Scan(&rows))
+			require.Equal(t, tc.expectedRows, rows)
+		})
+	}
+}
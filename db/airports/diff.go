@@ -0,0 +1,112 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package airports
+
+import (
+	"slices"
+	"sort"
+)
+
+// Fields an airport change can be reported for.
+const (
+	FieldName    = "name"
+	FieldCity    = "city"
+	FieldCountry = "country"
+	FieldGeoloc  = "geoloc"
+	FieldNames   = "names"
+)
+
+// FieldChange is a field whose value differs between two versions of an
+// airport.
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   any    `json:"old"`
+	New   any    `json:"new"`
+}
+
+// AirportChange holds the fields that differ between two versions of an
+// airport.
+type AirportChange struct {
+	IataCode string        `json:"iata_code"`
+	Fields   []FieldChange `json:"fields"`
+}
+
+// Diff holds the differences between two versions of a set of airports,
+// each of its lists ordered by IATA code.
+type Diff struct {
+	Added     []Airport       `json:"added"`
+	Removed   []Airport       `json:"removed"`
+	Changed   []AirportChange `json:"changed"`
+	Unchanged int             `json:"unchanged"`
+}
+
+// CompareAirports returns the fields that differ from one version of an
+// airport to another, if any.
+func CompareAirports(before, after *Airport) []FieldChange {
+	var changes []FieldChange
+	compare := func(field string, changed bool, oldValue, newValue any) {
+		if changed {
+			changes = append(changes, FieldChange{Field: field, Old: oldValue, New: newValue})
+		}
+	}
+	compare(FieldName, before.Name != after.Name, before.Name, after.Name)
+	compare(FieldCity, before.City != after.City, before.City, after.City)
+	compare(FieldCountry, before.Country != after.Country, before.Country, after.Country)
+	compare(FieldGeoloc, !equalGeolocs(before.Geoloc, after.Geoloc), before.Geoloc, after.Geoloc)
+	compare(FieldNames, !equalNames(before.Names, after.Names), before.Names, after.Names)
+	return changes
+}
+
+// DiffAirports compares two versions of a set of airports, matching them
+// by IATA code.
+func DiffAirports(before, after []Airport) Diff {
+	diff := Diff{
+		Added:   []Airport{},
+		Removed: []Airport{},
+		Changed: []AirportChange{},
+	}
+	byIataCode := make(map[string]*Airport, len(before))
+	for i := range before {
+		byIataCode[before[i].IataCode] = &before[i]
+	}
+	for i := range after {
+		a := &after[i]
+		prev, ok := byIataCode[a.IataCode]
+		if !ok {
+			diff.Added = append(diff.Added, *a)
+			continue
+		}
+		delete(byIataCode, a.IataCode)
+		if changes := CompareAirports(prev, a); len(changes) > 0 {
+			diff.Changed = append(diff.Changed, AirportChange{IataCode: a.IataCode, Fields: changes})
+		} else {
+			diff.Unchanged++
+		}
+	}
+	for _, a := range byIataCode {
+		diff.Removed = append(diff.Removed, *a)
+	}
+	sort.Slice(diff.Added, func(i, j int) bool { return diff.Added[i].IataCode < diff.Added[j].IataCode })
+	sort.Slice(diff.Removed, func(i, j int) bool { return diff.Removed[i].IataCode < diff.Removed[j].IataCode })
+	sort.Slice(diff.Changed, func(i, j int) bool { return diff.Changed[i].IataCode < diff.Changed[j].IataCode })
+	return diff
+}
+
+// equalGeolocs tells whether two coordinates, which may be missing, are
+// the same.
+func equalGeolocs(a, b *Geoloc) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// equalNames tells whether two lists of localized names are the same,
+// taking names without a type as official ones.
+func equalNames(a, b []LocalizedName) bool {
+	return slices.EqualFunc(a, b, func(x, y LocalizedName) bool {
+		return x.Lang == y.Lang && x.Value == y.Value && nameType(x) == nameType(y)
+	})
+}
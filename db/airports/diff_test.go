@@ -0,0 +1,110 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package airports
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareAirports(t *testing.T) {
+	cdg := Airport{
+		Name:     "Charles De Gaulle",
+		City:     "Paris",
+		Country:  "France",
+		IataCode: "CDG",
+		Geoloc:   &Geoloc{Lat: 49.012779, Lng: 2.55},
+		Names:    []LocalizedName{{Lang: "fr", Value: "Aéroport Paris-Charles-de-Gaulle", Type: NameTypeOfficial}},
+	}
+	testCases := []struct {
+		name           string
+		after          func(a Airport) Airport
+		expectedOutput []FieldChange
+	}{
+		{
+			name: "unchanged",
+			after: func(a Airport) Airport {
+				a.Geoloc = &Geoloc{Lat: 49.012779, Lng: 2.55}
+				a.Names = []LocalizedName{{Lang: "fr", Value: "Aéroport Paris-Charles-de-Gaulle"}}
+				return a
+			},
+		},
+		{
+			name: "name and city",
+			after: func(a Airport) Airport {
+				a.Name = "Paris Charles de Gaulle"
+				a.City = "Roissy-en-France"
+				return a
+			},
+			expectedOutput: []FieldChange{
+				{Field: FieldName, Old: "Charles De Gaulle", New: "Paris Charles de Gaulle"},
+				{Field: FieldCity, Old: "Paris", New: "Roissy-en-France"},
+			},
+		},
+		{
+			name: "coordinates removed",
+			after: func(a Airport) Airport {
+				a.Geoloc = nil
+				return a
+			},
+			expectedOutput: []FieldChange{
+				{Field: FieldGeoloc, Old: &Geoloc{Lat: 49.012779, Lng: 2.55}, New: (*Geoloc)(nil)},
+			},
+		},
+		{
+			name: "alias added",
+			after: func(a Airport) Airport {
+				a.Names = append(a.Names, LocalizedName{Lang: "fr", Value: "Roissy", Type: NameTypeAlias})
+				return a
+			},
+			expectedOutput: []FieldChange{
+				{
+					Field: FieldNames,
+					Old:   []LocalizedName{{Lang: "fr", Value: "Aéroport Paris-Charles-de-Gaulle", Type: NameTypeOfficial}},
+					New: []LocalizedName{
+						{Lang: "fr", Value: "Aéroport Paris-Charles-de-Gaulle", Type: NameTypeOfficial},
+						{Lang: "fr", Value: "Roissy", Type: NameTypeAlias},
+					},
+				},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			before := cdg
+			after := tc.after(cdg)
+			require.Equal(t, tc.expectedOutput, CompareAirports(&before, &after))
+		})
+	}
+}
+
+func TestDiffAirports(t *testing.T) {
+	before := []Airport{
+		{Name: "Orly", City: "Paris", Country: "France", IataCode: "ORY"},
+		{Name: "Charles De Gaulle", City: "Paris", Country: "France", IataCode: "CDG"},
+		{Name: "Heathrow", City: "London", Country: "United Kingdom", IataCode: "LHR"},
+	}
+	after := []Airport{
+		{Name: "Paris Charles de Gaulle", City: "Paris", Country: "France", IataCode: "CDG"},
+		{Name: "Orly", City: "Paris", Country: "France", IataCode: "ORY"},
+		{Name: "Guarulhos", City: "Sao Paulo", Country: "Brazil", IataCode: "GRU"},
+	}
+	require.Equal(t, Diff{
+		Added:   []Airport{{Name: "Guarulhos", City: "Sao Paulo", Country: "Brazil", IataCode: "GRU"}},
+		Removed: []Airport{{Name: "Heathrow", City: "London", Country: "United Kingdom", IataCode: "LHR"}},
+		Changed: []AirportChange{
+			{IataCode: "CDG", Fields: []FieldChange{{Field: FieldName, Old: "Charles De Gaulle", New: "Paris Charles de Gaulle"}}},
+		},
+		Unchanged: 1,
+	}, DiffAirports(before, after))
+
+	require.Equal(t, Diff{
+		Added:     []Airport{},
+		Removed:   []Airport{},
+		Changed:   []AirportChange{},
+		Unchanged: 3,
+	}, DiffAirports(before, before))
+}
@@ -0,0 +1,187 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package airports
+
+import (
+	"context"
+	"database/sql"
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+// Reasons why airports are reported as probable duplicates.
+const (
+	DuplicateReasonSameNameAndCity = "same_name_and_city"
+	DuplicateReasonNearby          = "nearby"
+)
+
+const (
+	// earthRadiusMeters is the mean radius of the Earth.
+	earthRadiusMeters = 6371008.8
+	// metersPerDegreeOfLatitude is a lower bound of the length of a degree
+	// of latitude, so that the latitude window never misses a candidate.
+	metersPerDegreeOfLatitude = 110574.0
+)
+
+// Duplicate is a group of airports that probably are the same one.
+type Duplicate struct {
+	Reason         string    `json:"reason"`
+	Airports       []Airport `json:"airports"`
+	DistanceMeters *float64  `json:"distance_meters,omitempty"`
+}
+
+const sameNameAndCityQuery = `
+SELECT g.name_key, g.city_key, a.name, a.city, a.country, a.iata_code, a.latitude, a.longitude
+FROM airports a
+JOIN (
+    SELECT lower(trim(name)) AS name_key, lower(trim(city)) AS city_key
+    FROM airports
+    GROUP BY name_key, city_key
+    HAVING COUNT(*) > 1
+) g ON lower(trim(a.name)) = g.name_key AND lower(trim(a.city)) = g.city_key
+ORDER BY g.name_key, g.city_key, a.iata_code
+`
+
+const withCoordinatesQuery = `
+SELECT name, city, country, iata_code, latitude, longitude
+FROM airports
+WHERE latitude IS NOT NULL AND longitude IS NOT NULL
+ORDER BY latitude
+`
+
+// findDuplicates scans the airports table for probable duplicates and calls
+// fn for each one as it is found: first groups of airports sharing the same
+// name and city under different IATA codes, then pairs of airports whose
+// coordinates are within radiusMeters of each other.
+func findDuplicates(ctx context.Context, db *sql.DB, radiusMeters float64, fn func(Duplicate) error) error {
+	if err := findSameNameAndCity(ctx, db, fn); err != nil {
+		return err
+	}
+	return findNearby(ctx, db, radiusMeters, fn)
+}
+
+// findSameNameAndCity reports airports sharing the same name and city,
+// ignoring case and surrounding spaces.
+func findSameNameAndCity(ctx context.Context, db *sql.DB, fn func(Duplicate) error) error {
+	rows, err := db.QueryContext(ctx, sameNameAndCityQuery)
+	if err != nil {
+		return errors.Wrap(err, "finding airports with the same name and city")
+	}
+	defer rows.Close()
+	var (
+		group                    []Airport
+		prevNameKey, prevCityKey string
+	)
+	flush := func() error {
+		if len(group) == 0 {
+			return nil
+		}
+		d := Duplicate{Reason: DuplicateReasonSameNameAndCity, Airports: group}
+		group = nil
+		return fn(d)
+	}
+	for rows.Next() {
+		var (
+			nameKey, cityKey string
+			a                Airport
+			lat, lng         sql.NullFloat64
+		)
+		if err := rows.Scan(&nameKey, &cityKey, &a.Name, &a.City, &a.Country, &a.IataCode, &lat, &lng); err != nil {
+			return errors.Wrap(err, "scanning airport")
+		}
+		if lat.Valid && lng.Valid {
+			a.Geoloc = &Geoloc{Lat: lat.Float64, Lng: lng.Float64}
+		}
+		if nameKey != prevNameKey || cityKey != prevCityKey {
+			if err := flush(); err != nil {
+				return err
+			}
+			prevNameKey, prevCityKey = nameKey, cityKey
+		}
+		group = append(group, a)
+	}
+	if err := rows.Err(); err != nil {
+		return errors.Wrap(err, "iterating airports")
+	}
+	return flush()
+}
+
+// findNearby reports pairs of airports within radiusMeters of each other.
+func findNearby(ctx context.Context, db *sql.DB, radiusMeters float64, fn func(Duplicate) error) error {
+	rows, err := db.QueryContext(ctx, withCoordinatesQuery)
+	if err != nil {
+		return errors.Wrap(err, "finding nearby airports")
+	}
+	defer rows.Close()
+	finder := newNearbyFinder(radiusMeters, fn)
+	for rows.Next() {
+		a, err := scanAirport(rows)
+		if err != nil {
+			return errors.Wrap(err, "scanning airport")
+		}
+		if err := finder.add(*a); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return errors.Wrap(err, "iterating airports")
+	}
+	return nil
+}
+
+// nearbyFinder reports pairs of airports within radiusMeters of each other.
+// Airports must be added ordered by latitude, so each one only needs to be
+// compared against the window of previous airports close enough in latitude.
+type nearbyFinder struct {
+	radiusMeters float64
+	latWindow    float64
+	window       []Airport
+	fn           func(Duplicate) error
+}
+
+// newNearbyFinder creates a nearbyFinder calling fn for each pair found.
+func newNearbyFinder(radiusMeters float64, fn func(Duplicate) error) *nearbyFinder {
+	return &nearbyFinder{
+		radiusMeters: radiusMeters,
+		latWindow:    radiusMeters / metersPerDegreeOfLatitude,
+		fn:           fn,
+	}
+}
+
+// add compares an airport with coordinates against the previous ones.
+func (f *nearbyFinder) add(a Airport) error {
+	start := 0
+	for start < len(f.window) && a.Geoloc.Lat-f.window[start].Geoloc.Lat > f.latWindow {
+		start++
+	}
+	f.window = f.window[start:]
+	for _, other := range f.window {
+		distance := DistanceMeters(*other.Geoloc, *a.Geoloc)
+		if distance > f.radiusMeters {
+			continue
+		}
+		if err := f.fn(Duplicate{
+			Reason:         DuplicateReasonNearby,
+			Airports:       []Airport{other, a},
+			DistanceMeters: &distance,
+		}); err != nil {
+			return err
+		}
+	}
+	f.window = append(f.window, a)
+	return nil
+}
+
+// DistanceMeters returns the great-circle distance between two points
+// using the haversine formula.
+func DistanceMeters(from, to Geoloc) float64 {
+	toRadians := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := toRadians(to.Lat - from.Lat)
+	dLng := toRadians(to.Lng - from.Lng)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(from.Lat))*math.Cos(toRadians(to.Lat))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(h)))
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package airports
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindDuplicates(t *testing.T) {
+	sameNameAndCityColumns := []string{"name_key", "city_key", "name", "city", "country", "iata_code", "latitude", "longitude"}
+	withCoordinatesColumns := []string{"name", "city", "country", "iata_code", "latitude", "longitude"}
+	testCases := []struct {
+		name           string
+		mockClosure    func() *sql.DB
+		expectedOutput []Duplicate
+		expectedError  error
+	}{
+		{
+			name: "happy path",
+			mockClosure: func() *sql.DB {
+				db, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				mock.ExpectQuery(regexp.QuoteMeta(sameNameAndCityQuery)).
+					WillReturnRows(sqlmock.NewRows(sameNameAndCityColumns).
+						AddRow("heathrow", "london", "Heathrow", "London", "United Kingdom", "LHR", nil, nil).
+						AddRow("heathrow", "london", "heathrow ", "London", "United Kingdom", "XLH", nil, nil).
+						AddRow("midway", "chicago", "Midway", "Chicago", "United States", "MDW", nil, nil).
+						AddRow("midway", "chicago", "Midway", "Chicago", "United States", "XMD", nil, nil))
+				mock.ExpectQuery(regexp.QuoteMeta(withCoordinatesQuery)).
+					WillReturnRows(sqlmock.NewRows(withCoordinatesColumns).
+						AddRow("Congonhas", "Sao Paulo", "Brazil", "CGH", -23.626692, -46.655375).
+						AddRow("Congonhas Airport", "Sao Paulo", "Brazil", "XCG", -23.6260, -46.6560).
+						AddRow("Guarulhos", "Sao Paulo", "Brazil", "GRU", -23.432075, -46.469511))
+				return db
+			},
+			expectedOutput: []Duplicate{
+				{
+					Reason: DuplicateReasonSameNameAndCity,
+					Airports: []Airport{
+						{Name: "Heathrow", City: "London", Country: "United Kingdom", IataCode: "LHR"},
+						{Name: "heathrow ", City: "London", Country: "United Kingdom", IataCode: "XLH"},
+					},
+				},
+				{
+					Reason: DuplicateReasonSameNameAndCity,
+					Airports: []Airport{
+						{Name: "Midway", City: "Chicago", Country: "United States", IataCode: "MDW"},
+						{Name: "Midway", City: "Chicago", Country: "United States", IataCode: "XMD"},
+					},
+				},
+				{
+					Reason: DuplicateReasonNearby,
+					Airports: []Airport{
+						{Name: "Congonhas", City: "Sao Paulo", Country: "Brazil", IataCode: "CGH", Geoloc: &Geoloc{Lat: -23.626692, Lng: -46.655375}},
+						{Name: "Congonhas Airport", City: "Sao Paulo", Country: "Brazil", IataCode: "XCG", Geoloc: &Geoloc{Lat: -23.6260, Lng: -46.6560}},
+					},
+				},
+			},
+		},
+		{
+			name: "error",
+			mockClosure: func() *sql.DB {
+				db, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				mock.ExpectQuery(regexp.QuoteMeta(sameNameAndCityQuery)).
+					WillReturnError(sql.ErrConnDone)
+				return db
+			},
+			expectedError: errors.New("finding airports with the same name and city: sql: connection is already closed"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := tc.mockClosure()
+			var output []Duplicate
+			err := NewSqliteStore(db, db).FindDuplicates(context.TODO(), 500, func(d Duplicate) error {
+				d.DistanceMeters = nil
+				output = append(output, d)
+				return nil
+			})
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf(`expected no error, got "%v"`, err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf(`expected error "%v", got nil`, tc.expectedError)
+				}
+				require.Equal(t, tc.expectedOutput, output)
+			}
+		})
+	}
+}
+
+func TestDistanceMeters(t *testing.T) {
+	lhr := Geoloc{Lat: 51.4775, Lng: -0.461389}
+	cdg := Geoloc{Lat: 49.009722, Lng: 2.547778}
+	require.InDelta(t, 347_500, DistanceMeters(lhr, cdg), 1_000)
+	require.Zero(t, DistanceMeters(lhr, lhr))
+}
@@ -0,0 +1,402 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package airports
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Weights given to each field an in-memory search term matches, mirroring
+// the bm25 column weights used by SqliteStore.
+const (
+	searchWeightIataCode = 10
+	searchWeightName     = 5
+	searchWeightAliases  = 4
+	searchWeightCity     = 3
+	searchWeightCountry  = 1
+)
+
+// MemoryStore is an AirportStore that keeps airports in memory only,
+// indexed by IATA code and, case-insensitively, by country and city.
+// Nothing survives a restart, which makes it handy for tests and demos.
+type MemoryStore struct {
+	mu        sync.RWMutex
+	airports  map[string]*Airport
+	byCountry map[string]map[string]struct{}
+	byCity    map[string]map[string]struct{}
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		airports:  make(map[string]*Airport),
+		byCountry: make(map[string]map[string]struct{}),
+		byCity:    make(map[string]map[string]struct{}),
+	}
+}
+
+// Upsert inserts or updates an airport. Existing coordinates are kept when
+// none are given, and existing localized names are only replaced when the
+// airport carries any.
+func (s *MemoryStore) Upsert(ctx context.Context, airport *Airport) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.upsert(airport)
+	return nil
+}
+
+// UpsertBatch inserts or updates several airports at once.
+func (s *MemoryStore) UpsertBatch(ctx context.Context, airports []*Airport) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, airport := range airports {
+		s.upsert(airport)
+	}
+	return nil
+}
+
+// ReplaceAll replaces all airports with the given ones at once.
+func (s *MemoryStore) ReplaceAll(ctx context.Context, airports []*Airport) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.airports = make(map[string]*Airport, len(airports))
+	s.byCountry = make(map[string]map[string]struct{})
+	s.byCity = make(map[string]map[string]struct{})
+	for _, airport := range airports {
+		s.upsert(airport)
+	}
+	return nil
+}
+
+// PreviewUpsert returns how upserting the given airports would change the
+// stored ones. The stored airports they would touch are copied into a
+// scratch store, which the airports are then upserted into.
+func (s *MemoryStore) PreviewUpsert(ctx context.Context, airports []*Airport) (Diff, error) {
+	scratch := NewMemoryStore()
+	before := []Airport{}
+	s.mu.RLock()
+	for _, iataCode := range uniqueIataCodes(airports) {
+		if prev, ok := s.airports[iataCode]; ok {
+			scratch.upsert(prev)
+			before = append(before, *copyAirport(prev))
+		}
+	}
+	s.mu.RUnlock()
+	for _, airport := range airports {
+		scratch.upsert(airport)
+	}
+	after := make([]Airport, 0, len(scratch.airports))
+	for _, a := range scratch.airports {
+		after = append(after, *a)
+	}
+	return DiffAirports(before, after), nil
+}
+
+// upsert stores a copy of the airport. The caller must hold the write lock.
+func (s *MemoryStore) upsert(airport *Airport) {
+	stored := copyAirport(airport)
+	for i := range stored.Names {
+		stored.Names[i].Type = nameType(stored.Names[i])
+	}
+	if prev, ok := s.airports[airport.IataCode]; ok {
+		if stored.Geoloc == nil {
+			stored.Geoloc = prev.Geoloc
+		}
+		if len(stored.Names) == 0 {
+			stored.Names = prev.Names
+		}
+		s.unindex(prev)
+	}
+	s.airports[stored.IataCode] = stored
+	addToIndex(s.byCountry, indexKey(stored.Country), stored.IataCode)
+	addToIndex(s.byCity, indexKey(stored.City), stored.IataCode)
+}
+
+// unindex removes an airport from the secondary indexes. The caller must
+// hold the write lock.
+func (s *MemoryStore) unindex(airport *Airport) {
+	removeFromIndex(s.byCountry, indexKey(airport.Country), airport.IataCode)
+	removeFromIndex(s.byCity, indexKey(airport.City), airport.IataCode)
+}
+
+// Get returns the airport with the given IATA code along with its
+// localized names.
+func (s *MemoryStore) Get(ctx context.Context, iataCode string) (*Airport, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	airport, ok := s.airports[iataCode]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return copyAirport(airport), nil
+}
+
+// List returns the airports matching the given filter ordered by IATA
+// code, without their localized names.
+func (s *MemoryStore) List(ctx context.Context, filter ListFilter) ([]Airport, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	airports := []Airport{}
+	add := func(a *Airport) {
+		listed := *copyAirport(a)
+		listed.Names = nil
+		airports = append(airports, listed)
+	}
+	byCountry, byCity := s.byCountry[indexKey(filter.Country)], s.byCity[indexKey(filter.City)]
+	switch {
+	case filter.Country == "" && filter.City == "":
+		for _, a := range s.airports {
+			add(a)
+		}
+	case filter.City == "":
+		for iataCode := range byCountry {
+			add(s.airports[iataCode])
+		}
+	case filter.Country == "":
+		for iataCode := range byCity {
+			add(s.airports[iataCode])
+		}
+	default:
+		if len(byCity) < len(byCountry) {
+			byCountry, byCity = byCity, byCountry
+		}
+		for iataCode := range intersect(byCountry, byCity) {
+			add(s.airports[iataCode])
+		}
+	}
+	sort.Slice(airports, func(i, j int) bool {
+		return airports[i].IataCode < airports[j].IataCode
+	})
+	return airports, nil
+}
+
+// Delete deletes the airport with the given IATA code along with its
+// localized names.
+func (s *MemoryStore) Delete(ctx context.Context, iataCode string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	airport, ok := s.airports[iataCode]
+	if !ok {
+		return ErrNotFound
+	}
+	s.unindex(airport)
+	delete(s.airports, iataCode)
+	return nil
+}
+
+// Search matches every term of the query against the words of airports'
+// IATA codes, names, aliases, cities and countries, ignoring case and
+// diacritics. The last term is matched as a prefix. Airports are scored by
+// the weights of the fields each term matched, the higher, the better.
+func (s *MemoryStore) Search(ctx context.Context, query string, limit int) ([]SearchResult, error) {
+	terms := searchTerms(foldForSearch(query))
+	results := []SearchResult{}
+	if len(terms) == 0 {
+		return results, nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, a := range s.airports {
+		if score := searchScore(a, terms); score > 0 {
+			listed := *copyAirport(a)
+			listed.Names = nil
+			results = append(results, SearchResult{Airport: listed, Score: score})
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].IataCode < results[j].IataCode
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// searchScore returns the score of an airport for the given folded terms,
+// or zero when any of them does not match.
+func searchScore(a *Airport, terms []string) float64 {
+	aliases := make([]string, 0, len(a.Names))
+	for _, n := range a.Names {
+		aliases = append(aliases, n.Value)
+	}
+	fields := []struct {
+		words  []string
+		weight float64
+	}{
+		{searchTerms(foldForSearch(a.IataCode)), searchWeightIataCode},
+		{searchTerms(foldForSearch(a.Name)), searchWeightName},
+		{searchTerms(foldForSearch(strings.Join(aliases, " "))), searchWeightAliases},
+		{searchTerms(foldForSearch(a.City)), searchWeightCity},
+		{searchTerms(foldForSearch(a.Country)), searchWeightCountry},
+	}
+	var score float64
+	for i, term := range terms {
+		prefix := i == len(terms)-1
+		var termScore float64
+		for _, f := range fields {
+			for _, w := range f.words {
+				if w == term || (prefix && strings.HasPrefix(w, term)) {
+					termScore += f.weight
+					break
+				}
+			}
+		}
+		if termScore == 0 {
+			return 0
+		}
+		score += termScore
+	}
+	return score
+}
+
+// FindDuplicates reports the same probable duplicates SqliteStore does:
+// first groups of airports sharing the same name and city under different
+// IATA codes, then pairs of airports whose coordinates are within
+// radiusMeters of each other.
+func (s *MemoryStore) FindDuplicates(ctx context.Context, radiusMeters float64, fn func(Duplicate) error) error {
+	s.mu.RLock()
+	all := make([]Airport, 0, len(s.airports))
+	for _, a := range s.airports {
+		listed := *copyAirport(a)
+		listed.Names = nil
+		all = append(all, listed)
+	}
+	s.mu.RUnlock()
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].IataCode < all[j].IataCode
+	})
+	type groupKey struct{ name, city string }
+	groups := make(map[groupKey][]Airport)
+	for _, a := range all {
+		key := groupKey{strings.ToLower(strings.TrimSpace(a.Name)), strings.ToLower(strings.TrimSpace(a.City))}
+		groups[key] = append(groups[key], a)
+	}
+	keys := make([]groupKey, 0, len(groups))
+	for key, group := range groups {
+		if len(group) > 1 {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].name != keys[j].name {
+			return keys[i].name < keys[j].name
+		}
+		return keys[i].city < keys[j].city
+	})
+	for _, key := range keys {
+		if err := fn(Duplicate{Reason: DuplicateReasonSameNameAndCity, Airports: groups[key]}); err != nil {
+			return err
+		}
+	}
+	var withCoordinates []Airport
+	for _, a := range all {
+		if a.Geoloc != nil {
+			withCoordinates = append(withCoordinates, a)
+		}
+	}
+	sort.SliceStable(withCoordinates, func(i, j int) bool {
+		return withCoordinates[i].Geoloc.Lat < withCoordinates[j].Geoloc.Lat
+	})
+	finder := newNearbyFinder(radiusMeters, fn)
+	for _, a := range withCoordinates {
+		if err := finder.add(a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Export calls fn for every airport along with its localized names,
+// ordered by IATA code. Airports are copied under the read lock, so fn sees
+// them as they were at a single point in time.
+func (s *MemoryStore) Export(ctx context.Context, fn func(Airport) error) error {
+	s.mu.RLock()
+	all := make([]Airport, 0, len(s.airports))
+	for _, a := range s.airports {
+		all = append(all, *copyAirport(a))
+	}
+	s.mu.RUnlock()
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].IataCode < all[j].IataCode
+	})
+	for _, a := range all {
+		if err := fn(a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyAirport returns a deep copy of an airport, so that callers can
+// neither change stored airports nor be affected by later upserts.
+func copyAirport(a *Airport) *Airport {
+	c := *a
+	if a.Geoloc != nil {
+		geoloc := *a.Geoloc
+		c.Geoloc = &geoloc
+	}
+	if a.Names != nil {
+		c.Names = append([]LocalizedName(nil), a.Names...)
+	}
+	return &c
+}
+
+// indexKey returns the key a country or city is indexed under.
+func indexKey(s string) string {
+	return strings.ToLower(s)
+}
+
+// addToIndex adds an IATA code to the set stored under key.
+func addToIndex(index map[string]map[string]struct{}, key, iataCode string) {
+	set, ok := index[key]
+	if !ok {
+		set = make(map[string]struct{})
+		index[key] = set
+	}
+	set[iataCode] = struct{}{}
+}
+
+// removeFromIndex removes an IATA code from the set stored under key,
+// dropping the set once it is empty.
+func removeFromIndex(index map[string]map[string]struct{}, key, iataCode string) {
+	set := index[key]
+	delete(set, iataCode)
+	if len(set) == 0 {
+		delete(index, key)
+	}
+}
+
+// intersect returns the IATA codes present in both sets. It iterates over
+// the first one, which should be the smallest.
+func intersect(a, b map[string]struct{}) map[string]struct{} {
+	result := make(map[string]struct{})
+	for iataCode := range a {
+		if _, ok := b[iataCode]; ok {
+			result[iataCode] = struct{}{}
+		}
+	}
+	return result
+}
+
+// foldForSearch lowercases s and strips its diacritics.
+func foldForSearch(s string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(t, s)
+	if err != nil {
+		folded = s
+	}
+	return strings.ToLower(folded)
+}
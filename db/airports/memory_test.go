@@ -0,0 +1,289 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package airports
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestMemoryStore(t *testing.T) *MemoryStore {
+	s := NewMemoryStore()
+	err := s.UpsertBatch(context.TODO(), []*Airport{
+		{Name: "Charles De Gaulle", City: "Paris", Country: "France", IataCode: "CDG", Geoloc: &Geoloc{Lat: 49.012779, Lng: 2.55}},
+		{Name: "Orly", City: "Paris", Country: "France", IataCode: "ORY"},
+		{Name: "Heathrow", City: "London", Country: "United Kingdom", IataCode: "LHR"},
+		{Name: "Congonhas", City: "São Paulo", Country: "Brazil", IataCode: "CGH", Geoloc: &Geoloc{Lat: -23.626692, Lng: -46.655375}},
+		{Name: "Congonhas", City: "Sao Paulo", Country: "Brazil", IataCode: "XCG", Geoloc: &Geoloc{Lat: -23.6260, Lng: -46.6560}},
+	})
+	require.NoError(t, err)
+	return s
+}
+
+func TestMemoryStoreUpsertAndGet(t *testing.T) {
+	ctx := context.TODO()
+	s := newTestMemoryStore(t)
+	err := s.Upsert(ctx, &Airport{
+		Name:     "Paris Charles de Gaulle",
+		City:     "Paris",
+		Country:  "France",
+		IataCode: "CDG",
+		Names:    []LocalizedName{{Lang: "fr", Value: "Roissy", Type: NameTypeAlias}, {Lang: "fr", Value: "Aéroport Paris-Charles-de-Gaulle"}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, s.Upsert(ctx, &Airport{Name: "Paris Charles de Gaulle", City: "Paris", Country: "France", IataCode: "CDG"}))
+
+	cdg, err := s.Get(ctx, "CDG")
+	require.NoError(t, err)
+	require.Equal(t, &Airport{
+		Name:     "Paris Charles de Gaulle",
+		City:     "Paris",
+		Country:  "France",
+		IataCode: "CDG",
+		Geoloc:   &Geoloc{Lat: 49.012779, Lng: 2.55},
+		Names: []LocalizedName{
+			{Lang: "fr", Value: "Roissy", Type: NameTypeAlias},
+			{Lang: "fr", Value: "Aéroport Paris-Charles-de-Gaulle", Type: NameTypeOfficial},
+		},
+	}, cdg)
+
+	// returned airports are copies.
+	cdg.Geoloc.Lat = 0
+	cdg, err = s.Get(ctx, "CDG")
+	require.NoError(t, err)
+	require.Equal(t, 49.012779, cdg.Geoloc.Lat)
+
+	_, err = s.Get(ctx, "XXX")
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestMemoryStoreList(t *testing.T) {
+	testCases := []struct {
+		name           string
+		filter         ListFilter
+		expectedOutput []string
+	}{
+		{
+			name:           "no filter",
+			expectedOutput: []string{"CDG", "CGH", "LHR", "ORY", "XCG"},
+		},
+		{
+			name:           "by country",
+			filter:         ListFilter{Country: "FRANCE"},
+			expectedOutput: []string{"CDG", "ORY"},
+		},
+		{
+			name:           "by city",
+			filter:         ListFilter{City: "london"},
+			expectedOutput: []string{"LHR"},
+		},
+		{
+			name:           "by country and city",
+			filter:         ListFilter{Country: "brazil", City: "são paulo"},
+			expectedOutput: []string{"CGH"},
+		},
+		{
+			name:           "no match",
+			filter:         ListFilter{Country: "france", City: "london"},
+			expectedOutput: []string{},
+		},
+	}
+	s := newTestMemoryStore(t)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			output, err := s.List(context.TODO(), tc.filter)
+			require.NoError(t, err)
+			iataCodes := []string{}
+			for _, a := range output {
+				iataCodes = append(iataCodes, a.IataCode)
+			}
+			require.Equal(t, tc.expectedOutput, iataCodes)
+		})
+	}
+}
+
+func TestMemoryStoreListAfterCountryChange(t *testing.T) {
+	ctx := context.TODO()
+	s := newTestMemoryStore(t)
+	require.NoError(t, s.Upsert(ctx, &Airport{Name: "Orly", City: "Paris", Country: "République française", IataCode: "ORY"}))
+	output, err := s.List(ctx, ListFilter{Country: "france"})
+	require.NoError(t, err)
+	require.Len(t, output, 1)
+	require.Equal(t, "CDG", output[0].IataCode)
+}
+
+func TestMemoryStoreDelete(t *testing.T) {
+	ctx := context.TODO()
+	s := newTestMemoryStore(t)
+	require.NoError(t, s.Delete(ctx, "CDG"))
+	require.ErrorIs(t, s.Delete(ctx, "CDG"), ErrNotFound)
+	_, err := s.Get(ctx, "CDG")
+	require.ErrorIs(t, err, ErrNotFound)
+	output, err := s.List(ctx, ListFilter{City: "paris"})
+	require.NoError(t, err)
+	require.Len(t, output, 1)
+	require.Equal(t, "ORY", output[0].IataCode)
+}
+
+func TestMemoryStoreSearch(t *testing.T) {
+	testCases := []struct {
+		name           string
+		query          string
+		expectedOutput []string
+	}{
+		{
+			name:           "prefix of last term",
+			query:          "heath",
+			expectedOutput: []string{"LHR"},
+		},
+		{
+			name:           "IATA code ranks first",
+			query:          "cdg",
+			expectedOutput: []string{"CDG"},
+		},
+		{
+			name:           "all terms must match, ignoring diacritics",
+			query:          "sao paulo cong",
+			expectedOutput: []string{"CGH", "XCG"},
+		},
+		{
+			name:           "only the last term is a prefix",
+			query:          "par orly",
+			expectedOutput: []string{},
+		},
+		{
+			name:           "no searchable terms",
+			query:          `"*`,
+			expectedOutput: []string{},
+		},
+	}
+	s := newTestMemoryStore(t)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			output, err := s.Search(context.TODO(), tc.query, 10)
+			require.NoError(t, err)
+			iataCodes := []string{}
+			for _, r := range output {
+				iataCodes = append(iataCodes, r.IataCode)
+			}
+			require.Equal(t, tc.expectedOutput, iataCodes)
+		})
+	}
+}
+
+func TestMemoryStoreSearchAliases(t *testing.T) {
+	ctx := context.TODO()
+	s := newTestMemoryStore(t)
+	require.NoError(t, s.Upsert(ctx, &Airport{
+		Name:     "Charles De Gaulle",
+		City:     "Paris",
+		Country:  "France",
+		IataCode: "CDG",
+		Names:    []LocalizedName{{Lang: "fr", Value: "Roissy", Type: NameTypeAlias}},
+	}))
+	output, err := s.Search(ctx, "roissy", 10)
+	require.NoError(t, err)
+	require.Len(t, output, 1)
+	require.Equal(t, "CDG", output[0].IataCode)
+	require.Equal(t, float64(searchWeightAliases), output[0].Score)
+	require.Nil(t, output[0].Names)
+}
+
+func TestMemoryStoreFindDuplicates(t *testing.T) {
+	s := newTestMemoryStore(t)
+	require.NoError(t, s.Upsert(context.TODO(), &Airport{Name: "orly ", City: "PARIS", Country: "France", IataCode: "XOR"}))
+	var output []Duplicate
+	err := s.FindDuplicates(context.TODO(), 500, func(d Duplicate) error {
+		d.DistanceMeters = nil
+		output = append(output, d)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []Duplicate{
+		{
+			Reason: DuplicateReasonSameNameAndCity,
+			Airports: []Airport{
+				{Name: "Orly", City: "Paris", Country: "France", IataCode: "ORY"},
+				{Name: "orly ", City: "PARIS", Country: "France", IataCode: "XOR"},
+			},
+		},
+		{
+			Reason: DuplicateReasonNearby,
+			Airports: []Airport{
+				{Name: "Congonhas", City: "São Paulo", Country: "Brazil", IataCode: "CGH", Geoloc: &Geoloc{Lat: -23.626692, Lng: -46.655375}},
+				{Name: "Congonhas", City: "Sao Paulo", Country: "Brazil", IataCode: "XCG", Geoloc: &Geoloc{Lat: -23.6260, Lng: -46.6560}},
+			},
+		},
+	}, output)
+}
+
+func TestMemoryStoreExportAndReplaceAll(t *testing.T) {
+	ctx := context.TODO()
+	s := newTestMemoryStore(t)
+	require.NoError(t, s.Upsert(ctx, &Airport{
+		Name:     "Charles De Gaulle",
+		City:     "Paris",
+		Country:  "France",
+		IataCode: "CDG",
+		Names:    []LocalizedName{{Lang: "fr", Value: "Roissy", Type: NameTypeAlias}},
+	}))
+
+	var exported []*Airport
+	err := s.Export(ctx, func(a Airport) error {
+		exported = append(exported, &a)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, exported, 5)
+	require.Equal(t, "CDG", exported[0].IataCode)
+	require.Equal(t, []LocalizedName{{Lang: "fr", Value: "Roissy", Type: NameTypeAlias}}, exported[0].Names)
+
+	require.NoError(t, s.ReplaceAll(ctx, []*Airport{
+		{Name: "Guarulhos", City: "Sao Paulo", Country: "Brazil", IataCode: "GRU"},
+	}))
+	all, err := s.List(ctx, ListFilter{})
+	require.NoError(t, err)
+	require.Equal(t, []Airport{{Name: "Guarulhos", City: "Sao Paulo", Country: "Brazil", IataCode: "GRU"}}, all)
+	paris, err := s.List(ctx, ListFilter{City: "Paris"})
+	require.NoError(t, err)
+	require.Empty(t, paris)
+
+	require.NoError(t, s.ReplaceAll(ctx, exported))
+	var restored []*Airport
+	err = s.Export(ctx, func(a Airport) error {
+		restored = append(restored, &a)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, exported, restored)
+}
+
+func TestMemoryStorePreviewUpsert(t *testing.T) {
+	ctx := context.TODO()
+	s := newTestMemoryStore(t)
+	diff, err := s.PreviewUpsert(ctx, []*Airport{
+		{Name: "Charles De Gaulle", City: "Roissy-en-France", Country: "France", IataCode: "CDG"},
+		{Name: "Orly", City: "Paris", Country: "France", IataCode: "ORY"},
+		{Name: "Guarulhos", City: "Sao Paulo", Country: "Brazil", IataCode: "GRU"},
+		{Name: "Guarulhos Intl", City: "Sao Paulo", Country: "Brazil", IataCode: "GRU"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, Diff{
+		Added:   []Airport{{Name: "Guarulhos Intl", City: "Sao Paulo", Country: "Brazil", IataCode: "GRU"}},
+		Removed: []Airport{},
+		Changed: []AirportChange{
+			{IataCode: "CDG", Fields: []FieldChange{{Field: FieldCity, Old: "Paris", New: "Roissy-en-France"}}},
+		},
+		Unchanged: 1,
+	}, diff)
+
+	cdg, err := s.Get(ctx, "CDG")
+	require.NoError(t, err)
+	require.Equal(t, "Paris", cdg.City)
+	_, err = s.Get(ctx, "GRU")
+	require.ErrorIs(t, err, ErrNotFound)
+}
@@ -0,0 +1,302 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package airports
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/pkg/errors"
+)
+
+// PostgresStore is an AirportStore backed by PostgreSQL.
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore creates a new PostgresStore on top of the given
+// connection pool, which must use the pgx driver.
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// pgUpsertQuery keeps the existing coordinates when none are given, since
+// most supplier feeds do not carry them.
+const pgUpsertQuery = `
+INSERT INTO airports (name, city, country, iata_code, latitude, longitude)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (iata_code) DO UPDATE
+SET name = EXCLUDED.name, city = EXCLUDED.city, country = EXCLUDED.country,
+    latitude = COALESCE(EXCLUDED.latitude, airports.latitude),
+    longitude = COALESCE(EXCLUDED.longitude, airports.longitude)
+`
+
+const pgListQuery = `
+SELECT name, city, country, iata_code, latitude, longitude
+FROM airports
+WHERE ($1::text = '' OR lower(country) = lower($1::text))
+AND ($2::text = '' OR lower(city) = lower($2::text))
+ORDER BY iata_code
+`
+
+// pgSearchQuery ranks matches against the search_document column, which
+// triggers keep up to date with the airport's IATA code, name, aliases,
+// city and country, weighted in that order.
+const pgSearchQuery = `
+SELECT a.name, a.city, a.country, a.iata_code, ts_rank(a.search_document, q.query) AS score
+FROM airports a, to_tsquery('simple', unaccent($1::text)) AS q(query)
+WHERE a.search_document @@ q.query
+ORDER BY score DESC, a.iata_code
+LIMIT $2
+`
+
+// The staging table airports are copied into before being merged, since
+// COPY itself cannot upsert.
+const (
+	pgCreateStagingQuery = `
+CREATE TEMPORARY TABLE airports_staging (
+    name TEXT NOT NULL,
+    city TEXT NOT NULL,
+    country TEXT NOT NULL,
+    iata_code TEXT NOT NULL,
+    latitude DOUBLE PRECISION,
+    longitude DOUBLE PRECISION
+) ON COMMIT DROP
+`
+	pgMergeStagingQuery = `
+INSERT INTO airports (name, city, country, iata_code, latitude, longitude)
+SELECT name, city, country, iata_code, latitude, longitude
+FROM airports_staging
+ON CONFLICT (iata_code) DO UPDATE
+SET name = EXCLUDED.name, city = EXCLUDED.city, country = EXCLUDED.country,
+    latitude = COALESCE(EXCLUDED.latitude, airports.latitude),
+    longitude = COALESCE(EXCLUDED.longitude, airports.longitude)
+`
+	pgDeleteBatchNamesQuery = `
+DELETE FROM airport_names
+WHERE iata_code = ANY($1)
+`
+)
+
+var (
+	stagingColumns = []string{"name", "city", "country", "iata_code", "latitude", "longitude"}
+	nameColumns    = []string{"iata_code", "lang", "value", "alias_type"}
+)
+
+// Upsert inserts or updates an airport. When the airport carries localized
+// names, they replace the existing ones within the same transaction;
+// otherwise existing names are left untouched.
+func (s *PostgresStore) Upsert(ctx context.Context, airport *Airport) error {
+	if len(airport.Names) == 0 {
+		return upsert(ctx, s.db, pgUpsertQuery, airport)
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "beginning transaction")
+	}
+	if err := upsert(ctx, tx, pgUpsertQuery, airport); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := replaceNames(ctx, tx, airport.IataCode, airport.Names); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "committing transaction")
+	}
+	return nil
+}
+
+// UpsertBatch inserts or updates several airports within a single
+// transaction. Airports and their localized names are streamed to the
+// server with COPY, which is much faster than one statement per airport.
+func (s *PostgresStore) UpsertBatch(ctx context.Context, airports []*Airport) error {
+	airports = mergeDuplicates(airports)
+	if len(airports) == 0 {
+		return nil
+	}
+	return s.withPgxConn(ctx, func(conn *pgx.Conn) error {
+		return copyBatch(ctx, conn, airports, false)
+	})
+}
+
+// ReplaceAll replaces all airports with the given ones within a single
+// transaction, copying them with COPY as UpsertBatch does.
+func (s *PostgresStore) ReplaceAll(ctx context.Context, airports []*Airport) error {
+	airports = mergeDuplicates(airports)
+	return s.withPgxConn(ctx, func(conn *pgx.Conn) error {
+		return copyBatch(ctx, conn, airports, true)
+	})
+}
+
+// PreviewUpsert returns how upserting the given airports would change the
+// stored ones. They are upserted one by one within a transaction that is
+// rolled back, which has the same outcome as UpsertBatch.
+func (s *PostgresStore) PreviewUpsert(ctx context.Context, airports []*Airport) (Diff, error) {
+	return previewUpsert(ctx, s.db, pgUpsertQuery, airports)
+}
+
+// withPgxConn calls fn with the pgx connection underlying one of the
+// pool's connections, since COPY is not available through database/sql.
+func (s *PostgresStore) withPgxConn(ctx context.Context, fn func(conn *pgx.Conn) error) error {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return errors.Wrap(err, "getting connection")
+	}
+	defer conn.Close()
+	return conn.Raw(func(driverConn any) error {
+		stdlibConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return errors.Errorf("unexpected driver connection %T", driverConn)
+		}
+		return fn(stdlibConn.Conn())
+	})
+}
+
+// copyBatch copies airports into a staging table, merges them into the
+// airports table and replaces the localized names of those carrying any.
+// When replacing, all existing airports are deleted first.
+func copyBatch(ctx context.Context, conn *pgx.Conn, airports []*Airport, replacing bool) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, "beginning transaction")
+	}
+	// rolling back a committed transaction is a no-op.
+	defer tx.Rollback(ctx)
+	if replacing {
+		if _, err := tx.Exec(ctx, deleteAllNamesQuery); err != nil {
+			return errors.Wrap(err, "deleting airport names")
+		}
+		if _, err := tx.Exec(ctx, deleteAllAirportsQuery); err != nil {
+			return errors.Wrap(err, "deleting airports")
+		}
+	}
+	if _, err := tx.Exec(ctx, pgCreateStagingQuery); err != nil {
+		return errors.Wrap(err, "creating staging table")
+	}
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{"airports_staging"}, stagingColumns,
+		pgx.CopyFromSlice(len(airports), func(i int) ([]any, error) {
+			a := airports[i]
+			var lat, lng any
+			if a.Geoloc != nil {
+				lat, lng = a.Geoloc.Lat, a.Geoloc.Lng
+			}
+			return []any{a.Name, a.City, a.Country, a.IataCode, lat, lng}, nil
+		}),
+	); err != nil {
+		return errors.Wrap(err, "copying airports")
+	}
+	if _, err := tx.Exec(ctx, pgMergeStagingQuery); err != nil {
+		return errors.Wrap(err, "upserting airports")
+	}
+	var (
+		iataCodes []string
+		names     [][]any
+	)
+	for _, a := range airports {
+		if len(a.Names) == 0 {
+			continue
+		}
+		iataCodes = append(iataCodes, a.IataCode)
+		for _, n := range a.Names {
+			names = append(names, []any{a.IataCode, n.Lang, n.Value, nameType(n)})
+		}
+	}
+	if len(iataCodes) > 0 {
+		if _, err := tx.Exec(ctx, pgDeleteBatchNamesQuery, iataCodes); err != nil {
+			return errors.Wrap(err, "deleting airport names")
+		}
+		if _, err := tx.CopyFrom(ctx, pgx.Identifier{"airport_names"}, nameColumns, pgx.CopyFromRows(names)); err != nil {
+			return errors.Wrap(err, "copying airport names")
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return errors.Wrap(err, "committing transaction")
+	}
+	return nil
+}
+
+// mergeDuplicates merges airports sharing the same IATA code, since a
+// single INSERT ... ON CONFLICT cannot touch the same row twice. The result
+// is the same as upserting them one after the other: later airports win,
+// but keep the coordinates and names of earlier ones when they carry none.
+func mergeDuplicates(airports []*Airport) []*Airport {
+	merged := make([]*Airport, 0, len(airports))
+	positions := make(map[string]int, len(airports))
+	for _, a := range airports {
+		i, ok := positions[a.IataCode]
+		if !ok {
+			positions[a.IataCode] = len(merged)
+			merged = append(merged, a)
+			continue
+		}
+		prev, next := merged[i], *a
+		if next.Geoloc == nil {
+			next.Geoloc = prev.Geoloc
+		}
+		if len(next.Names) == 0 {
+			next.Names = prev.Names
+		}
+		merged[i] = &next
+	}
+	return merged
+}
+
+// Get returns the airport with the given IATA code along with its
+// localized names.
+func (s *PostgresStore) Get(ctx context.Context, iataCode string) (*Airport, error) {
+	return getAirport(ctx, s.db, iataCode)
+}
+
+// List returns the airports matching the given filter ordered by IATA
+// code, without their localized names.
+func (s *PostgresStore) List(ctx context.Context, filter ListFilter) ([]Airport, error) {
+	return listAirports(ctx, s.db, pgListQuery, filter)
+}
+
+// Delete deletes the airport with the given IATA code along with its
+// localized names.
+func (s *PostgresStore) Delete(ctx context.Context, iataCode string) error {
+	return deleteAirport(ctx, s.db, iataCode)
+}
+
+// Search performs a full-text search over airports' IATA codes, names,
+// cities, countries and aliases. The last term of the query is matched as
+// a prefix, so partially typed words still match.
+func (s *PostgresStore) Search(ctx context.Context, query string, limit int) ([]SearchResult, error) {
+	match := tsQuery(query)
+	if match == "" {
+		return []SearchResult{}, nil
+	}
+	return searchAirports(ctx, s.db, pgSearchQuery, match, limit)
+}
+
+// FindDuplicates scans the airports table for probable duplicates.
+// See findDuplicates.
+func (s *PostgresStore) FindDuplicates(ctx context.Context, radiusMeters float64, fn func(Duplicate) error) error {
+	return findDuplicates(ctx, s.db, radiusMeters, fn)
+}
+
+// Export calls fn for every airport along with its localized names,
+// ordered by IATA code, as seen at a single point in time.
+func (s *PostgresStore) Export(ctx context.Context, fn func(Airport) error) error {
+	return exportAirports(ctx, s.db, pgListQuery, fn)
+}
+
+// tsQuery turns free text into a tsquery expression requiring every term,
+// the last one as a prefix. Terms only hold letters and digits, so tsquery
+// operators typed by users are never interpreted.
+func tsQuery(query string) string {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return ""
+	}
+	terms[len(terms)-1] += ":*"
+	return strings.Join(terms, " & ")
+}
@@ -0,0 +1,249 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package airports
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresUpsert(t *testing.T) {
+	testCases := []struct {
+		name          string
+		input         *Airport
+		mockClosure   func() *sql.DB
+		expectedError error
+	}{
+		{
+			name: "happy path",
+			input: &Airport{
+				Name:     "John F. Kennedy International Airport",
+				City:     "New York",
+				Country:  "United States",
+				IataCode: "JFK",
+				Geoloc:   &Geoloc{Lat: 40.639751, Lng: -73.778925},
+			},
+			mockClosure: func() *sql.DB {
+				db, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				mock.ExpectExec(regexp.QuoteMeta(pgUpsertQuery)).
+					WithArgs("John F. Kennedy International Airport", "New York", "United States", "JFK", 40.639751, -73.778925).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				return db
+			},
+		},
+		{
+			name: "happy path with localized names",
+			input: &Airport{
+				Name:     "Charles De Gaulle",
+				City:     "Paris",
+				Country:  "France",
+				IataCode: "CDG",
+				Names:    []LocalizedName{{Lang: "fr", Value: "Roissy", Type: NameTypeAlias}},
+			},
+			mockClosure: func() *sql.DB {
+				db, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(pgUpsertQuery)).
+					WithArgs("Charles De Gaulle", "Paris", "France", "CDG", nil, nil).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta(deleteNamesQuery)).
+					WithArgs("CDG").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexp.QuoteMeta(insertNameQuery)).
+					WithArgs("CDG", "fr", "Roissy", NameTypeAlias).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+				return db
+			},
+		},
+		{
+			name: "error upserting airport with localized names",
+			input: &Airport{
+				Name:     "Charles De Gaulle",
+				City:     "Paris",
+				Country:  "France",
+				IataCode: "CDG",
+				Names:    []LocalizedName{{Lang: "fr", Value: "Roissy", Type: NameTypeAlias}},
+			},
+			mockClosure: func() *sql.DB {
+				db, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(pgUpsertQuery)).
+					WithArgs("Charles De Gaulle", "Paris", "France", "CDG", nil, nil).
+					WillReturnError(sql.ErrConnDone)
+				mock.ExpectRollback()
+				return db
+			},
+			expectedError: errors.New("upserting airport: sql: connection is already closed"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := tc.mockClosure()
+			err := NewPostgresStore(db).Upsert(context.TODO(), tc.input)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf(`expected no error, got "%v"`, err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf(`expected error "%v", got nil`, tc.expectedError)
+				}
+			}
+		})
+	}
+}
+
+func TestPostgresUpsertBatchRequiresPgx(t *testing.T) {
+	db, _, err := sqlmock.New()
+	require.NoError(t, err)
+	err = NewPostgresStore(db).UpsertBatch(context.TODO(), []*Airport{{IataCode: "CDG"}})
+	require.ErrorContains(t, err, "unexpected driver connection")
+}
+
+func TestPostgresReplaceAllRequiresPgx(t *testing.T) {
+	db, _, err := sqlmock.New()
+	require.NoError(t, err)
+	err = NewPostgresStore(db).ReplaceAll(context.TODO(), []*Airport{{IataCode: "CDG"}})
+	require.ErrorContains(t, err, "unexpected driver connection")
+}
+
+func TestPostgresExport(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(exportNamesQuery)).
+		WillReturnRows(sqlmock.NewRows([]string{"iata_code", "lang", "value", "alias_type"}).
+			AddRow("CDG", "fr", "Roissy", NameTypeAlias))
+	mock.ExpectQuery(regexp.QuoteMeta(pgListQuery)).
+		WithArgs("", "").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "city", "country", "iata_code", "latitude", "longitude"}).
+			AddRow("Charles De Gaulle", "Paris", "France", "CDG", 49.012779, 2.55))
+	mock.ExpectRollback()
+	var output []Airport
+	err = NewPostgresStore(db).Export(context.TODO(), func(a Airport) error {
+		output = append(output, a)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []Airport{
+		{
+			Name:     "Charles De Gaulle",
+			City:     "Paris",
+			Country:  "France",
+			IataCode: "CDG",
+			Geoloc:   &Geoloc{Lat: 49.012779, Lng: 2.55},
+			Names:    []LocalizedName{{Lang: "fr", Value: "Roissy", Type: NameTypeAlias}},
+		},
+	}, output)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresList(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	mock.ExpectQuery(regexp.QuoteMeta(pgListQuery)).
+		WithArgs("france", "").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "city", "country", "iata_code", "latitude", "longitude"}).
+			AddRow("Charles De Gaulle", "Paris", "France", "CDG", 49.012779, 2.55))
+	output, err := NewPostgresStore(db).List(context.TODO(), ListFilter{Country: "france"})
+	require.NoError(t, err)
+	require.Equal(t, []Airport{
+		{Name: "Charles De Gaulle", City: "Paris", Country: "France", IataCode: "CDG", Geoloc: &Geoloc{Lat: 49.012779, Lng: 2.55}},
+	}, output)
+}
+
+func TestPostgresSearch(t *testing.T) {
+	testCases := []struct {
+		name           string
+		query          string
+		mockClosure    func() *sql.DB
+		expectedOutput []SearchResult
+		expectedError  error
+	}{
+		{
+			name:  "happy path",
+			query: "london heath",
+			mockClosure: func() *sql.DB {
+				db, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				mock.ExpectQuery(regexp.QuoteMeta(pgSearchQuery)).
+					WithArgs("london & heath:*", 10).
+					WillReturnRows(sqlmock.NewRows([]string{"name", "city", "country", "iata_code", "score"}).
+						AddRow("Heathrow", "London", "United Kingdom", "LHR", 0.6))
+				return db
+			},
+			expectedOutput: []SearchResult{
+				{
+					Airport: Airport{Name: "Heathrow", City: "London", Country: "United Kingdom", IataCode: "LHR"},
+					Score:   0.6,
+				},
+			},
+		},
+		{
+			name:  "query without searchable terms",
+			query: "!&:*",
+			mockClosure: func() *sql.DB {
+				db, _, err := sqlmock.New()
+				require.NoError(t, err)
+				return db
+			},
+			expectedOutput: []SearchResult{},
+		},
+		{
+			name:  "error",
+			query: "heathrow",
+			mockClosure: func() *sql.DB {
+				db, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				mock.ExpectQuery(regexp.QuoteMeta(pgSearchQuery)).
+					WithArgs("heathrow:*", 10).
+					WillReturnError(sql.ErrConnDone)
+				return db
+			},
+			expectedError: errors.New("searching airports: sql: connection is already closed"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := tc.mockClosure()
+			output, err := NewPostgresStore(db).Search(context.TODO(), tc.query, 10)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf(`expected no error, got "%v"`, err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf(`expected error "%v", got nil`, tc.expectedError)
+				}
+				require.Equal(t, tc.expectedOutput, output)
+			}
+		})
+	}
+}
+
+func TestMergeDuplicates(t *testing.T) {
+	names := []LocalizedName{{Lang: "fr", Value: "Roissy", Type: NameTypeAlias}}
+	output := mergeDuplicates([]*Airport{
+		{Name: "Charles De Gaulle", IataCode: "CDG", Geoloc: &Geoloc{Lat: 49.01, Lng: 2.55}, Names: names},
+		{Name: "Heathrow", IataCode: "LHR"},
+		{Name: "Paris Charles de Gaulle", IataCode: "CDG"},
+	})
+	require.Equal(t, []*Airport{
+		{Name: "Paris Charles de Gaulle", IataCode: "CDG", Geoloc: &Geoloc{Lat: 49.01, Lng: 2.55}, Names: names},
+		{Name: "Heathrow", IataCode: "LHR"},
+	}, output)
+}
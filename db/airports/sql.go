@@ -0,0 +1,475 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package airports
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// execer is implemented by both *sql.DB and *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// querier is implemented by both *sql.DB and *sql.Tx.
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// scanner is implemented by both *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// The queries below are understood by both SQLite and PostgreSQL.
+
+const deleteNamesQuery = `
+DELETE FROM airport_names
+WHERE iata_code = $1
+`
+
+const insertNameQuery = `
+INSERT INTO airport_names (iata_code, lang, value, alias_type)
+VALUES ($1, $2, $3, $4)
+`
+
+const getQuery = `
+SELECT name, city, country, iata_code, latitude, longitude
+FROM airports
+WHERE iata_code = $1
+`
+
+const getNamesQuery = `
+SELECT lang, value, alias_type
+FROM airport_names
+WHERE iata_code = $1
+ORDER BY id
+`
+
+// The queries below take as many placeholders as IATA codes they filter
+// airports by, listed with inPlaceholders.
+
+const getManyQuery = `
+SELECT name, city, country, iata_code, latitude, longitude
+FROM airports
+WHERE iata_code IN (%s)
+ORDER BY iata_code
+`
+
+const getManyNamesQuery = `
+SELECT iata_code, lang, value, alias_type
+FROM airport_names
+WHERE iata_code IN (%s)
+ORDER BY iata_code, id
+`
+
+const deleteQuery = `
+DELETE FROM airports
+WHERE iata_code = $1
+`
+
+const exportNamesQuery = `
+SELECT iata_code, lang, value, alias_type
+FROM airport_names
+ORDER BY iata_code, id
+`
+
+const (
+	deleteAllNamesQuery    = `DELETE FROM airport_names`
+	deleteAllAirportsQuery = `DELETE FROM airports`
+)
+
+// tracerName is the name of the tracer database spans are started from.
+const tracerName = "github.com/tiagomelo/go-airports-service/db/airports"
+
+// upsert inserts or updates the airport row itself using the given
+// backend-specific query, within a database span.
+func upsert(ctx context.Context, db execer, query string, airport *Airport) error {
+	var lat, lng sql.NullFloat64
+	if airport.Geoloc != nil {
+		lat = sql.NullFloat64{Float64: airport.Geoloc.Lat, Valid: true}
+		lng = sql.NullFloat64{Float64: airport.Geoloc.Lng, Valid: true}
+	}
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "upsert airports",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBOperationName("upsert"),
+			semconv.DBCollectionName("airports"),
+			semconv.DBQueryTextKey.String(strings.TrimSpace(query)),
+			attribute.String("airport.iata_code", airport.IataCode),
+		),
+	)
+	defer span.End()
+	if _, err := db.ExecContext(ctx, query,
+		airport.Name,
+		airport.City,
+		airport.Country,
+		airport.IataCode,
+		lat,
+		lng,
+	); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "upserting airport")
+		return errors.Wrap(err, "upserting airport")
+	}
+	return nil
+}
+
+// replaceNames replaces all localized names of the given airport.
+func replaceNames(ctx context.Context, db execer, iataCode string, names []LocalizedName) error {
+	if _, err := db.ExecContext(ctx, deleteNamesQuery, iataCode); err != nil {
+		return errors.Wrap(err, "deleting airport names")
+	}
+	for _, n := range names {
+		if _, err := db.ExecContext(ctx, insertNameQuery, iataCode, n.Lang, n.Value, nameType(n)); err != nil {
+			return errors.Wrap(err, "inserting airport name")
+		}
+	}
+	return nil
+}
+
+// upsertAll upserts airports one by one within the given transaction using
+// the given backend-specific query, replacing the localized names of those
+// carrying any.
+func upsertAll(ctx context.Context, tx execer, query string, airports []*Airport) error {
+	for _, airport := range airports {
+		if err := upsert(ctx, tx, query, airport); err != nil {
+			return err
+		}
+		if len(airport.Names) == 0 {
+			continue
+		}
+		if err := replaceNames(ctx, tx, airport.IataCode, airport.Names); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// previewUpsert upserts airports within a transaction that is always rolled
+// back, using the given backend-specific query, and returns how they would
+// change the stored ones. Since the airports are actually upserted, the
+// database rejects them just like it would outside of a preview. Airports
+// are read back before and after upserting them, so that coordinates and
+// names kept from the stored airports are accounted for. Callers are
+// expected to preview airports in batches, so that the transaction, and
+// the queries reading them back, are kept short.
+func previewUpsert(ctx context.Context, db *sql.DB, query string, airports []*Airport) (Diff, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return Diff{}, errors.Wrap(err, "beginning transaction")
+	}
+	defer tx.Rollback()
+	iataCodes := uniqueIataCodes(airports)
+	before, err := getAirports(ctx, tx, iataCodes)
+	if err != nil {
+		return Diff{}, err
+	}
+	if err := upsertAll(ctx, tx, query, airports); err != nil {
+		return Diff{}, err
+	}
+	after, err := getAirports(ctx, tx, iataCodes)
+	if err != nil {
+		return Diff{}, err
+	}
+	return DiffAirports(before, after), nil
+}
+
+// getAirports returns the airports with the given IATA codes that exist,
+// along with their localized names, ordered by IATA code. Airports and
+// names are each read with a single query.
+func getAirports(ctx context.Context, db querier, iataCodes []string) ([]Airport, error) {
+	airports := []Airport{}
+	if len(iataCodes) == 0 {
+		return airports, nil
+	}
+	placeholders := inPlaceholders(len(iataCodes))
+	args := make([]any, len(iataCodes))
+	for i, iataCode := range iataCodes {
+		args[i] = iataCode
+	}
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(getManyQuery, placeholders), args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting airports")
+	}
+	defer rows.Close()
+	byIataCode := make(map[string]int, len(iataCodes))
+	for rows.Next() {
+		a, err := scanAirport(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "scanning airport")
+		}
+		byIataCode[a.IataCode] = len(airports)
+		airports = append(airports, *a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "iterating airports")
+	}
+	nameRows, err := db.QueryContext(ctx, fmt.Sprintf(getManyNamesQuery, placeholders), args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting airport names")
+	}
+	defer nameRows.Close()
+	for nameRows.Next() {
+		var (
+			iataCode string
+			n        LocalizedName
+		)
+		if err := nameRows.Scan(&iataCode, &n.Lang, &n.Value, &n.Type); err != nil {
+			return nil, errors.Wrap(err, "scanning airport name")
+		}
+		if i, ok := byIataCode[iataCode]; ok {
+			airports[i].Names = append(airports[i].Names, n)
+		}
+	}
+	if err := nameRows.Err(); err != nil {
+		return nil, errors.Wrap(err, "iterating airport names")
+	}
+	return airports, nil
+}
+
+// inPlaceholders returns n comma-separated numbered placeholders, to be
+// listed within an IN clause.
+func inPlaceholders(n int) string {
+	placeholders := make([]string, n)
+	for i := range placeholders {
+		placeholders[i] = "$" + strconv.Itoa(i+1)
+	}
+	return strings.Join(placeholders, ", ")
+}
+
+// uniqueIataCodes returns the IATA codes of the given airports, each one
+// once, in the order they first appear.
+func uniqueIataCodes(airports []*Airport) []string {
+	seen := make(map[string]struct{}, len(airports))
+	iataCodes := make([]string, 0, len(airports))
+	for _, a := range airports {
+		if _, ok := seen[a.IataCode]; ok {
+			continue
+		}
+		seen[a.IataCode] = struct{}{}
+		iataCodes = append(iataCodes, a.IataCode)
+	}
+	return iataCodes
+}
+
+// nameType returns the type of a localized name, which defaults to official.
+func nameType(n LocalizedName) string {
+	if n.Type == "" {
+		return NameTypeOfficial
+	}
+	return n.Type
+}
+
+// getAirport returns the airport with the given IATA code along with its
+// localized names.
+func getAirport(ctx context.Context, db querier, iataCode string) (*Airport, error) {
+	a, err := scanAirport(db.QueryRowContext(ctx, getQuery, iataCode))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, errors.Wrap(err, "getting airport")
+	}
+	rows, err := db.QueryContext(ctx, getNamesQuery, iataCode)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting airport names")
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var n LocalizedName
+		if err := rows.Scan(&n.Lang, &n.Value, &n.Type); err != nil {
+			return nil, errors.Wrap(err, "scanning airport name")
+		}
+		a.Names = append(a.Names, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "iterating airport names")
+	}
+	return a, nil
+}
+
+// scanAirport scans name, city, country, IATA code, latitude and longitude
+// into an airport.
+func scanAirport(row scanner) (*Airport, error) {
+	var (
+		a        Airport
+		lat, lng sql.NullFloat64
+	)
+	if err := row.Scan(&a.Name, &a.City, &a.Country, &a.IataCode, &lat, &lng); err != nil {
+		return nil, err
+	}
+	if lat.Valid && lng.Valid {
+		a.Geoloc = &Geoloc{Lat: lat.Float64, Lng: lng.Float64}
+	}
+	return &a, nil
+}
+
+// listAirports returns the airports matching the given filter using the
+// given backend-specific query, which takes the country and the city.
+func listAirports(ctx context.Context, db *sql.DB, query string, filter ListFilter) ([]Airport, error) {
+	rows, err := db.QueryContext(ctx, query, filter.Country, filter.City)
+	if err != nil {
+		return nil, errors.Wrap(err, "listing airports")
+	}
+	defer rows.Close()
+	airports := []Airport{}
+	for rows.Next() {
+		a, err := scanAirport(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "scanning airport")
+		}
+		airports = append(airports, *a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "iterating airports")
+	}
+	return airports, nil
+}
+
+// deleteAirport deletes the airport with the given IATA code along with
+// its localized names.
+func deleteAirport(ctx context.Context, db *sql.DB, iataCode string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "beginning transaction")
+	}
+	res, err := tx.ExecContext(ctx, deleteQuery, iataCode)
+	if err != nil {
+		tx.Rollback()
+		return errors.Wrap(err, "deleting airport")
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return errors.Wrap(err, "deleting airport")
+	}
+	if deleted == 0 {
+		tx.Rollback()
+		return ErrNotFound
+	}
+	if _, err := tx.ExecContext(ctx, deleteNamesQuery, iataCode); err != nil {
+		tx.Rollback()
+		return errors.Wrap(err, "deleting airport names")
+	}
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "committing transaction")
+	}
+	return nil
+}
+
+// exportAirports calls fn for every airport along with its localized
+// names using the given backend-specific list query, which takes the
+// country and the city. Both are read within the same read-only
+// transaction, so that they are consistent with each other.
+func exportAirports(ctx context.Context, db *sql.DB, query string, fn func(Airport) error) error {
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return errors.Wrap(err, "beginning transaction")
+	}
+	defer tx.Rollback()
+	names, err := exportNames(ctx, tx)
+	if err != nil {
+		return err
+	}
+	rows, err := tx.QueryContext(ctx, query, "", "")
+	if err != nil {
+		return errors.Wrap(err, "listing airports")
+	}
+	defer rows.Close()
+	for rows.Next() {
+		a, err := scanAirport(rows)
+		if err != nil {
+			return errors.Wrap(err, "scanning airport")
+		}
+		a.Names = names[a.IataCode]
+		if err := fn(*a); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return errors.Wrap(err, "iterating airports")
+	}
+	return nil
+}
+
+// exportNames returns the localized names of all airports, keyed by IATA
+// code.
+func exportNames(ctx context.Context, tx *sql.Tx) (map[string][]LocalizedName, error) {
+	rows, err := tx.QueryContext(ctx, exportNamesQuery)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting airport names")
+	}
+	defer rows.Close()
+	names := make(map[string][]LocalizedName)
+	for rows.Next() {
+		var (
+			iataCode string
+			n        LocalizedName
+		)
+		if err := rows.Scan(&iataCode, &n.Lang, &n.Value, &n.Type); err != nil {
+			return nil, errors.Wrap(err, "scanning airport name")
+		}
+		names[iataCode] = append(names[iataCode], n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "iterating airport names")
+	}
+	return names, nil
+}
+
+// deleteAll deletes all airports along with their localized names within
+// the given transaction.
+func deleteAll(ctx context.Context, tx execer) error {
+	if _, err := tx.ExecContext(ctx, deleteAllNamesQuery); err != nil {
+		return errors.Wrap(err, "deleting airport names")
+	}
+	if _, err := tx.ExecContext(ctx, deleteAllAirportsQuery); err != nil {
+		return errors.Wrap(err, "deleting airports")
+	}
+	return nil
+}
+
+// searchAirports runs the given backend-specific full-text search query,
+// which takes the match expression and the limit.
+func searchAirports(ctx context.Context, db *sql.DB, query, match string, limit int) ([]SearchResult, error) {
+	rows, err := db.QueryContext(ctx, query, match, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "searching airports")
+	}
+	defer rows.Close()
+	results := []SearchResult{}
+	for rows.Next() {
+		var r SearchResult
+		if err := rows.Scan(&r.Name, &r.City, &r.Country, &r.IataCode, &r.Score); err != nil {
+			return nil, errors.Wrap(err, "scanning airport")
+		}
+		results = append(results, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "iterating airports")
+	}
+	return results, nil
+}
+
+// searchTerms splits free text into the words a full-text search matches.
+func searchTerms(query string) []string {
+	return strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
@@ -0,0 +1,168 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package airports
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// SqliteStore is an AirportStore backed by SQLite. Writes go through the
+// writer pool and reads through the reader one, so that, in WAL mode,
+// readers do not queue behind writers.
+type SqliteStore struct {
+	writer *sql.DB
+	reader *sql.DB
+}
+
+// NewSqliteStore creates a new SqliteStore on top of the given writer and
+// reader connection pools, which may be the same.
+func NewSqliteStore(writer, reader *sql.DB) *SqliteStore {
+	return &SqliteStore{writer: writer, reader: reader}
+}
+
+// upsertQuery keeps the existing coordinates when none are given, since
+// most supplier feeds do not carry them.
+const upsertQuery = `
+INSERT INTO airports (name, city, country, iata_code, latitude, longitude)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (iata_code) DO UPDATE
+SET name = $1, city = $2, country = $3,
+    latitude = COALESCE($5, latitude), longitude = COALESCE($6, longitude)
+`
+
+const listQuery = `
+SELECT name, city, country, iata_code, latitude, longitude
+FROM airports
+WHERE ($1 = '' OR country = $1 COLLATE NOCASE)
+AND ($2 = '' OR city = $2 COLLATE NOCASE)
+ORDER BY iata_code
+`
+
+// searchQuery ranks matches with bm25, weighting columns in the order they
+// are declared in airports_fts: iata_code, name, city, country and aliases.
+const searchQuery = `
+SELECT a.name, a.city, a.country, a.iata_code, -bm25(airports_fts, 10.0, 5.0, 3.0, 1.0, 4.0) AS score
+FROM airports_fts
+JOIN airports a ON a.id = airports_fts.rowid
+WHERE airports_fts MATCH $1
+ORDER BY score DESC
+LIMIT $2
+`
+
+// Upsert inserts or updates an airport. When the airport carries localized
+// names, they replace the existing ones within the same transaction;
+// otherwise existing names are left untouched.
+func (s *SqliteStore) Upsert(ctx context.Context, airport *Airport) error {
+	if len(airport.Names) == 0 {
+		return upsert(ctx, s.writer, upsertQuery, airport)
+	}
+	return s.UpsertBatch(ctx, []*Airport{airport})
+}
+
+// UpsertBatch inserts or updates several airports within a single
+// transaction, which is considerably faster in SQLite than one
+// transaction per airport.
+func (s *SqliteStore) UpsertBatch(ctx context.Context, airports []*Airport) error {
+	tx, err := s.writer.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "beginning transaction")
+	}
+	if err := upsertAll(ctx, tx, upsertQuery, airports); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "committing transaction")
+	}
+	return nil
+}
+
+// ReplaceAll replaces all airports with the given ones within a single
+// transaction, so readers see either the old airports or the new ones.
+func (s *SqliteStore) ReplaceAll(ctx context.Context, airports []*Airport) error {
+	tx, err := s.writer.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "beginning transaction")
+	}
+	if err := deleteAll(ctx, tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := upsertAll(ctx, tx, upsertQuery, airports); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "committing transaction")
+	}
+	return nil
+}
+
+// PreviewUpsert returns how upserting the given airports would change the
+// stored ones. They are upserted through the writer pool within a
+// transaction that is rolled back, so other writes wait for the preview.
+func (s *SqliteStore) PreviewUpsert(ctx context.Context, airports []*Airport) (Diff, error) {
+	return previewUpsert(ctx, s.writer, upsertQuery, airports)
+}
+
+// Get returns the airport with the given IATA code along with its
+// localized names.
+func (s *SqliteStore) Get(ctx context.Context, iataCode string) (*Airport, error) {
+	return getAirport(ctx, s.reader, iataCode)
+}
+
+// List returns the airports matching the given filter ordered by IATA
+// code, without their localized names.
+func (s *SqliteStore) List(ctx context.Context, filter ListFilter) ([]Airport, error) {
+	return listAirports(ctx, s.reader, listQuery, filter)
+}
+
+// Delete deletes the airport with the given IATA code along with its
+// localized names.
+func (s *SqliteStore) Delete(ctx context.Context, iataCode string) error {
+	return deleteAirport(ctx, s.writer, iataCode)
+}
+
+// Search performs a full-text search over airports' IATA codes, names,
+// cities, countries and aliases. The last term of the query is matched as
+// a prefix, so partially typed words still match.
+func (s *SqliteStore) Search(ctx context.Context, query string, limit int) ([]SearchResult, error) {
+	match := ftsQuery(query)
+	if match == "" {
+		return []SearchResult{}, nil
+	}
+	return searchAirports(ctx, s.reader, searchQuery, match, limit)
+}
+
+// FindDuplicates scans the airports table for probable duplicates.
+// See findDuplicates.
+func (s *SqliteStore) FindDuplicates(ctx context.Context, radiusMeters float64, fn func(Duplicate) error) error {
+	return findDuplicates(ctx, s.reader, radiusMeters, fn)
+}
+
+// Export calls fn for every airport along with its localized names,
+// ordered by IATA code, as seen at a single point in time.
+func (s *SqliteStore) Export(ctx context.Context, fn func(Airport) error) error {
+	return exportAirports(ctx, s.reader, listQuery, fn)
+}
+
+// ftsQuery turns free text into an FTS5 MATCH expression. Every term is
+// quoted, so FTS5 operators typed by users are matched literally, and the
+// last one is turned into a prefix query.
+func ftsQuery(query string) string {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return ""
+	}
+	for i, term := range terms {
+		terms[i] = `"` + term + `"`
+	}
+	terms[len(terms)-1] += "*"
+	return strings.Join(terms, " ")
+}
@@ -0,0 +1,997 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package airports
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestUpsert(t *testing.T) {
+	testCases := []struct {
+		name          string
+		input         *Airport
+		mockClosure   func() *sql.DB
+		expectedError error
+	}{
+		{
+			name: "happy path",
+			input: &Airport{
+				Name:     "John F. Kennedy International Airport",
+				City:     "New York",
+				Country:  "United States",
+				IataCode: "JFK",
+			},
+			mockClosure: func() *sql.DB {
+				db, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				mock.ExpectExec(regexp.QuoteMeta(upsertQuery)).
+					WithArgs(
+						"John F. Kennedy International Airport",
+						"New York",
+						"United States",
+						"JFK",
+						nil,
+						nil,
+					).WillReturnResult(sqlmock.NewResult(0, 1))
+				return db
+			},
+		},
+		{
+			name: "error",
+			input: &Airport{
+				Name:     "John F. Kennedy International Airport",
+				City:     "New York",
+				Country:  "United States",
+				IataCode: "JFK",
+			},
+			mockClosure: func() *sql.DB {
+				db, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				mock.ExpectExec(regexp.QuoteMeta(upsertQuery)).
+					WithArgs(
+						"John F. Kennedy International Airport",
+						"New York",
+						"United States",
+						"JFK",
+						nil,
+						nil,
+					).WillReturnError(sql.ErrConnDone)
+				return db
+			},
+			expectedError: errors.New("upserting airport: sql: connection is already closed"),
+		},
+		{
+			name: "happy path with coordinates",
+			input: &Airport{
+				Name:     "John F. Kennedy International Airport",
+				City:     "New York",
+				Country:  "United States",
+				IataCode: "JFK",
+				Geoloc:   &Geoloc{Lat: 40.639751, Lng: -73.778925},
+			},
+			mockClosure: func() *sql.DB {
+				db, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				mock.ExpectExec(regexp.QuoteMeta(upsertQuery)).
+					WithArgs(
+						"John F. Kennedy International Airport",
+						"New York",
+						"United States",
+						"JFK",
+						40.639751,
+						-73.778925,
+					).WillReturnResult(sqlmock.NewResult(0, 1))
+				return db
+			},
+		},
+		{
+			name: "happy path with localized names",
+			input: &Airport{
+				Name:     "Charles De Gaulle",
+				City:     "Paris",
+				Country:  "France",
+				IataCode: "CDG",
+				Names: []LocalizedName{
+					{Lang: "fr", Value: "Aéroport Paris-Charles-de-Gaulle"},
+					{Lang: "fr", Value: "Roissy", Type: NameTypeAlias},
+				},
+			},
+			mockClosure: func() *sql.DB {
+				db, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(upsertQuery)).
+					WithArgs("Charles De Gaulle", "Paris", "France", "CDG", nil, nil).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta(deleteNamesQuery)).
+					WithArgs("CDG").
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec(regexp.QuoteMeta(insertNameQuery)).
+					WithArgs("CDG", "fr", "Aéroport Paris-Charles-de-Gaulle", NameTypeOfficial).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(regexp.QuoteMeta(insertNameQuery)).
+					WithArgs("CDG", "fr", "Roissy", NameTypeAlias).
+					WillReturnResult(sqlmock.NewResult(2, 1))
+				mock.ExpectCommit()
+				return db
+			},
+		},
+		{
+			name: "error inserting localized name",
+			input: &Airport{
+				Name:     "Charles De Gaulle",
+				City:     "Paris",
+				Country:  "France",
+				IataCode: "CDG",
+				Names: []LocalizedName{
+					{Lang: "fr", Value: "Roissy", Type: NameTypeAlias},
+				},
+			},
+			mockClosure: func() *sql.DB {
+				db, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(upsertQuery)).
+					WithArgs("Charles De Gaulle", "Paris", "France", "CDG", nil, nil).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta(deleteNamesQuery)).
+					WithArgs("CDG").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexp.QuoteMeta(insertNameQuery)).
+					WithArgs("CDG", "fr", "Roissy", NameTypeAlias).
+					WillReturnError(sql.ErrConnDone)
+				mock.ExpectRollback()
+				return db
+			},
+			expectedError: errors.New("inserting airport name: sql: connection is already closed"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := tc.mockClosure()
+			err := NewSqliteStore(db, db).Upsert(context.TODO(), tc.input)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf(`expected no error, got "%v"`, err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf(`expected error "%v", got nil`, tc.expectedError)
+				}
+			}
+		})
+	}
+}
+
+func TestUpsertTracing(t *testing.T) {
+	testCases := []struct {
+		name           string
+		execError      error
+		expectedStatus codes.Code
+	}{
+		{
+			name:           "happy path",
+			expectedStatus: codes.Unset,
+		},
+		{
+			name:           "error",
+			execError:      sql.ErrConnDone,
+			expectedStatus: codes.Error,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sr := tracetest.NewSpanRecorder()
+			originalProvider := otel.GetTracerProvider()
+			defer otel.SetTracerProvider(originalProvider)
+			provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+			otel.SetTracerProvider(provider)
+
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			exec := mock.ExpectExec(regexp.QuoteMeta(upsertQuery)).
+				WithArgs("John F. Kennedy International Airport", "New York", "United States", "JFK", nil, nil)
+			if tc.execError != nil {
+				exec.WillReturnError(tc.execError)
+			} else {
+				exec.WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+			ctx, parent := provider.Tracer("test").Start(context.TODO(), "parent")
+			_ = NewSqliteStore(db, db).Upsert(ctx, &Airport{
+				Name:     "John F. Kennedy International Airport",
+				City:     "New York",
+				Country:  "United States",
+				IataCode: "JFK",
+			})
+			parent.End()
+
+			spans := sr.Ended()
+			require.Len(t, spans, 2)
+			span := spans[0]
+			require.Equal(t, "upsert airports", span.Name())
+			require.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID())
+			require.Equal(t, tc.expectedStatus, span.Status().Code)
+			require.Contains(t, span.Attributes(), attribute.String("airport.iata_code", "JFK"))
+			require.Contains(t, span.Attributes(), attribute.String("db.operation.name", "upsert"))
+		})
+	}
+}
+
+func TestGet(t *testing.T) {
+	testCases := []struct {
+		name           string
+		mockClosure    func() *sql.DB
+		expectedOutput *Airport
+		expectedError  error
+	}{
+		{
+			name: "happy path",
+			mockClosure: func() *sql.DB {
+				db, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				mock.ExpectQuery(regexp.QuoteMeta(getQuery)).
+					WithArgs("CDG").
+					WillReturnRows(sqlmock.NewRows([]string{"name", "city", "country", "iata_code", "latitude", "longitude"}).
+						AddRow("Charles De Gaulle", "Paris", "France", "CDG", 49.012779, 2.55))
+				mock.ExpectQuery(regexp.QuoteMeta(getNamesQuery)).
+					WithArgs("CDG").
+					WillReturnRows(sqlmock.NewRows([]string{"lang", "value", "alias_type"}).
+						AddRow("fr", "Roissy", NameTypeAlias))
+				return db
+			},
+			expectedOutput: &Airport{
+				Name:     "Charles De Gaulle",
+				City:     "Paris",
+				Country:  "France",
+				IataCode: "CDG",
+				Geoloc:   &Geoloc{Lat: 49.012779, Lng: 2.55},
+				Names: []LocalizedName{
+					{Lang: "fr", Value: "Roissy", Type: NameTypeAlias},
+				},
+			},
+		},
+		{
+			name: "not found",
+			mockClosure: func() *sql.DB {
+				db, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				mock.ExpectQuery(regexp.QuoteMeta(getQuery)).
+					WithArgs("CDG").
+					WillReturnError(sql.ErrNoRows)
+				return db
+			},
+			expectedError: ErrNotFound,
+		},
+		{
+			name: "error getting names",
+			mockClosure: func() *sql.DB {
+				db, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				mock.ExpectQuery(regexp.QuoteMeta(getQuery)).
+					WithArgs("CDG").
+					WillReturnRows(sqlmock.NewRows([]string{"name", "city", "country", "iata_code", "latitude", "longitude"}).
+						AddRow("Charles De Gaulle", "Paris", "France", "CDG", 49.012779, 2.55))
+				mock.ExpectQuery(regexp.QuoteMeta(getNamesQuery)).
+					WithArgs("CDG").
+					WillReturnError(sql.ErrConnDone)
+				return db
+			},
+			expectedError: errors.New("getting airport names: sql: connection is already closed"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := tc.mockClosure()
+			output, err := NewSqliteStore(db, db).Get(context.TODO(), "CDG")
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf(`expected no error, got "%v"`, err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf(`expected error "%v", got nil`, tc.expectedError)
+				}
+				require.Equal(t, tc.expectedOutput, output)
+			}
+		})
+	}
+}
+
+func TestSearch(t *testing.T) {
+	testCases := []struct {
+		name           string
+		query          string
+		mockClosure    func() *sql.DB
+		expectedOutput []SearchResult
+		expectedError  error
+	}{
+		{
+			name:  "happy path",
+			query: "london heath",
+			mockClosure: func() *sql.DB {
+				db, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				mock.ExpectQuery(regexp.QuoteMeta(searchQuery)).
+					WithArgs(`"london" "heath"*`, 10).
+					WillReturnRows(sqlmock.NewRows([]string{"name", "city", "country", "iata_code", "score"}).
+						AddRow("Heathrow", "London", "United Kingdom", "LHR", 1.5))
+				return db
+			},
+			expectedOutput: []SearchResult{
+				{
+					Airport: Airport{Name: "Heathrow", City: "London", Country: "United Kingdom", IataCode: "LHR"},
+					Score:   1.5,
+				},
+			},
+		},
+		{
+			name:  "query without searchable terms",
+			query: `"*`,
+			mockClosure: func() *sql.DB {
+				db, _, err := sqlmock.New()
+				require.NoError(t, err)
+				return db
+			},
+			expectedOutput: []SearchResult{},
+		},
+		{
+			name:  "error",
+			query: "heathrow",
+			mockClosure: func() *sql.DB {
+				db, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				mock.ExpectQuery(regexp.QuoteMeta(searchQuery)).
+					WithArgs(`"heathrow"*`, 10).
+					WillReturnError(sql.ErrConnDone)
+				return db
+			},
+			expectedError: errors.New("searching airports: sql: connection is already closed"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := tc.mockClosure()
+			output, err := NewSqliteStore(db, db).Search(context.TODO(), tc.query, 10)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf(`expected no error, got "%v"`, err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf(`expected error "%v", got nil`, tc.expectedError)
+				}
+				require.Equal(t, tc.expectedOutput, output)
+			}
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	testCases := []struct {
+		name           string
+		filter         ListFilter
+		mockClosure    func() *sql.DB
+		expectedOutput []Airport
+		expectedError  error
+	}{
+		{
+			name: "happy path",
+			mockClosure: func() *sql.DB {
+				db, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				mock.ExpectQuery(regexp.QuoteMeta(listQuery)).
+					WithArgs("", "").
+					WillReturnRows(sqlmock.NewRows([]string{"name", "city", "country", "iata_code", "latitude", "longitude"}).
+						AddRow("Hartsfield Jackson Atlanta Intl", "Atlanta", "United States", "ATL", 33.636719, -84.428067).
+						AddRow("Chicago Ohare Intl", "Chicago", "United States", "ORD", nil, nil))
+				return db
+			},
+			expectedOutput: []Airport{
+				{Name: "Hartsfield Jackson Atlanta Intl", City: "Atlanta", Country: "United States", IataCode: "ATL", Geoloc: &Geoloc{Lat: 33.636719, Lng: -84.428067}},
+				{Name: "Chicago Ohare Intl", City: "Chicago", Country: "United States", IataCode: "ORD"},
+			},
+		},
+		{
+			name:   "filtered by country and city",
+			filter: ListFilter{Country: "united states", City: "chicago"},
+			mockClosure: func() *sql.DB {
+				db, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				mock.ExpectQuery(regexp.QuoteMeta(listQuery)).
+					WithArgs("united states", "chicago").
+					WillReturnRows(sqlmock.NewRows([]string{"name", "city", "country", "iata_code", "latitude", "longitude"}).
+						AddRow("Chicago Ohare Intl", "Chicago", "United States", "ORD", nil, nil))
+				return db
+			},
+			expectedOutput: []Airport{
+				{Name: "Chicago Ohare Intl", City: "Chicago", Country: "United States", IataCode: "ORD"},
+			},
+		},
+		{
+			name: "error",
+			mockClosure: func() *sql.DB {
+				db, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				mock.ExpectQuery(regexp.QuoteMeta(listQuery)).
+					WithArgs("", "").
+					WillReturnError(sql.ErrConnDone)
+				return db
+			},
+			expectedError: errors.New("listing airports: sql: connection is already closed"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := tc.mockClosure()
+			output, err := NewSqliteStore(db, db).List(context.TODO(), tc.filter)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf(`expected no error, got "%v"`, err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf(`expected error "%v", got nil`, tc.expectedError)
+				}
+				require.Equal(t, tc.expectedOutput, output)
+			}
+		})
+	}
+}
+
+func TestUpsertBatch(t *testing.T) {
+	testCases := []struct {
+		name          string
+		mockClosure   func() *sql.DB
+		expectedError error
+	}{
+		{
+			name: "happy path",
+			mockClosure: func() *sql.DB {
+				db, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(upsertQuery)).
+					WithArgs("Hartsfield Jackson Atlanta Intl", "Atlanta", "United States", "ATL", nil, nil).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(regexp.QuoteMeta(upsertQuery)).
+					WithArgs("Charles De Gaulle", "Paris", "France", "CDG", nil, nil).
+					WillReturnResult(sqlmock.NewResult(2, 1))
+				mock.ExpectExec(regexp.QuoteMeta(deleteNamesQuery)).
+					WithArgs("CDG").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexp.QuoteMeta(insertNameQuery)).
+					WithArgs("CDG", "fr", "Roissy", NameTypeAlias).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+				return db
+			},
+		},
+		{
+			name: "error beginning transaction",
+			mockClosure: func() *sql.DB {
+				db, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				mock.ExpectBegin().WillReturnError(sql.ErrConnDone)
+				return db
+			},
+			expectedError: errors.New("beginning transaction: sql: connection is already closed"),
+		},
+		{
+			name: "error upserting airport",
+			mockClosure: func() *sql.DB {
+				db, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(upsertQuery)).
+					WithArgs("Hartsfield Jackson Atlanta Intl", "Atlanta", "United States", "ATL", nil, nil).
+					WillReturnError(sql.ErrConnDone)
+				mock.ExpectRollback()
+				return db
+			},
+			expectedError: errors.New("upserting airport: sql: connection is already closed"),
+		},
+		{
+			name: "error committing",
+			mockClosure: func() *sql.DB {
+				db, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(upsertQuery)).
+					WithArgs("Hartsfield Jackson Atlanta Intl", "Atlanta", "United States", "ATL", nil, nil).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(regexp.QuoteMeta(upsertQuery)).
+					WithArgs("Charles De Gaulle", "Paris", "France", "CDG", nil, nil).
+					WillReturnResult(sqlmock.NewResult(2, 1))
+				mock.ExpectExec(regexp.QuoteMeta(deleteNamesQuery)).
+					WithArgs("CDG").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexp.QuoteMeta(insertNameQuery)).
+					WithArgs("CDG", "fr", "Roissy", NameTypeAlias).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit().WillReturnError(sql.ErrConnDone)
+				return db
+			},
+			expectedError: errors.New("committing transaction: sql: connection is already closed"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := tc.mockClosure()
+			err := NewSqliteStore(db, db).UpsertBatch(context.TODO(), []*Airport{
+				{Name: "Hartsfield Jackson Atlanta Intl", City: "Atlanta", Country: "United States", IataCode: "ATL"},
+				{
+					Name:     "Charles De Gaulle",
+					City:     "Paris",
+					Country:  "France",
+					IataCode: "CDG",
+					Names:    []LocalizedName{{Lang: "fr", Value: "Roissy", Type: NameTypeAlias}},
+				},
+			})
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf(`expected no error, got "%v"`, err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf(`expected error "%v", got nil`, tc.expectedError)
+				}
+			}
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	testCases := []struct {
+		name          string
+		mockClosure   func() *sql.DB
+		expectedError error
+	}{
+		{
+			name: "happy path",
+			mockClosure: func() *sql.DB {
+				db, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(deleteQuery)).
+					WithArgs("CDG").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta(deleteNamesQuery)).
+					WithArgs("CDG").
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectCommit()
+				return db
+			},
+		},
+		{
+			name: "not found",
+			mockClosure: func() *sql.DB {
+				db, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(deleteQuery)).
+					WithArgs("CDG").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+				return db
+			},
+			expectedError: ErrNotFound,
+		},
+		{
+			name: "error deleting airport",
+			mockClosure: func() *sql.DB {
+				db, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(deleteQuery)).
+					WithArgs("CDG").
+					WillReturnError(sql.ErrConnDone)
+				mock.ExpectRollback()
+				return db
+			},
+			expectedError: errors.New("deleting airport: sql: connection is already closed"),
+		},
+		{
+			name: "error deleting names",
+			mockClosure: func() *sql.DB {
+				db, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(deleteQuery)).
+					WithArgs("CDG").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta(deleteNamesQuery)).
+					WithArgs("CDG").
+					WillReturnError(sql.ErrConnDone)
+				mock.ExpectRollback()
+				return db
+			},
+			expectedError: errors.New("deleting airport names: sql: connection is already closed"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := tc.mockClosure()
+			err := NewSqliteStore(db, db).Delete(context.TODO(), "CDG")
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf(`expected no error, got "%v"`, err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf(`expected error "%v", got nil`, tc.expectedError)
+				}
+			}
+		})
+	}
+}
+
+func TestSqliteStoreRoutesReadsAndWrites(t *testing.T) {
+	writer, writerMock, err := sqlmock.New()
+	require.NoError(t, err)
+	reader, readerMock, err := sqlmock.New()
+	require.NoError(t, err)
+	writerMock.ExpectExec(regexp.QuoteMeta(upsertQuery)).
+		WithArgs("Chicago Ohare Intl", "Chicago", "United States", "ORD", nil, nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	readerMock.ExpectQuery(regexp.QuoteMeta(listQuery)).
+		WithArgs("", "").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "city", "country", "iata_code", "latitude", "longitude"}).
+			AddRow("Chicago Ohare Intl", "Chicago", "United States", "ORD", nil, nil))
+
+	store := NewSqliteStore(writer, reader)
+	err = store.Upsert(context.TODO(), &Airport{Name: "Chicago Ohare Intl", City: "Chicago", Country: "United States", IataCode: "ORD"})
+	require.NoError(t, err)
+	output, err := store.List(context.TODO(), ListFilter{})
+	require.NoError(t, err)
+	require.Equal(t, []Airport{{Name: "Chicago Ohare Intl", City: "Chicago", Country: "United States", IataCode: "ORD"}}, output)
+	require.NoError(t, writerMock.ExpectationsWereMet())
+	require.NoError(t, readerMock.ExpectationsWereMet())
+}
+
+func TestReplaceAll(t *testing.T) {
+	testCases := []struct {
+		name          string
+		mockClosure   func() *sql.DB
+		expectedError error
+	}{
+		{
+			name: "happy path",
+			mockClosure: func() *sql.DB {
+				db, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(deleteAllNamesQuery)).
+					WillReturnResult(sqlmock.NewResult(0, 3))
+				mock.ExpectExec(regexp.QuoteMeta(deleteAllAirportsQuery)).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec(regexp.QuoteMeta(upsertQuery)).
+					WithArgs("Charles De Gaulle", "Paris", "France", "CDG", 49.012779, 2.55).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(regexp.QuoteMeta(deleteNamesQuery)).
+					WithArgs("CDG").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexp.QuoteMeta(insertNameQuery)).
+					WithArgs("CDG", "fr", "Roissy", NameTypeAlias).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+				return db
+			},
+		},
+		{
+			name: "error beginning transaction",
+			mockClosure: func() *sql.DB {
+				db, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				mock.ExpectBegin().WillReturnError(sql.ErrConnDone)
+				return db
+			},
+			expectedError: errors.New("beginning transaction: sql: connection is already closed"),
+		},
+		{
+			name: "error deleting airports",
+			mockClosure: func() *sql.DB {
+				db, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(deleteAllNamesQuery)).
+					WillReturnResult(sqlmock.NewResult(0, 3))
+				mock.ExpectExec(regexp.QuoteMeta(deleteAllAirportsQuery)).
+					WillReturnError(sql.ErrConnDone)
+				mock.ExpectRollback()
+				return db
+			},
+			expectedError: errors.New("deleting airports: sql: connection is already closed"),
+		},
+		{
+			name: "error upserting airport",
+			mockClosure: func() *sql.DB {
+				db, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(deleteAllNamesQuery)).
+					WillReturnResult(sqlmock.NewResult(0, 3))
+				mock.ExpectExec(regexp.QuoteMeta(deleteAllAirportsQuery)).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec(regexp.QuoteMeta(upsertQuery)).
+					WithArgs("Charles De Gaulle", "Paris", "France", "CDG", 49.012779, 2.55).
+					WillReturnError(sql.ErrConnDone)
+				mock.ExpectRollback()
+				return db
+			},
+			expectedError: errors.New("upserting airport: sql: connection is already closed"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := tc.mockClosure()
+			err := NewSqliteStore(db, db).ReplaceAll(context.TODO(), []*Airport{
+				{
+					Name:     "Charles De Gaulle",
+					City:     "Paris",
+					Country:  "France",
+					IataCode: "CDG",
+					Geoloc:   &Geoloc{Lat: 49.012779, Lng: 2.55},
+					Names:    []LocalizedName{{Lang: "fr", Value: "Roissy", Type: NameTypeAlias}},
+				},
+			})
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf(`expected no error, got "%v"`, err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else if tc.expectedError != nil {
+				t.Fatalf(`expected error "%v", got nil`, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestPreviewUpsert(t *testing.T) {
+	airportColumns := []string{"name", "city", "country", "iata_code", "latitude", "longitude"}
+	nameColumns := []string{"iata_code", "lang", "value", "alias_type"}
+	getMany := fmt.Sprintf(getManyQuery, "$1, $2")
+	getManyNames := fmt.Sprintf(getManyNamesQuery, "$1, $2")
+	testCases := []struct {
+		name           string
+		mockClosure    func() *sql.DB
+		expectedOutput Diff
+		expectedError  error
+	}{
+		{
+			name: "happy path",
+			mockClosure: func() *sql.DB {
+				db, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(getMany)).
+					WithArgs("CDG", "GRU").
+					WillReturnRows(sqlmock.NewRows(airportColumns).
+						AddRow("Charles De Gaulle", "Paris", "France", "CDG", 49.012779, 2.55))
+				mock.ExpectQuery(regexp.QuoteMeta(getManyNames)).
+					WithArgs("CDG", "GRU").
+					WillReturnRows(sqlmock.NewRows(nameColumns))
+				mock.ExpectExec(regexp.QuoteMeta(upsertQuery)).
+					WithArgs("Charles De Gaulle", "Paris", "France", "CDG", nil, nil).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(regexp.QuoteMeta(deleteNamesQuery)).
+					WithArgs("CDG").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexp.QuoteMeta(insertNameQuery)).
+					WithArgs("CDG", "fr", "Roissy", NameTypeAlias).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(regexp.QuoteMeta(upsertQuery)).
+					WithArgs("Guarulhos", "Sao Paulo", "Brazil", "GRU", nil, nil).
+					WillReturnResult(sqlmock.NewResult(2, 1))
+				mock.ExpectQuery(regexp.QuoteMeta(getMany)).
+					WithArgs("CDG", "GRU").
+					WillReturnRows(sqlmock.NewRows(airportColumns).
+						AddRow("Charles De Gaulle", "Paris", "France", "CDG", 49.012779, 2.55).
+						AddRow("Guarulhos", "Sao Paulo", "Brazil", "GRU", nil, nil))
+				mock.ExpectQuery(regexp.QuoteMeta(getManyNames)).
+					WithArgs("CDG", "GRU").
+					WillReturnRows(sqlmock.NewRows(nameColumns).
+						AddRow("CDG", "fr", "Roissy", NameTypeAlias))
+				mock.ExpectRollback()
+				return db
+			},
+			expectedOutput: Diff{
+				Added:   []Airport{{Name: "Guarulhos", City: "Sao Paulo", Country: "Brazil", IataCode: "GRU"}},
+				Removed: []Airport{},
+				Changed: []AirportChange{
+					{
+						IataCode: "CDG",
+						Fields: []FieldChange{{
+							Field: FieldNames,
+							Old:   []LocalizedName(nil),
+							New:   []LocalizedName{{Lang: "fr", Value: "Roissy", Type: NameTypeAlias}},
+						}},
+					},
+				},
+			},
+		},
+		{
+			name: "error beginning transaction",
+			mockClosure: func() *sql.DB {
+				db, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				mock.ExpectBegin().WillReturnError(sql.ErrConnDone)
+				return db
+			},
+			expectedError: errors.New("beginning transaction: sql: connection is already closed"),
+		},
+		{
+			name: "error getting airports",
+			mockClosure: func() *sql.DB {
+				db, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(getMany)).
+					WithArgs("CDG", "GRU").
+					WillReturnError(sql.ErrConnDone)
+				mock.ExpectRollback()
+				return db
+			},
+			expectedError: errors.New("getting airports: sql: connection is already closed"),
+		},
+		{
+			name: "error upserting airport",
+			mockClosure: func() *sql.DB {
+				db, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(getMany)).
+					WithArgs("CDG", "GRU").
+					WillReturnRows(sqlmock.NewRows(airportColumns))
+				mock.ExpectQuery(regexp.QuoteMeta(getManyNames)).
+					WithArgs("CDG", "GRU").
+					WillReturnRows(sqlmock.NewRows(nameColumns))
+				mock.ExpectExec(regexp.QuoteMeta(upsertQuery)).
+					WithArgs("Charles De Gaulle", "Paris", "France", "CDG", nil, nil).
+					WillReturnError(errors.New("constraint failed"))
+				mock.ExpectRollback()
+				return db
+			},
+			expectedError: errors.New("upserting airport: constraint failed"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := tc.mockClosure()
+			output, err := NewSqliteStore(db, db).PreviewUpsert(context.TODO(), []*Airport{
+				{
+					Name:     "Charles De Gaulle",
+					City:     "Paris",
+					Country:  "France",
+					IataCode: "CDG",
+					Names:    []LocalizedName{{Lang: "fr", Value: "Roissy", Type: NameTypeAlias}},
+				},
+				{Name: "Guarulhos", City: "Sao Paulo", Country: "Brazil", IataCode: "GRU"},
+			})
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf(`expected no error, got "%v"`, err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf(`expected error "%v", got nil`, tc.expectedError)
+				}
+				require.Equal(t, tc.expectedOutput, output)
+			}
+		})
+	}
+}
+
+func TestExport(t *testing.T) {
+	testCases := []struct {
+		name           string
+		mockClosure    func() *sql.DB
+		expectedOutput []Airport
+		expectedError  error
+	}{
+		{
+			name: "happy path",
+			mockClosure: func() *sql.DB {
+				db, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(exportNamesQuery)).
+					WillReturnRows(sqlmock.NewRows([]string{"iata_code", "lang", "value", "alias_type"}).
+						AddRow("CDG", "fr", "Aéroport Paris-Charles-de-Gaulle", NameTypeOfficial).
+						AddRow("CDG", "fr", "Roissy", NameTypeAlias))
+				mock.ExpectQuery(regexp.QuoteMeta(listQuery)).
+					WithArgs("", "").
+					WillReturnRows(sqlmock.NewRows([]string{"name", "city", "country", "iata_code", "latitude", "longitude"}).
+						AddRow("Charles De Gaulle", "Paris", "France", "CDG", 49.012779, 2.55).
+						AddRow("Chicago Ohare Intl", "Chicago", "United States", "ORD", nil, nil))
+				mock.ExpectRollback()
+				return db
+			},
+			expectedOutput: []Airport{
+				{
+					Name:     "Charles De Gaulle",
+					City:     "Paris",
+					Country:  "France",
+					IataCode: "CDG",
+					Geoloc:   &Geoloc{Lat: 49.012779, Lng: 2.55},
+					Names: []LocalizedName{
+						{Lang: "fr", Value: "Aéroport Paris-Charles-de-Gaulle", Type: NameTypeOfficial},
+						{Lang: "fr", Value: "Roissy", Type: NameTypeAlias},
+					},
+				},
+				{Name: "Chicago Ohare Intl", City: "Chicago", Country: "United States", IataCode: "ORD"},
+			},
+		},
+		{
+			name: "error getting names",
+			mockClosure: func() *sql.DB {
+				db, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(exportNamesQuery)).
+					WillReturnError(sql.ErrConnDone)
+				mock.ExpectRollback()
+				return db
+			},
+			expectedError: errors.New("getting airport names: sql: connection is already closed"),
+		},
+		{
+			name: "error listing airports",
+			mockClosure: func() *sql.DB {
+				db, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(exportNamesQuery)).
+					WillReturnRows(sqlmock.NewRows([]string{"iata_code", "lang", "value", "alias_type"}))
+				mock.ExpectQuery(regexp.QuoteMeta(listQuery)).
+					WithArgs("", "").
+					WillReturnError(sql.ErrConnDone)
+				mock.ExpectRollback()
+				return db
+			},
+			expectedError: errors.New("listing airports: sql: connection is already closed"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := tc.mockClosure()
+			var output []Airport
+			err := NewSqliteStore(db, db).Export(context.TODO(), func(a Airport) error {
+				output = append(output, a)
+				return nil
+			})
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf(`expected no error, got "%v"`, err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf(`expected error "%v", got nil`, tc.expectedError)
+				}
+				require.Equal(t, tc.expectedOutput, output)
+			}
+		})
+	}
+}
@@ -6,7 +6,12 @@ package db
 
 import (
 	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
 
+	_ "github.com/jackc/pgx/v5/stdlib"
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/pkg/errors"
 )
@@ -14,17 +19,228 @@ import (
 // For ease of unit testing.
 var sqlOpen = sql.Open
 
-// ConnectToSqlite establishes a connection to a SQLite database.
-func ConnectToSqlite(sqliteFilePath string) (*sql.DB, error) {
-	// SQLite DSN (Data Source Name) with specific tuning options:
-	// - _journal=WAL        - Enables Write-Ahead Logging (WAL) mode to improve write performance and concurrency.
-	// - _synchronous=NORMAL - Reduces the number of sync operations to disk, balancing speed and durability.
-	// - _cache=private      - Ensures each database connection has its own cache, preventing conflicts in multi-connection scenarios.
-	// - _busy_timeout=5000  - If the database is locked, wait up to 5000ms before failing, improving robustness under contention.
-	dsn := sqliteFilePath + "?_journal=WAL&_synchronous=NORMAL&_cache=private&_busy_timeout=5000"
-	db, err := sqlOpen("sqlite3", dsn)
+// Journal modes and synchronous settings SQLite accepts.
+var (
+	sqliteJournalModes = []string{"DELETE", "TRUNCATE", "PERSIST", "MEMORY", "WAL", "OFF"}
+	sqliteSynchronous  = []string{"OFF", "NORMAL", "FULL", "EXTRA"}
+)
+
+// PoolConfig holds the connection pool settings of a database.
+// Zero values keep database/sql's defaults. On SQLite, the connection
+// counts only apply to the reader pool, the writer always having a single
+// connection.
+type PoolConfig struct {
+	// MaxOpenConns is the maximum number of open connections, unlimited
+	// when zero.
+	MaxOpenConns int
+	// MaxIdleConns is the maximum number of idle connections.
+	MaxIdleConns int
+	// ConnMaxLifetime is how long a connection may be reused, forever when
+	// zero.
+	ConnMaxLifetime time.Duration
+}
+
+// Validate checks that the pool settings make sense.
+func (p PoolConfig) Validate() error {
+	switch {
+	case p.MaxOpenConns < 0:
+		return errors.New("max open connections cannot be negative")
+	case p.MaxIdleConns < 0:
+		return errors.New("max idle connections cannot be negative")
+	case p.MaxOpenConns > 0 && p.MaxIdleConns > p.MaxOpenConns:
+		return errors.Errorf("max idle connections (%d) cannot exceed max open connections (%d)", p.MaxIdleConns, p.MaxOpenConns)
+	case p.ConnMaxLifetime < 0:
+		return errors.New("connection max lifetime cannot be negative")
+	}
+	return nil
+}
+
+// Apply applies the pool settings to db.
+func (p PoolConfig) Apply(db *sql.DB) {
+	if p.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(p.MaxOpenConns)
+	}
+	if p.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(p.MaxIdleConns)
+	}
+	if p.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(p.ConnMaxLifetime)
+	}
+}
+
+// SqliteConfig holds where a SQLite database lives and how it is tuned.
+type SqliteConfig struct {
+	// Path is the database file.
+	Path string
+	// JournalMode is the journal mode, such as WAL or DELETE.
+	JournalMode string
+	// Synchronous is how often SQLite syncs to disk: OFF, NORMAL, FULL or
+	// EXTRA, trading durability for speed.
+	Synchronous string
+	// BusyTimeout is how long to wait for a locked database before failing.
+	BusyTimeout time.Duration
+	// Pool holds the connection pool settings.
+	Pool PoolConfig
+}
+
+// DefaultSqliteConfig returns the settings used for the SQLite database at
+// the given path unless told otherwise:
+//   - WAL journal mode, which improves write performance and concurrency.
+//   - NORMAL synchronous, which reduces the number of syncs to disk,
+//     balancing speed and durability.
+//   - a 5s busy timeout, which improves robustness under contention.
+func DefaultSqliteConfig(sqliteFilePath string) SqliteConfig {
+	return SqliteConfig{
+		Path:        sqliteFilePath,
+		JournalMode: "WAL",
+		Synchronous: "NORMAL",
+		BusyTimeout: 5 * time.Second,
+	}
+}
+
+// Validate checks that the SQLite settings make sense.
+func (c SqliteConfig) Validate() error {
+	if c.Path == "" {
+		return errors.New("sqlite file path cannot be empty")
+	}
+	if !containsFold(sqliteJournalModes, c.JournalMode) {
+		return errors.Errorf("invalid sqlite journal mode %q: expected one of %s", c.JournalMode, strings.Join(sqliteJournalModes, ", "))
+	}
+	if !containsFold(sqliteSynchronous, c.Synchronous) {
+		return errors.Errorf("invalid sqlite synchronous setting %q: expected one of %s", c.Synchronous, strings.Join(sqliteSynchronous, ", "))
+	}
+	if c.BusyTimeout < 0 {
+		return errors.New("sqlite busy timeout cannot be negative")
+	}
+	return errors.Wrap(c.Pool.Validate(), "invalid sqlite pool settings")
+}
+
+// dsn returns the SQLite DSN (Data Source Name) of the writer pool.
+// Besides the settings, _cache=private ensures each connection has its own
+// cache, preventing conflicts in multi-connection scenarios.
+func (c SqliteConfig) dsn() string {
+	params := url.Values{}
+	params.Set("_journal", strings.ToUpper(c.JournalMode))
+	params.Set("_synchronous", strings.ToUpper(c.Synchronous))
+	params.Set("_cache", "private")
+	params.Set("_busy_timeout", fmt.Sprint(c.BusyTimeout.Milliseconds()))
+	return c.Path + "?" + params.Encode()
+}
+
+// readOnlyDsn returns the SQLite DSN of the reader pool. It is a URI, so
+// that mode=ro reaches SQLite, and leaves the journal mode alone, as
+// read-only connections cannot change it.
+func (c SqliteConfig) readOnlyDsn() string {
+	params := url.Values{}
+	params.Set("mode", "ro")
+	params.Set("_synchronous", strings.ToUpper(c.Synchronous))
+	params.Set("_cache", "private")
+	params.Set("_busy_timeout", fmt.Sprint(c.BusyTimeout.Milliseconds()))
+	return "file:" + uriPathEscaper.Replace(c.Path) + "?" + params.Encode()
+}
+
+// uriPathEscaper escapes the characters that have a special meaning in
+// SQLite URI filenames.
+var uriPathEscaper = strings.NewReplacer("%", "%25", "?", "%3F", "#", "%23")
+
+// SqlitePools holds the connection pools of a SQLite database. SQLite
+// allows a single writer at a time, so Writer has a single connection,
+// which keeps writes from failing with "database is locked". Reader opens
+// the database read-only and, in WAL mode, does not queue behind it.
+type SqlitePools struct {
+	Writer *sql.DB
+	Reader *sql.DB
+}
+
+// Close closes both pools. The reader goes first, so that the writer holds
+// the last connection, which checkpoints the WAL and removes it, something
+// a read-only connection cannot do.
+func (p *SqlitePools) Close() error {
+	readerErr := p.Reader.Close()
+	if err := p.Writer.Close(); err != nil {
+		return errors.Wrap(err, "closing sqlite writer pool")
+	}
+	return errors.Wrap(readerErr, "closing sqlite reader pool")
+}
+
+// ConnectToSqlite establishes the writer and reader connection pools of a
+// SQLite database. The pool settings apply to the reader.
+func ConnectToSqlite(cfg SqliteConfig) (*SqlitePools, error) {
+	writer, err := connectToSqliteWriter(cfg)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := sqlOpen("sqlite3", cfg.readOnlyDsn())
+	if err != nil {
+		writer.Close()
+		return nil, errors.Wrapf(err, "opening sqlite file %s read-only", cfg.Path)
+	}
+	cfg.Pool.Apply(reader)
+	return &SqlitePools{Writer: writer, Reader: reader}, nil
+}
+
+// connectToSqliteWriter establishes the single-connection writer pool of
+// a SQLite database.
+func connectToSqliteWriter(cfg SqliteConfig) (*sql.DB, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	db, err := sqlOpen("sqlite3", cfg.dsn())
+	if err != nil {
+		return nil, errors.Wrapf(err, "opening sqlite file %s", cfg.Path)
+	}
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+	if cfg.Pool.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(cfg.Pool.ConnMaxLifetime)
+	}
+	return db, nil
+}
+
+// ErrNoFTS5 is returned when SQLite was compiled without FTS5, which the
+// airports search table needs.
+var ErrNoFTS5 = errors.New("sqlite was built without FTS5: rebuild with -tags sqlite_fts5")
+
+// CheckSqliteFTS5 checks that the SQLite library db is opened with was
+// compiled with FTS5, returning ErrNoFTS5 otherwise.
+func CheckSqliteFTS5(db *sql.DB) error {
+	var enabled bool
+	if err := db.QueryRow("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&enabled); err != nil {
+		return errors.Wrap(err, "checking for sqlite FTS5 support")
+	}
+	if !enabled {
+		return ErrNoFTS5
+	}
+	return nil
+}
+
+// ConnectToPostgres establishes a connection to a PostgreSQL database
+// using the pgx driver.
+func ConnectToPostgres(dsn string) (*sql.DB, error) {
+	db, err := sqlOpen("pgx", dsn)
 	if err != nil {
-		return nil, errors.Wrapf(err, "opening sqlite file %s", sqliteFilePath)
+		return nil, errors.Wrap(err, "opening postgres connection")
 	}
 	return db, nil
 }
+
+// CreatePostgresUnaccent installs the unaccent extension the airports
+// search document needs, unless it already is. Creating extensions takes
+// privileges the service's role may lack, in which case a database
+// administrator must install it beforehand.
+func CreatePostgresUnaccent(db *sql.DB) error {
+	if _, err := db.Exec("CREATE EXTENSION IF NOT EXISTS unaccent"); err != nil {
+		return errors.Wrap(err, "creating the postgres unaccent extension, which a database administrator must install when this role may not")
+	}
+	return nil
+}
+
+// containsFold tells whether values contains s, ignoring case.
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
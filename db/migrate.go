@@ -0,0 +1,143 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package db
+
+import (
+	"io/fs"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/database/pgx/v5"
+	"github.com/golang-migrate/migrate/v4/database/sqlite3"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/pkg/errors"
+	"github.com/tiagomelo/go-airports-service/db/migrations"
+)
+
+// What to do about pending migrations at startup.
+const (
+	// MigrateAuto applies pending migrations.
+	MigrateAuto = "auto"
+	// MigrateCheck fails when there are pending migrations.
+	MigrateCheck = "check"
+	// MigrateOff neither applies nor checks migrations.
+	MigrateOff = "off"
+)
+
+// ErrSchemaBehind is returned when the database schema is older than the
+// one the embedded migrations lead to.
+var ErrSchemaBehind = errors.New("database schema is behind")
+
+// MigrateSqlite applies or checks the embedded SQLite migrations according
+// to mode, returning the resulting schema version. It uses its own
+// connection, which is closed once done.
+func MigrateSqlite(cfg SqliteConfig, mode string) (uint, error) {
+	if mode == MigrateOff {
+		return 0, nil
+	}
+	db, err := connectToSqliteWriter(cfg)
+	if err != nil {
+		return 0, err
+	}
+	// the migrations create an FTS5 table, which would leave the schema
+	// dirty halfway through.
+	if err := CheckSqliteFTS5(db); err != nil {
+		db.Close()
+		return 0, err
+	}
+	driver, err := sqlite3.WithInstance(db, &sqlite3.Config{})
+	if err != nil {
+		db.Close()
+		return 0, errors.Wrap(err, "creating sqlite migration driver")
+	}
+	return runMigrations(migrations.Sqlite, ".", "sqlite3", driver, mode)
+}
+
+// MigratePostgres applies or checks the embedded PostgreSQL migrations
+// according to mode, returning the resulting schema version. It uses its
+// own connection, which is closed once done. Before applying them, the
+// unaccent extension is installed, so that lacking the rights to do so
+// fails before any migration starts rather than leaving the schema dirty.
+func MigratePostgres(dsn, mode string) (uint, error) {
+	if mode == MigrateOff {
+		return 0, nil
+	}
+	db, err := ConnectToPostgres(dsn)
+	if err != nil {
+		return 0, err
+	}
+	if mode == MigrateAuto {
+		if err := CreatePostgresUnaccent(db); err != nil {
+			db.Close()
+			return 0, err
+		}
+	}
+	driver, err := pgx.WithInstance(db, &pgx.Config{})
+	if err != nil {
+		db.Close()
+		return 0, errors.Wrap(err, "creating postgres migration driver")
+	}
+	return runMigrations(migrations.Postgres, "postgres", "pgx5", driver, mode)
+}
+
+// runMigrations applies or checks the migrations found under dir in fsys.
+// It closes the driver, along with its connection, once done.
+func runMigrations(fsys fs.FS, dir, driverName string, driver database.Driver, mode string) (uint, error) {
+	src, err := iofs.New(fsys, dir)
+	if err != nil {
+		driver.Close()
+		return 0, errors.Wrap(err, "reading embedded migrations")
+	}
+	m, err := migrate.NewWithInstance("iofs", src, driverName, driver)
+	if err != nil {
+		src.Close()
+		driver.Close()
+		return 0, errors.Wrap(err, "creating migrator")
+	}
+	defer m.Close()
+	switch mode {
+	case MigrateAuto:
+		if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+			return 0, errors.Wrap(err, "applying migrations")
+		}
+	case MigrateCheck:
+	default:
+		return 0, errors.Errorf("unknown migrate mode %q", mode)
+	}
+	version, dirty, err := m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return 0, errors.Wrap(err, "getting schema version")
+	}
+	if dirty {
+		return version, errors.Errorf("database schema is dirty at version %d: a migration failed halfway and must be fixed by hand", version)
+	}
+	latest, err := latestVersion(src)
+	if err != nil {
+		return version, err
+	}
+	if version < latest {
+		return version, errors.Wrapf(ErrSchemaBehind, "at version %d, expected %d", version, latest)
+	}
+	return version, nil
+}
+
+// latestVersion returns the version of the last migration in src.
+func latestVersion(src source.Driver) (uint, error) {
+	version, err := src.First()
+	if err != nil {
+		return 0, errors.Wrap(err, "reading first migration")
+	}
+	for {
+		next, err := src.Next(version)
+		if errors.Is(err, fs.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, errors.Wrap(err, "reading migrations")
+		}
+		version = next
+	}
+}
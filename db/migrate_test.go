@@ -0,0 +1,97 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package db
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrateSqlite(t *testing.T) {
+	sqlOpen = sql.Open
+	// without FTS5, which the sqlite_fts5 build tag compiles in, migrating
+	// fails upfront.
+	withFTS5 := hasFTS5(t)
+	testCases := []struct {
+		name            string
+		modes           []string
+		needsFTS5       bool
+		expectedVersion uint
+		expectedError   error
+	}{
+		{
+			name:            "auto applies all migrations",
+			modes:           []string{MigrateAuto},
+			needsFTS5:       true,
+			expectedVersion: 5,
+		},
+		{
+			name:            "auto is a no-op when up to date",
+			modes:           []string{MigrateAuto, MigrateAuto},
+			needsFTS5:       true,
+			expectedVersion: 5,
+		},
+		{
+			name:            "check passes when up to date",
+			modes:           []string{MigrateAuto, MigrateCheck},
+			needsFTS5:       true,
+			expectedVersion: 5,
+		},
+		{
+			name:          "check fails when behind",
+			modes:         []string{MigrateCheck},
+			needsFTS5:     true,
+			expectedError: errors.Wrap(ErrSchemaBehind, "at version 0, expected 5"),
+		},
+		{
+			name:  "off does nothing",
+			modes: []string{MigrateOff},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.needsFTS5 && !withFTS5 {
+				tc.expectedError = ErrNoFTS5
+			}
+			path := filepath.Join(t.TempDir(), "test.db")
+			var (
+				version uint
+				err     error
+			)
+			for _, mode := range tc.modes {
+				version, err = MigrateSqlite(DefaultSqliteConfig(path), mode)
+			}
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf(`expected no error, got "%v"`, err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+				require.True(t, errors.Is(err, errors.Cause(tc.expectedError)))
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf(`expected error "%v", got nil`, tc.expectedError)
+				}
+				require.Equal(t, tc.expectedVersion, version)
+			}
+		})
+	}
+}
+
+// hasFTS5 reports whether SQLite was compiled with FTS5.
+func hasFTS5(t *testing.T) bool {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	err = CheckSqliteFTS5(db)
+	if errors.Is(err, ErrNoFTS5) {
+		return false
+	}
+	require.NoError(t, err)
+	return true
+}
@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS airport_names_fts_after_delete;
+DROP TRIGGER IF EXISTS airport_names_fts_after_update;
+DROP TRIGGER IF EXISTS airport_names_fts_after_insert;
+DROP TRIGGER IF EXISTS airports_fts_after_delete;
+DROP TRIGGER IF EXISTS airports_fts_after_update;
+DROP TRIGGER IF EXISTS airports_fts_after_insert;
+DROP TABLE IF EXISTS airports_fts;
@@ -0,0 +1,51 @@
+CREATE VIRTUAL TABLE IF NOT EXISTS airports_fts USING fts5 (
+    iata_code,
+    name,
+    city,
+    country,
+    aliases,
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+INSERT INTO airports_fts (rowid, iata_code, name, city, country, aliases)
+SELECT a.id, a.iata_code, a.name, a.city, a.country,
+    (SELECT group_concat(n.value, ' ') FROM airport_names n WHERE n.iata_code = a.iata_code)
+FROM airports a;
+
+CREATE TRIGGER IF NOT EXISTS airports_fts_after_insert AFTER INSERT ON airports BEGIN
+    INSERT INTO airports_fts (rowid, iata_code, name, city, country, aliases)
+    VALUES (new.id, new.iata_code, new.name, new.city, new.country,
+        (SELECT group_concat(n.value, ' ') FROM airport_names n WHERE n.iata_code = new.iata_code));
+END;
+
+CREATE TRIGGER IF NOT EXISTS airports_fts_after_update AFTER UPDATE ON airports BEGIN
+    DELETE FROM airports_fts WHERE rowid = old.id;
+    INSERT INTO airports_fts (rowid, iata_code, name, city, country, aliases)
+    VALUES (new.id, new.iata_code, new.name, new.city, new.country,
+        (SELECT group_concat(n.value, ' ') FROM airport_names n WHERE n.iata_code = new.iata_code));
+END;
+
+CREATE TRIGGER IF NOT EXISTS airports_fts_after_delete AFTER DELETE ON airports BEGIN
+    DELETE FROM airports_fts WHERE rowid = old.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS airport_names_fts_after_insert AFTER INSERT ON airport_names BEGIN
+    UPDATE airports_fts
+    SET aliases = (SELECT group_concat(n.value, ' ') FROM airport_names n WHERE n.iata_code = new.iata_code)
+    WHERE rowid = (SELECT a.id FROM airports a WHERE a.iata_code = new.iata_code);
+END;
+
+CREATE TRIGGER IF NOT EXISTS airport_names_fts_after_update AFTER UPDATE ON airport_names BEGIN
+    UPDATE airports_fts
+    SET aliases = (SELECT group_concat(n.value, ' ') FROM airport_names n WHERE n.iata_code = old.iata_code)
+    WHERE rowid = (SELECT a.id FROM airports a WHERE a.iata_code = old.iata_code);
+    UPDATE airports_fts
+    SET aliases = (SELECT group_concat(n.value, ' ') FROM airport_names n WHERE n.iata_code = new.iata_code)
+    WHERE rowid = (SELECT a.id FROM airports a WHERE a.iata_code = new.iata_code);
+END;
+
+CREATE TRIGGER IF NOT EXISTS airport_names_fts_after_delete AFTER DELETE ON airport_names BEGIN
+    UPDATE airports_fts
+    SET aliases = (SELECT group_concat(n.value, ' ') FROM airport_names n WHERE n.iata_code = old.iata_code)
+    WHERE rowid = (SELECT a.id FROM airports a WHERE a.iata_code = old.iata_code);
+END;
@@ -0,0 +1,2 @@
+ALTER TABLE airports DROP COLUMN longitude;
+ALTER TABLE airports DROP COLUMN latitude;
@@ -0,0 +1,2 @@
+ALTER TABLE airports ADD COLUMN latitude REAL;
+ALTER TABLE airports ADD COLUMN longitude REAL;
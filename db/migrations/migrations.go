@@ -0,0 +1,19 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+// Package migrations embeds the database migrations, so that they ship
+// with the binary and can be applied at startup.
+package migrations
+
+import "embed"
+
+// Sqlite holds the SQLite migrations.
+//
+//go:embed *.sql
+var Sqlite embed.FS
+
+// Postgres holds the PostgreSQL migrations, under the postgres directory.
+//
+//go:embed postgres/*.sql
+var Postgres embed.FS
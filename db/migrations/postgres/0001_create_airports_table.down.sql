@@ -0,0 +1 @@
+DROP TABLE IF EXISTS airports;
\ No newline at end of file
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS airports (
+    id BIGSERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    city TEXT NOT NULL,
+    country TEXT NOT NULL,
+    iata_code TEXT NOT NULL UNIQUE
+);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS airport_names;
\ No newline at end of file
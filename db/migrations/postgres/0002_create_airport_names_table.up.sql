@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS airport_names (
+    id BIGSERIAL PRIMARY KEY,
+    iata_code TEXT NOT NULL,
+    lang TEXT NOT NULL,
+    value TEXT NOT NULL,
+    alias_type TEXT NOT NULL DEFAULT 'official',
+    UNIQUE (iata_code, lang, value)
+);
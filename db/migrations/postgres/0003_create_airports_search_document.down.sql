@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS airport_names_search_document_after_write ON airport_names;
+DROP FUNCTION IF EXISTS airport_names_search_document_after_write();
+DROP TRIGGER IF EXISTS airports_search_document_before_write ON airports;
+DROP FUNCTION IF EXISTS airports_search_document_before_write();
+DROP INDEX IF EXISTS airports_search_document_idx;
+ALTER TABLE airports DROP COLUMN IF EXISTS search_document;
+DROP FUNCTION IF EXISTS airports_search_document(TEXT, TEXT, TEXT, TEXT);
@@ -0,0 +1,48 @@
+CREATE EXTENSION IF NOT EXISTS unaccent;
+
+ALTER TABLE airports ADD COLUMN IF NOT EXISTS search_document TSVECTOR;
+
+CREATE OR REPLACE FUNCTION airports_search_document(p_iata_code TEXT, p_name TEXT, p_city TEXT, p_country TEXT)
+RETURNS TSVECTOR AS $$
+    SELECT setweight(to_tsvector('simple', unaccent(p_iata_code)), 'A') ||
+        setweight(to_tsvector('simple', unaccent(p_name)), 'B') ||
+        setweight(to_tsvector('simple', unaccent(coalesce(
+            (SELECT string_agg(n.value, ' ') FROM airport_names n WHERE n.iata_code = p_iata_code), ''))), 'B') ||
+        setweight(to_tsvector('simple', unaccent(p_city)), 'C') ||
+        setweight(to_tsvector('simple', unaccent(p_country)), 'D');
+$$ LANGUAGE SQL STABLE;
+
+UPDATE airports SET search_document = airports_search_document(iata_code, name, city, country);
+
+CREATE INDEX IF NOT EXISTS airports_search_document_idx ON airports USING GIN (search_document);
+
+CREATE OR REPLACE FUNCTION airports_search_document_before_write() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.search_document := airports_search_document(NEW.iata_code, NEW.name, NEW.city, NEW.country);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER airports_search_document_before_write
+BEFORE INSERT OR UPDATE OF iata_code, name, city, country ON airports
+FOR EACH ROW EXECUTE FUNCTION airports_search_document_before_write();
+
+CREATE OR REPLACE FUNCTION airport_names_search_document_after_write() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE airports
+        SET search_document = airports_search_document(iata_code, name, city, country)
+        WHERE iata_code = OLD.iata_code;
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        UPDATE airports
+        SET search_document = airports_search_document(iata_code, name, city, country)
+        WHERE iata_code = NEW.iata_code;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER airport_names_search_document_after_write
+AFTER INSERT OR UPDATE OR DELETE ON airport_names
+FOR EACH ROW EXECUTE FUNCTION airport_names_search_document_after_write();
@@ -0,0 +1,2 @@
+ALTER TABLE airports DROP COLUMN IF EXISTS longitude;
+ALTER TABLE airports DROP COLUMN IF EXISTS latitude;
@@ -0,0 +1,2 @@
+ALTER TABLE airports ADD COLUMN IF NOT EXISTS latitude DOUBLE PRECISION;
+ALTER TABLE airports ADD COLUMN IF NOT EXISTS longitude DOUBLE PRECISION;
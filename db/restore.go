@@ -0,0 +1,175 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package db
+
+import (
+	"database/sql"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4/database/sqlite3"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/pkg/errors"
+	"github.com/tiagomelo/go-airports-service/db/migrations"
+)
+
+// ReplacedSuffix is appended, after a timestamp, to the name of the
+// database file a restore replaces, which is kept around in case the
+// restore must be undone.
+const ReplacedSuffix = ".bak"
+
+// replacedTimeLayout is the layout of the timestamp in the name of the
+// database file a restore replaces.
+const replacedTimeLayout = "20060102T150405Z"
+
+// For ease of unit testing.
+var (
+	now      = time.Now
+	osRename = os.Rename
+)
+
+// RestoreSqlite swaps the SQLite database at sqliteFilePath for the backup
+// at backupPath, returning the backup's schema version and the path the
+// replaced database is kept at, if there was one. The backup must pass an
+// integrity check and carry a clean schema version no newer than the
+// embedded migrations know of; older ones are brought up to date by the
+// migrations at startup. The replaced database, along with its WAL, is
+// kept with the time of the restore and the .bak suffix appended to its
+// name, and the restore is refused rather than overwriting files kept by
+// an earlier one. Should a rename fail, the files already moved aside are
+// put back. The service must not be running.
+func RestoreSqlite(backupPath, sqliteFilePath string) (uint, string, error) {
+	previous := sqliteFilePath + "." + now().UTC().Format(replacedTimeLayout) + ReplacedSuffix
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		if _, err := os.Lstat(previous + suffix); err == nil {
+			return 0, "", errors.Errorf("previous database file already kept at %s", previous+suffix)
+		}
+	}
+	tmp, err := copyNextTo(backupPath, sqliteFilePath)
+	if err != nil {
+		return 0, "", err
+	}
+	// The copy is the one validated, as checking FTS5 tables requires
+	// write access, which the backup must not be exposed to.
+	version, err := validateSqliteBackup(tmp)
+	if err != nil {
+		os.Remove(tmp)
+		return 0, "", err
+	}
+	// The WAL and shared memory files are moved aside before the database
+	// file, so that the database is the last one to leave its path, and
+	// everything moved is put back should a rename fail.
+	var moved []string
+	undo := func() {
+		for i := len(moved) - 1; i >= 0; i-- {
+			osRename(previous+moved[i], sqliteFilePath+moved[i])
+		}
+		os.Remove(tmp)
+	}
+	for _, suffix := range []string{"-wal", "-shm", ""} {
+		err := osRename(sqliteFilePath+suffix, previous+suffix)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			undo()
+			return 0, "", errors.Wrapf(err, "keeping previous database file %s", sqliteFilePath+suffix)
+		}
+		moved = append(moved, suffix)
+	}
+	if err := osRename(tmp, sqliteFilePath); err != nil {
+		undo()
+		return 0, "", errors.Wrap(err, "swapping database file")
+	}
+	if len(moved) == 0 || moved[len(moved)-1] != "" {
+		previous = ""
+	}
+	return version, previous, nil
+}
+
+// validateSqliteBackup checks the integrity and the schema version of a
+// SQLite backup, returning the latter.
+func validateSqliteBackup(backupPath string) (uint, error) {
+	backup, err := sqlOpen("sqlite3", backupPath)
+	if err != nil {
+		return 0, errors.Wrap(err, "opening backup")
+	}
+	defer backup.Close()
+	var check string
+	if err := backup.QueryRow("PRAGMA quick_check").Scan(&check); err != nil {
+		return 0, errors.Wrap(err, "checking backup integrity")
+	}
+	if check != "ok" {
+		return 0, errors.Errorf("backup is corrupt: %s", check)
+	}
+	var (
+		version uint
+		dirty   bool
+	)
+	err = backup.QueryRow("SELECT version, dirty FROM "+sqlite3.DefaultMigrationsTable+" LIMIT 1").Scan(&version, &dirty)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, errors.Wrap(err, "reading backup schema version")
+	}
+	if version == 0 {
+		return 0, errors.New("backup has no schema version")
+	}
+	if dirty {
+		return 0, errors.Errorf("backup schema is dirty at version %d", version)
+	}
+	src, err := iofs.New(migrations.Sqlite, ".")
+	if err != nil {
+		return 0, errors.Wrap(err, "reading embedded migrations")
+	}
+	defer src.Close()
+	latest, err := latestVersion(src)
+	if err != nil {
+		return 0, err
+	}
+	if version > latest {
+		return 0, errors.Errorf("backup schema version %d is newer than the latest known one, %d", version, latest)
+	}
+	return version, nil
+}
+
+// copyNextTo copies src into a temporary file in the directory of dst,
+// with the permissions of dst, so that it can then be atomically renamed
+// to dst, and returns its path.
+func copyNextTo(src, dst string) (string, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return "", errors.Wrap(err, "opening backup")
+	}
+	defer in.Close()
+	out, err := os.CreateTemp(filepath.Dir(dst), filepath.Base(dst)+".restore-*")
+	if err != nil {
+		return "", errors.Wrap(err, "creating temporary database file")
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(out.Name())
+		return "", errors.Wrap(err, "copying backup")
+	}
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(dst); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := out.Chmod(mode); err != nil {
+		out.Close()
+		os.Remove(out.Name())
+		return "", errors.Wrap(err, "setting temporary database file mode")
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		os.Remove(out.Name())
+		return "", errors.Wrap(err, "syncing temporary database file")
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(out.Name())
+		return "", errors.Wrap(err, "closing temporary database file")
+	}
+	return out.Name(), nil
+}
@@ -0,0 +1,232 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// createSqliteFile creates a SQLite database file holding the given
+// statements.
+func createSqliteFile(t *testing.T, path, statements string) {
+	db, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.Exec(statements)
+	require.NoError(t, err)
+}
+
+func TestRestoreSqlite(t *testing.T) {
+	const schemaMigrations = `CREATE TABLE schema_migrations (version uint64, dirty bool);`
+	testCases := []struct {
+		name            string
+		backup          func(path string)
+		expectedVersion uint
+		expectedError   error
+	}{
+		{
+			name: "happy path",
+			backup: func(path string) {
+				createSqliteFile(t, path, schemaMigrations+`
+					INSERT INTO schema_migrations VALUES (4, false);
+					CREATE TABLE airports (iata_code TEXT);
+					INSERT INTO airports VALUES ('CDG'), ('GRU');`)
+			},
+			expectedVersion: 4,
+		},
+		{
+			name: "older schema version",
+			backup: func(path string) {
+				createSqliteFile(t, path, schemaMigrations+`
+					INSERT INTO schema_migrations VALUES (2, false);
+					CREATE TABLE airports (iata_code TEXT);
+					INSERT INTO airports VALUES ('CDG'), ('GRU');`)
+			},
+			expectedVersion: 2,
+		},
+		{
+			name: "not a database",
+			backup: func(path string) {
+				require.NoError(t, os.WriteFile(path, []byte("definitely not a database file, yet long enough for sqlite to look at its header"), 0o644))
+			},
+			expectedError: errors.New("checking backup integrity: file is not a database"),
+		},
+		{
+			name: "no schema version table",
+			backup: func(path string) {
+				createSqliteFile(t, path, `CREATE TABLE airports (iata_code TEXT);`)
+			},
+			expectedError: errors.New("reading backup schema version: no such table: schema_migrations"),
+		},
+		{
+			name: "no schema version",
+			backup: func(path string) {
+				createSqliteFile(t, path, schemaMigrations)
+			},
+			expectedError: errors.New("backup has no schema version"),
+		},
+		{
+			name: "dirty schema",
+			backup: func(path string) {
+				createSqliteFile(t, path, schemaMigrations+`INSERT INTO schema_migrations VALUES (3, true);`)
+			},
+			expectedError: errors.New("backup schema is dirty at version 3"),
+		},
+		{
+			name: "newer schema version",
+			backup: func(path string) {
+				createSqliteFile(t, path, schemaMigrations+`INSERT INTO schema_migrations VALUES (99, false);`)
+			},
+			expectedError: errors.New("backup schema version 99 is newer than the latest known one, 5"),
+		},
+	}
+	restoredAt := time.Date(2025, 6, 1, 12, 30, 0, 0, time.UTC)
+	defer func() {
+		now = time.Now
+	}()
+	for _, tc := range testCases {
+		sqlOpen = sql.Open
+		now = func() time.Time { return restoredAt }
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			backupPath := filepath.Join(dir, "backup.db")
+			dbPath := filepath.Join(dir, "airports.db")
+			previousPath := dbPath + ".20250601T123000Z" + ReplacedSuffix
+			tc.backup(backupPath)
+			createSqliteFile(t, dbPath, `CREATE TABLE airports (iata_code TEXT); INSERT INTO airports VALUES ('LHR');`)
+			require.NoError(t, os.WriteFile(dbPath+"-wal", nil, 0o644))
+
+			version, previous, err := RestoreSqlite(backupPath, dbPath)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf(`expected no error, got "%v"`, err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+				require.NoFileExists(t, previousPath)
+				leftovers, err := filepath.Glob(dbPath + ".restore-*")
+				require.NoError(t, err)
+				require.Empty(t, leftovers)
+				return
+			}
+			if tc.expectedError != nil {
+				t.Fatalf(`expected error "%v", got nil`, tc.expectedError)
+			}
+			require.Equal(t, tc.expectedVersion, version)
+			require.Equal(t, previousPath, previous)
+			require.FileExists(t, previousPath)
+			require.FileExists(t, previousPath+"-wal")
+			require.NoFileExists(t, dbPath+"-wal")
+
+			restored, err := sql.Open("sqlite3", dbPath)
+			require.NoError(t, err)
+			defer restored.Close()
+			var rows int
+			require.NoError(t, restored.QueryRow(`SELECT COUNT(*) FROM airports`).Scan(&rows))
+			require.Equal(t, 2, rows)
+		})
+	}
+}
+
+func TestRestoreSqliteKeepsEarlierBackups(t *testing.T) {
+	sqlOpen = sql.Open
+	defer func() {
+		now = time.Now
+	}()
+	dir := t.TempDir()
+	backupPath := filepath.Join(dir, "backup.db")
+	dbPath := filepath.Join(dir, "airports.db")
+	createSqliteFile(t, backupPath, `CREATE TABLE schema_migrations (version uint64, dirty bool);
+		INSERT INTO schema_migrations VALUES (5, false);`)
+	createSqliteFile(t, dbPath, `CREATE TABLE airports (iata_code TEXT);`)
+
+	now = func() time.Time { return time.Date(2025, 6, 1, 12, 30, 0, 0, time.UTC) }
+	_, first, err := RestoreSqlite(backupPath, dbPath)
+	require.NoError(t, err)
+	require.Equal(t, dbPath+".20250601T123000Z"+ReplacedSuffix, first)
+
+	now = func() time.Time { return time.Date(2025, 6, 2, 8, 0, 0, 0, time.UTC) }
+	_, second, err := RestoreSqlite(backupPath, dbPath)
+	require.NoError(t, err)
+	require.Equal(t, dbPath+".20250602T080000Z"+ReplacedSuffix, second)
+	require.FileExists(t, first)
+	require.FileExists(t, second)
+
+	// a restore within the same second as the previous one is refused.
+	_, _, err = RestoreSqlite(backupPath, dbPath)
+	require.EqualError(t, err, "previous database file already kept at "+second)
+	require.FileExists(t, dbPath)
+	leftovers, err := filepath.Glob(dbPath + ".restore-*")
+	require.NoError(t, err)
+	require.Empty(t, leftovers)
+}
+
+func TestRestoreSqliteUndoesRenames(t *testing.T) {
+	testCases := []struct {
+		name          string
+		failingRename func(oldpath, dbPath string) bool
+		expectedError func(dbPath string) string
+	}{
+		{
+			name:          "keeping the database file fails",
+			failingRename: func(oldpath, dbPath string) bool { return oldpath == dbPath },
+			expectedError: func(dbPath string) string {
+				return "keeping previous database file " + dbPath + ": rename error"
+			},
+		},
+		{
+			name:          "swapping the database file fails",
+			failingRename: func(oldpath, dbPath string) bool { return strings.HasPrefix(oldpath, dbPath+".restore-") },
+			expectedError: func(dbPath string) string { return "swapping database file: rename error" },
+		},
+	}
+	defer func() {
+		now = time.Now
+		osRename = os.Rename
+	}()
+	for _, tc := range testCases {
+		sqlOpen = sql.Open
+		now = func() time.Time { return time.Date(2025, 6, 1, 12, 30, 0, 0, time.UTC) }
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			backupPath := filepath.Join(dir, "backup.db")
+			dbPath := filepath.Join(dir, "airports.db")
+			createSqliteFile(t, backupPath, `CREATE TABLE schema_migrations (version uint64, dirty bool);
+				INSERT INTO schema_migrations VALUES (5, false);`)
+			createSqliteFile(t, dbPath, `CREATE TABLE airports (iata_code TEXT); INSERT INTO airports VALUES ('LHR');`)
+			require.NoError(t, os.WriteFile(dbPath+"-wal", []byte("wal"), 0o644))
+			original, err := os.ReadFile(dbPath)
+			require.NoError(t, err)
+			osRename = func(oldpath, newpath string) error {
+				if tc.failingRename(oldpath, dbPath) {
+					return errors.New("rename error")
+				}
+				return os.Rename(oldpath, newpath)
+			}
+
+			_, _, err = RestoreSqlite(backupPath, dbPath)
+			require.EqualError(t, err, tc.expectedError(dbPath))
+			current, err := os.ReadFile(dbPath)
+			require.NoError(t, err)
+			require.Equal(t, original, current)
+			wal, err := os.ReadFile(dbPath + "-wal")
+			require.NoError(t, err)
+			require.Equal(t, "wal", string(wal))
+			kept, err := filepath.Glob(dbPath + ".*" + ReplacedSuffix + "*")
+			require.NoError(t, err)
+			require.Empty(t, kept)
+			leftovers, err := filepath.Glob(dbPath + ".restore-*")
+			require.NoError(t, err)
+			require.Empty(t, leftovers)
+		})
+	}
+}
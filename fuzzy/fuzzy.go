@@ -0,0 +1,157 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+// Package fuzzy provides string similarity measures used to match
+// misspelled or abbreviated airport names to existing airports.
+package fuzzy
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// abbreviations maps abbreviations commonly found in supplier feeds to
+// the words they stand for.
+var abbreviations = map[string]string{
+	"apt":   "airport",
+	"arpt":  "airport",
+	"fld":   "field",
+	"ft":    "fort",
+	"intl":  "international",
+	"intnl": "international",
+	"mt":    "mount",
+	"muni":  "municipal",
+	"natl":  "national",
+	"regl":  "regional",
+	"rgnl":  "regional",
+	"st":    "saint",
+}
+
+// Normalize lowercases s, strips diacritics and apostrophes, collapses any
+// other punctuation into single spaces and expands common abbreviations,
+// so that "Chicago O'Hare Int'l" and "chicago ohare international" compare equal.
+func Normalize(s string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(t, s)
+	if err != nil {
+		folded = s
+	}
+	folded = strings.NewReplacer("'", "", "’", "").Replace(strings.ToLower(folded))
+	words := strings.FieldsFunc(folded, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	for i, w := range words {
+		if expanded, ok := abbreviations[w]; ok {
+			words[i] = expanded
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// JaroWinkler returns the Jaro-Winkler similarity between a and b, from 0
+// (nothing in common) to 1 (identical).
+func JaroWinkler(a, b string) float64 {
+	const (
+		boostThreshold = 0.7
+		prefixScale    = 0.1
+		maxPrefix      = 4
+	)
+	ra, rb := []rune(a), []rune(b)
+	sim := jaro(ra, rb)
+	if sim <= boostThreshold {
+		return sim
+	}
+	prefix := 0
+	for prefix < len(ra) && prefix < len(rb) && prefix < maxPrefix && ra[prefix] == rb[prefix] {
+		prefix++
+	}
+	return sim + float64(prefix)*prefixScale*(1-sim)
+}
+
+// jaro returns the Jaro similarity between a and b.
+func jaro(a, b []rune) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	window := max(len(a), len(b))/2 - 1
+	if window < 0 {
+		window = 0
+	}
+	matchedA := make([]bool, len(a))
+	matchedB := make([]bool, len(b))
+	matches := 0
+	for i := range a {
+		lo, hi := max(0, i-window), min(len(b), i+window+1)
+		for j := lo; j < hi; j++ {
+			if matchedB[j] || a[i] != b[j] {
+				continue
+			}
+			matchedA[i], matchedB[j] = true, true
+			matches++
+			break
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+	transpositions, j := 0, 0
+	for i := range a {
+		if !matchedA[i] {
+			continue
+		}
+		for !matchedB[j] {
+			j++
+		}
+		if a[i] != b[j] {
+			transpositions++
+		}
+		j++
+	}
+	m := float64(matches)
+	return (m/float64(len(a)) + m/float64(len(b)) + (m-float64(transpositions)/2)/m) / 3
+}
+
+// Trigram returns the trigram similarity between a and b, that is, the
+// number of trigrams they share divided by the number of distinct trigrams
+// in both. As in PostgreSQL's pg_trgm, each word is padded so that word
+// boundaries count.
+func Trigram(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	if len(ta) == 0 && len(tb) == 0 {
+		return 1
+	}
+	shared := 0
+	for t := range ta {
+		if tb[t] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(ta)+len(tb)-shared)
+}
+
+// trigrams returns the set of trigrams of the words in s.
+func trigrams(s string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range strings.Fields(s) {
+		padded := []rune("  " + w + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			set[string(padded[i:i+3])] = true
+		}
+	}
+	return set
+}
+
+// Similarity combines Jaro-Winkler, which rewards common prefixes and
+// tolerates typos, with trigram similarity, which tolerates reordered and
+// missing words. Both strings are expected to be normalized.
+func Similarity(a, b string) float64 {
+	return (JaroWinkler(a, b) + Trigram(a, b)) / 2
+}
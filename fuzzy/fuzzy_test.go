@@ -0,0 +1,72 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package fuzzy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	testCases := []struct {
+		input          string
+		expectedOutput string
+	}{
+		{input: "Chicago O'Hare Int'l", expectedOutput: "chicago ohare international"},
+		{input: "Chicago Ohare Intl", expectedOutput: "chicago ohare international"},
+		{input: "São Paulo/Congonhas", expectedOutput: "sao paulo congonhas"},
+		{input: "  St. Louis Lambert Intl Arpt ", expectedOutput: "saint louis lambert international airport"},
+		{input: "", expectedOutput: ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			require.Equal(t, tc.expectedOutput, Normalize(tc.input))
+		})
+	}
+}
+
+func TestJaroWinkler(t *testing.T) {
+	testCases := []struct {
+		a, b           string
+		expectedOutput float64
+	}{
+		{a: "martha", b: "marhta", expectedOutput: 0.961},
+		{a: "dwayne", b: "duane", expectedOutput: 0.84},
+		{a: "dixon", b: "dicksonx", expectedOutput: 0.813},
+		{a: "heathrow", b: "heathrow", expectedOutput: 1},
+		{a: "", b: "", expectedOutput: 1},
+		{a: "abc", b: "", expectedOutput: 0},
+		{a: "abc", b: "xyz", expectedOutput: 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.a+"/"+tc.b, func(t *testing.T) {
+			require.InDelta(t, tc.expectedOutput, JaroWinkler(tc.a, tc.b), 0.001)
+		})
+	}
+}
+
+func TestTrigram(t *testing.T) {
+	testCases := []struct {
+		a, b           string
+		expectedOutput float64
+	}{
+		{a: "word", b: "word", expectedOutput: 1},
+		{a: "word", b: "two words", expectedOutput: 0.363},
+		{a: "abc", b: "xyz", expectedOutput: 0},
+		{a: "", b: "", expectedOutput: 1},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.a+"/"+tc.b, func(t *testing.T) {
+			require.InDelta(t, tc.expectedOutput, Trigram(tc.a, tc.b), 0.001)
+		})
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	ohare := Normalize("Chicago O'Hare International")
+	require.Equal(t, 1.0, Similarity(Normalize("Chicago Ohare Intl"), ohare))
+	require.Greater(t, Similarity(Normalize("Chicago Ohara Intl"), ohare), Similarity(Normalize("Chicago Midway Intl"), ohare))
+}
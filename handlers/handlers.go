@@ -5,23 +5,68 @@
 package handlers
 
 import (
-	"database/sql"
 	"log/slog"
+	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/tiagomelo/go-airports-service/apikeys"
+	"github.com/tiagomelo/go-airports-service/auth"
+	"github.com/tiagomelo/go-airports-service/autocomplete"
+	"github.com/tiagomelo/go-airports-service/db/airports"
 	v1 "github.com/tiagomelo/go-airports-service/handlers/v1"
+	airportshandlers "github.com/tiagomelo/go-airports-service/handlers/v1/airports"
+	"github.com/tiagomelo/go-airports-service/metrics"
+	"github.com/tiagomelo/go-airports-service/middleware"
+	"github.com/tiagomelo/go-airports-service/ratelimit"
+	"github.com/tiagomelo/go-airports-service/snapshots"
 )
 
 // ApiMuxConfig struct holds the configuration for the API.
 type ApiMuxConfig struct {
-	Db  *sql.DB
-	Log *slog.Logger
+	Store               airports.AirportStore
+	Index               *autocomplete.Index
+	Snapshots           *snapshots.Store
+	Log                 *slog.Logger
+	AccessLogSampleRate float64
+	Authenticator       auth.Authenticator
+	APIKeys             apikeys.Store
+	RateLimiter         *ratelimit.Limiter
+	IPRateLimiter       *ratelimit.Limiter
+	IngestQuota         *ratelimit.Limiter
+	BodyLimits          v1.BodyLimits
+	UpsertLimits        airportshandlers.Limits
+	MinUploadRate       int64
+	MinUploadRateWindow time.Duration
+	CORS                *middleware.CORSOptions
 }
 
-// NewApiMux creates and returns a new mux.Router configured with version 1 (v1) routes.
+// NewApiMux creates and returns a new mux.Router configured with version 1 (v1) routes,
+// along with the Prometheus metrics endpoint, which requires the admin scope when
+// authentication is enabled, as metrics reveal traffic and ingest volumes.
 func NewApiMux(c *ApiMuxConfig) *mux.Router {
-	return v1.Routes(&v1.Config{
-		Db:  c.Db,
-		Log: c.Log,
+	router := v1.Routes(&v1.Config{
+		Store:               c.Store,
+		Index:               c.Index,
+		Snapshots:           c.Snapshots,
+		Log:                 c.Log,
+		AccessLogSampleRate: c.AccessLogSampleRate,
+		Authenticator:       c.Authenticator,
+		APIKeys:             c.APIKeys,
+		RateLimiter:         c.RateLimiter,
+		IPRateLimiter:       c.IPRateLimiter,
+		IngestQuota:         c.IngestQuota,
+		BodyLimits:          c.BodyLimits,
+		UpsertLimits:        c.UpsertLimits,
+		MinUploadRate:       c.MinUploadRate,
+		MinUploadRateWindow: c.MinUploadRateWindow,
+		CORS:                c.CORS,
 	})
+	metricsHandler := metrics.Handler()
+	if c.Authenticator != nil {
+		// scrapes are not audited, as they would flood the audit log.
+		metricsHandler = middleware.Authorize(c.Authenticator, auth.ScopeAdmin, metricsHandler)
+	}
+	router.Handle("/metrics", metricsHandler).Methods(http.MethodGet)
+	return router
 }
@@ -6,33 +6,84 @@ package airports
 
 import (
 	"bufio"
+	"bytes"
 	"context"
-	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/tiagomelo/go-airports-service/autocomplete"
 	"github.com/tiagomelo/go-airports-service/db/airports"
+	"github.com/tiagomelo/go-airports-service/metrics"
+	"github.com/tiagomelo/go-airports-service/middleware"
+	"github.com/tiagomelo/go-airports-service/ratelimit"
 	"github.com/tiagomelo/go-airports-service/validate"
 	"github.com/tiagomelo/go-airports-service/web"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // UpsertAirportRequest represents a request to upsert an airport.
 type UpsertAirportRequest struct {
-	Name     string `json:"name" validate:"required"`
-	City     string `json:"city" validate:"required"`
-	Country  string `json:"country" validate:"required"`
-	IataCode string `json:"iata_code" validate:"required"`
+	Name     string                 `json:"name" validate:"required"`
+	City     string                 `json:"city" validate:"required"`
+	Country  string                 `json:"country" validate:"required"`
+	IataCode string                 `json:"iata_code" validate:"required,len=3,alpha"`
+	Geoloc   *GeolocRequest         `json:"geoloc"`
+	Names    []LocalizedNameRequest `json:"names" validate:"omitempty,dive"`
 }
 
-// ToAirport converts an upsert airport request to an airport.
+// GeolocRequest represents an airport's coordinates, in decimal degrees.
+type GeolocRequest struct {
+	Lat *float64 `json:"lat" validate:"required,latitude"`
+	Lng *float64 `json:"lng" validate:"required,longitude"`
+}
+
+// LocalizedNameRequest represents a name an airport is known by in a given language.
+type LocalizedNameRequest struct {
+	Lang  string `json:"lang" validate:"required,bcp47_language_tag"`
+	Value string `json:"value" validate:"required"`
+	Type  string `json:"type" validate:"omitempty,oneof=official alias"`
+}
+
+// ToAirport converts an upsert airport request to an airport, whose IATA
+// code is uppercased, as the one airports are looked up and deleted by.
+// Localized names repeated in the same language are only kept once, as
+// first given, since the database stores each one once per airport.
 func (u *UpsertAirportRequest) ToAirport() *airports.Airport {
-	return &airports.Airport{
+	airport := &airports.Airport{
 		Name:     u.Name,
 		City:     u.City,
 		Country:  u.Country,
-		IataCode: u.IataCode,
+		IataCode: strings.ToUpper(u.IataCode),
+	}
+	if u.Geoloc != nil {
+		airport.Geoloc = &airports.Geoloc{
+			Lat: *u.Geoloc.Lat,
+			Lng: *u.Geoloc.Lng,
+		}
+	}
+	type nameKey struct{ lang, value string }
+	seen := make(map[nameKey]bool, len(u.Names))
+	for _, n := range u.Names {
+		key := nameKey{lang: n.Lang, value: n.Value}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		airport.Names = append(airport.Names, airports.LocalizedName{
+			Lang:  n.Lang,
+			Value: n.Value,
+			Type:  n.Type,
+		})
 	}
+	return airport
 }
 
 // UpsertAirportResponse represents a response to an upsert airport request.
@@ -55,13 +106,37 @@ func (he handlerError) Error() string {
 	return he.msg
 }
 
-// handlers struct holds a database connection.
+// Limits bound the airports upsert requests may carry. Zero values mean
+// unlimited.
+type Limits struct {
+	// MaxAirportBytes is the maximum size of each airport in the JSON
+	// array, so that a single one cannot take up an arbitrary amount of
+	// memory while being decoded.
+	MaxAirportBytes int64
+	// MaxAirports is the maximum number of airports per request.
+	MaxAirports int
+	// Strict rejects airports with fields other than the known ones,
+	// which are ignored otherwise.
+	Strict bool
+}
+
+// handlers struct holds the airport store, the autocomplete index and the
+// limits of upsert requests.
 type handlers struct {
-	db *sql.DB
+	store  airports.AirportStore
+	index  *autocomplete.Index
+	limits Limits
 }
 
-// maxBufferedReaderSize is the maximum size of the buffered reader.
-const maxBufferedReaderSize = 32 * 1024
+const (
+	// tracerName is the name of the tracer the handlers start spans from.
+	tracerName = "github.com/tiagomelo/go-airports-service/handlers/v1/airports"
+	// maxBufferedReaderSize is the maximum size of the buffered reader.
+	maxBufferedReaderSize = 32 * 1024
+	// upsertBatchSize is the number of airports the streaming endpoint
+	// upserts, or previews on dry runs, at once.
+	upsertBatchSize = 500
+)
 
 // For ease of unit testing.
 var (
@@ -69,35 +144,78 @@ var (
 	newHttpResponseController = func(rw http.ResponseWriter) responseController {
 		return http.NewResponseController(rw)
 	}
-	// upsertAirport is a function that upserts an airport in the database.
-	upsertAirport = airports.Upsert
 )
 
-// NewHandlers initializes a new instance of handlers with a database connection.
-func NewHandlers(db *sql.DB) *handlers {
+// NewHandlers initializes a new instance of handlers with an airport store,
+// the autocomplete index to keep up to date on upserts and deletes, and
+// the limits of upsert requests.
+func NewHandlers(store airports.AirportStore, index *autocomplete.Index, limits Limits) *handlers {
 	return &handlers{
-		db: db,
+		store:  store,
+		index:  index,
+		limits: limits,
 	}
 }
 
-// HandleUpsert handles the upsert of airports in a streaming fashion.
+// HandleUpsert handles the upsert of airports in a streaming fashion. With
+// dry_run=true, airports are decoded and validated all the same, but
+// nothing is upserted: the response tells how they would change the
+// stored ones instead.
 func (h *handlers) HandleUpsert(w http.ResponseWriter, r *http.Request) {
+	dryRun, err := parseDryRun(r)
+	if err != nil {
+		web.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var (
+		upsert  func(context.Context, []*airports.Airport) *handlerError
+		preview *dryRunPreview
+		stats   *ingestStats
+	)
+	if dryRun {
+		preview = newDryRunPreview(h.store)
+		upsert = preview.add
+	} else {
+		stats = &ingestStats{last: time.Now()}
+		upsert = stats.count(h.upsertBatch)
+		defer func() {
+			metrics.ObserveUpsertRequest(metrics.EndpointStreaming, stats.upserted, stats.failed)
+		}()
+	}
+	admit := func() *handlerError { return nil }
+	if quota := ratelimit.FromContext(r.Context()); quota != nil && !dryRun {
+		admit = admitAirports(w, quota)
+	}
 	ctr := newHttpResponseController(w)
 	bufReader := bufio.NewReaderSize(r.Body, maxBufferedReaderSize)
-	dec := json.NewDecoder(bufReader)
+	elements := &elementReader{r: bufReader, limit: -1}
+	dec := json.NewDecoder(elements)
+	if h.limits.Strict {
+		dec.DisallowUnknownFields()
+	}
 	// check for opening '['.
 	if err := h.readExpectedToken(dec, json.Delim('[')); err != nil {
-		web.RespondWithError(w, http.StatusBadRequest, "invalid JSON: expected '[' at start")
+		herr := bodyError(err, "invalid JSON: expected '[' at start")
+		web.RespondWithError(w, herr.code, herr.Error())
 		return
 	}
 	// process each airport in the JSON object.
-	if herr := h.processAirports(r.Context(), dec); herr != nil {
+	if herr := h.processAirports(r.Context(), dec, elements, upsert, admit, !dryRun); herr != nil {
+		if stats != nil && herr.code == http.StatusBadRequest {
+			// the airport that could not be decoded or validated.
+			stats.failed++
+		}
 		web.RespondWithError(w, herr.code, herr.Error())
 		return
 	}
 	// check for closing ']'.
 	if err := h.readExpectedToken(dec, json.Delim(']')); err != nil {
-		web.RespondWithError(w, http.StatusBadRequest, "invalid JSON: expected ']' at end")
+		herr := bodyError(err, "invalid JSON: expected ']' at end")
+		web.RespondWithError(w, herr.code, herr.Error())
+		return
+	}
+	if dryRun {
+		web.Respond(w, http.StatusOK, preview.response())
 		return
 	}
 	// flush response and finalize.
@@ -120,27 +238,266 @@ func (h *handlers) readExpectedToken(dec *json.Decoder, expected json.Delim) err
 	return nil
 }
 
-// processAirport handles processing of a single airport entry.
-func (h *handlers) processAirport(ctx context.Context, dec *json.Decoder) *handlerError {
+// decodeAirport decodes and validates a single airport entry within a
+// span, reading no more than the maximum size of an airport through
+// elements, the reader dec reads from.
+func (h *handlers) decodeAirport(ctx context.Context, dec *json.Decoder, elements *elementReader) (*airports.Airport, *handlerError) {
+	_, span := otel.Tracer(tracerName).Start(ctx, "decode airport")
+	defer span.End()
 	var req UpsertAirportRequest
-	if err := dec.Decode(&req); err != nil {
-		return &handlerError{http.StatusBadRequest, "invalid JSON airport structure"}
+	if err := h.decodeElement(dec, elements, &req); err != nil {
+		if bodyEnded(dec, elements, err) {
+			return nil, errBodyEnded
+		}
+		herr := h.decodeError(err, "invalid JSON airport structure")
+		span.SetStatus(codes.Error, herr.Error())
+		return nil, herr
 	}
+	span.SetAttributes(attribute.String("airport.iata_code", req.IataCode))
 	if err := validate.Check(req); err != nil {
-		return &handlerError{http.StatusBadRequest, err.Error()}
+		span.SetStatus(codes.Error, err.Error())
+		return nil, &handlerError{http.StatusBadRequest, err.Error()}
 	}
-	if err := upsertAirport(ctx, h.db, req.ToAirport()); err != nil {
-		return &handlerError{http.StatusInternalServerError, fmt.Sprintf("%s: %v", "error upserting airport", err)}
-	}
-	return nil
+	return req.ToAirport(), nil
 }
 
-// processAirports processes all airports in the JSON array.
-func (h *handlers) processAirports(ctx context.Context, dec *json.Decoder) *handlerError {
-	for dec.More() {
-		if herr := h.processAirport(ctx, dec); herr != nil {
+// processAirports processes all airports in the JSON array, passing them
+// to upsert in batches of upsertBatchSize, each one within a span, once
+// admit lets them in. With partial set, airports decoded before an invalid
+// or unadmitted entry, or before the maximum number of airports is
+// exceeded, are still upserted.
+func (h *handlers) processAirports(ctx context.Context, dec *json.Decoder, elements *elementReader, upsert func(context.Context, []*airports.Airport) *handlerError, admit func() *handlerError, partial bool) *handlerError {
+	upsert = traceBatches(upsert)
+	batch := make([]*airports.Airport, 0, upsertBatchSize)
+	for decoded := 0; dec.More(); decoded++ {
+		var (
+			airport *airports.Airport
+			herr    *handlerError
+		)
+		if h.limits.MaxAirports > 0 && decoded == h.limits.MaxAirports {
+			herr = tooManyAirports(h.limits.MaxAirports)
+		} else {
+			airport, herr = h.decodeAirport(ctx, dec, elements)
+			if herr == errBodyEnded {
+				// missing the closing ']', which is reported as such.
+				break
+			}
+		}
+		if herr == nil {
+			herr = admit()
+		}
+		if herr != nil {
+			if !partial {
+				return herr
+			}
+			if err := upsert(ctx, batch); err != nil {
+				return err
+			}
 			return herr
 		}
+		batch = append(batch, airport)
+		if len(batch) == upsertBatchSize {
+			if herr := upsert(ctx, batch); herr != nil {
+				return herr
+			}
+			batch = batch[:0]
+		}
+	}
+	return upsert(ctx, batch)
+}
+
+// decodeElement decodes the next element of the JSON array into v, failing
+// with errElementTooLarge when it is larger than the maximum size of an
+// airport.
+func (h *handlers) decodeElement(dec *json.Decoder, elements *elementReader, v any) error {
+	maxBytes := h.limits.MaxAirportBytes
+	if maxBytes <= 0 {
+		return dec.Decode(v)
+	}
+	start := dec.InputOffset()
+	// the decoder cannot read past the end of the largest element allowed,
+	// but may have buffered that far already.
+	elements.limit = start + maxBytes
+	defer func() {
+		elements.limit = -1
+	}()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if dec.InputOffset()-start > maxBytes {
+		return errElementTooLarge
 	}
 	return nil
 }
+
+// decodeError returns the error to answer with for an airport that could
+// not be decoded: 413 Request Entity Too Large when it, or the body, is
+// too large, and 400 Bad Request with the given message otherwise, along
+// with the unknown field, if that is why.
+func (h *handlers) decodeError(err error, msg string) *handlerError {
+	if errors.Is(err, errElementTooLarge) {
+		return &handlerError{http.StatusRequestEntityTooLarge, fmt.Sprintf("airport too large: at most %d bytes", h.limits.MaxAirportBytes)}
+	}
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		return &handlerError{http.StatusBadRequest, fmt.Sprintf("%s: unknown field %s", msg, field)}
+	}
+	return bodyError(err, msg)
+}
+
+// bodyError returns the error to answer with for a request body that
+// could not be read or decoded: 413 Request Entity Too Large when it is
+// larger than the route allows, 408 Request Timeout when it arrives too
+// slowly, and 400 Bad Request with the given message otherwise.
+func bodyError(err error, msg string) *handlerError {
+	var (
+		maxBytesErr *http.MaxBytesError
+		slowErr     *middleware.SlowBodyError
+	)
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return &handlerError{http.StatusRequestEntityTooLarge, fmt.Sprintf("request body too large: at most %d bytes", maxBytesErr.Limit)}
+	case errors.As(err, &slowErr):
+		return &handlerError{http.StatusRequestTimeout, slowErr.Error()}
+	}
+	return &handlerError{http.StatusBadRequest, msg}
+}
+
+// tooManyAirports returns the error to answer with for a request carrying
+// more than the maximum number of airports.
+func tooManyAirports(maxAirports int) *handlerError {
+	return &handlerError{http.StatusRequestEntityTooLarge, fmt.Sprintf("too many airports: at most %d per request", maxAirports)}
+}
+
+// errBodyEnded is returned by decodeAirport when the body ends where the
+// next airport should start.
+var errBodyEnded = &handlerError{http.StatusBadRequest, "unexpected end of JSON input"}
+
+// bodyEnded reports whether err, the error decoding the next element of
+// the JSON array, is due to the body, read through elements, ending before
+// that element started.
+// Depending on the encoding/json implementation, dec.More may report true
+// when the body ends before the closing ']'.
+func bodyEnded(dec *json.Decoder, elements *elementReader, err error) bool {
+	var syntaxErr *json.SyntaxError
+	if !elements.eof || (!errors.As(err, &syntaxErr) && !errors.Is(err, io.ErrUnexpectedEOF)) {
+		return false
+	}
+	rest, _ := io.ReadAll(dec.Buffered())
+	return len(bytes.TrimSpace(rest)) == 0
+}
+
+// errElementTooLarge is returned when an element of the JSON array is
+// larger than the maximum size of an airport.
+var errElementTooLarge = errors.New("element too large")
+
+// elementReader is an io.Reader that fails with errElementTooLarge once
+// limit bytes were read through it, so that the decoder reading from it
+// cannot buffer an arbitrarily large element. The limit is the offset in
+// the stream, not a number of bytes left, and is unlimited when negative.
+type elementReader struct {
+	r     io.Reader
+	read  int64
+	limit int64
+	// eof is set once the underlying reader is exhausted.
+	eof bool
+}
+
+// Read reads up to the limit.
+func (e *elementReader) Read(p []byte) (int, error) {
+	if e.limit >= 0 {
+		if e.read >= e.limit {
+			return 0, errElementTooLarge
+		}
+		if left := e.limit - e.read; int64(len(p)) > left {
+			p = p[:left]
+		}
+	}
+	n, err := e.r.Read(p)
+	e.read += int64(n)
+	if err == io.EOF {
+		e.eof = true
+	}
+	return n, err
+}
+
+// admitAirports returns a function that takes an airport from the client's
+// ingest quota, answering with 429 Too Many Requests once it is used up,
+// along with the headers telling how long to wait.
+func admitAirports(w http.ResponseWriter, quota *ratelimit.Quota) func() *handlerError {
+	return func() *handlerError {
+		return takeQuota(w, quota, 1)
+	}
+}
+
+// takeQuota takes n airports from the client's ingest quota, answering
+// with 429 Too Many Requests when it does not have that many left, along
+// with the headers telling how long to wait.
+func takeQuota(w http.ResponseWriter, quota *ratelimit.Quota, n int) *handlerError {
+	res := quota.TakeN(n)
+	if res.Allowed {
+		return nil
+	}
+	res.SetHeaders(w.Header())
+	return &handlerError{http.StatusTooManyRequests, fmt.Sprintf("ingest quota of %d airports per minute exceeded", res.Limit)}
+}
+
+// upsertBatch upserts the given airports at once, in bulk, adding them to
+// the autocomplete index, and returns how many were upserted: either all
+// of them or, when the batch is rolled back, none.
+func (h *handlers) upsertBatch(ctx context.Context, batch []*airports.Airport) (int, *handlerError) {
+	if len(batch) == 0 {
+		return 0, nil
+	}
+	if err := h.store.UpsertBatch(ctx, batch); err != nil {
+		return 0, &handlerError{http.StatusInternalServerError, fmt.Sprintf("%s: %v", "error upserting airport", err)}
+	}
+	for _, airport := range batch {
+		h.index.Add(*airport)
+	}
+	return len(batch), nil
+}
+
+// traceBatches wraps an upsert function so that each non-empty batch is
+// upserted within a span, which the store's spans are children of.
+func traceBatches(upsert func(context.Context, []*airports.Airport) *handlerError) func(context.Context, []*airports.Airport) *handlerError {
+	return func(ctx context.Context, batch []*airports.Airport) *handlerError {
+		if len(batch) == 0 {
+			return upsert(ctx, batch)
+		}
+		ctx, span := otel.Tracer(tracerName).Start(ctx, "upsert batch",
+			trace.WithAttributes(attribute.Int("airports.batch.size", len(batch))),
+		)
+		defer span.End()
+		herr := upsert(ctx, batch)
+		if herr != nil {
+			span.SetStatus(codes.Error, herr.Error())
+		}
+		return herr
+	}
+}
+
+// ingestStats counts the airports a streaming upsert request upserted and
+// failed to upsert, recording the throughput of each batch along the way.
+type ingestStats struct {
+	upserted int
+	failed   int
+	last     time.Time
+}
+
+// count wraps an upsert function, returning how many airports it upserted,
+// so that the airports it upserts, or fails to, are counted.
+func (s *ingestStats) count(upsert func(context.Context, []*airports.Airport) (int, *handlerError)) func(context.Context, []*airports.Airport) *handlerError {
+	return func(ctx context.Context, batch []*airports.Airport) *handlerError {
+		upserted, herr := upsert(ctx, batch)
+		if upserted > 0 {
+			now := time.Now()
+			metrics.ObserveIngestBatch(upserted, now.Sub(s.last))
+			s.last = now
+			s.upserted += upserted
+		}
+		if herr != nil {
+			s.failed += len(batch) - upserted
+		}
+		return herr
+	}
+}
@@ -7,14 +7,26 @@ package airports
 import (
 	"bytes"
 	"context"
-	"database/sql"
 	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/stretchr/testify/require"
+	"github.com/tiagomelo/go-airports-service/autocomplete"
 	"github.com/tiagomelo/go-airports-service/db/airports"
+	"github.com/tiagomelo/go-airports-service/middleware"
+	"github.com/tiagomelo/go-airports-service/ratelimit"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func TestHandleUpsert(t *testing.T) {
@@ -22,7 +34,7 @@ func TestHandleUpsert(t *testing.T) {
 		name               string
 		input              string
 		mockClosure        func(rc *mockResponseController)
-		mockUpsertAirport  func(ctx context.Context, db *sql.DB, airport *airports.Airport) error
+		mockUpsertBatch    func(ctx context.Context, batch []*airports.Airport) error
 		expectedOutput     string
 		expectedStatusCode int
 	}{
@@ -35,7 +47,7 @@ func TestHandleUpsert(t *testing.T) {
 				"iata_code": "CGH"
 			}]`,
 			mockClosure:        func(rc *mockResponseController) {},
-			mockUpsertAirport:  func(ctx context.Context, db *sql.DB, airport *airports.Airport) error { return nil },
+			mockUpsertBatch:    func(ctx context.Context, batch []*airports.Airport) error { return nil },
 			expectedOutput:     `{"message":"airports upserted"}`,
 			expectedStatusCode: http.StatusOK,
 		},
@@ -62,6 +74,112 @@ func TestHandleUpsert(t *testing.T) {
 			expectedOutput:     `{"error":"[{\"field\":\"iata_code\",\"error\":\"iata_code is a required field\"}]"}`,
 			expectedStatusCode: http.StatusBadRequest,
 		},
+		{
+			name: "invalid coordinates",
+			input: `[{
+				"name": "Aeroporto de Congonhas",
+				"city": "São Paulo",
+				"country": "Brasil",
+				"iata_code": "CGH",
+				"geoloc": {"lat": -123.62, "lng": -46.65}
+			}]`,
+			mockClosure:        func(rc *mockResponseController) {},
+			expectedOutput:     `{"error":"[{\"field\":\"lat\",\"error\":\"lat must contain valid latitude coordinates\"}]"}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "lowercase iata code",
+			input: `[{
+				"name": "Aeroporto de Congonhas",
+				"city": "São Paulo",
+				"country": "Brasil",
+				"iata_code": "cgh"
+			}]`,
+			mockClosure: func(rc *mockResponseController) {},
+			mockUpsertBatch: func(ctx context.Context, batch []*airports.Airport) error {
+				if len(batch) != 1 || batch[0].IataCode != "CGH" {
+					return errors.New("unexpected batch")
+				}
+				return nil
+			},
+			expectedOutput:     `{"message":"airports upserted"}`,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: "iata code too long",
+			input: `[{
+				"name": "Aeroporto de Congonhas",
+				"city": "São Paulo",
+				"country": "Brasil",
+				"iata_code": "CGHX"
+			}]`,
+			mockClosure:        func(rc *mockResponseController) {},
+			expectedOutput:     `{"error":"[{\"field\":\"iata_code\",\"error\":\"iata_code must be 3 characters in length\"}]"}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "iata code not alphabetic",
+			input: `[{
+				"name": "Aeroporto de Congonhas",
+				"city": "São Paulo",
+				"country": "Brasil",
+				"iata_code": "C9H"
+			}]`,
+			mockClosure:        func(rc *mockResponseController) {},
+			expectedOutput:     `{"error":"[{\"field\":\"iata_code\",\"error\":\"iata_code can only contain alphabetic characters\"}]"}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "repeated localized names",
+			input: `[{
+				"name": "Charles De Gaulle",
+				"city": "Paris",
+				"country": "France",
+				"iata_code": "CDG",
+				"names": [
+					{"lang": "fr", "value": "Roissy", "type": "alias"},
+					{"lang": "fr", "value": "Aéroport Paris-Charles-de-Gaulle"},
+					{"lang": "fr", "value": "Roissy"},
+					{"lang": "en", "value": "Roissy", "type": "alias"}
+				]
+			}]`,
+			mockClosure: func(rc *mockResponseController) {},
+			mockUpsertBatch: func(ctx context.Context, batch []*airports.Airport) error {
+				expectedNames := []airports.LocalizedName{
+					{Lang: "fr", Value: "Roissy", Type: "alias"},
+					{Lang: "fr", Value: "Aéroport Paris-Charles-de-Gaulle"},
+					{Lang: "en", Value: "Roissy", Type: "alias"},
+				}
+				if len(batch) != 1 || !reflect.DeepEqual(expectedNames, batch[0].Names) {
+					return errors.New("unexpected batch")
+				}
+				return nil
+			},
+			expectedOutput:     `{"message":"airports upserted"}`,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: "airports before an invalid one are upserted",
+			input: `[{
+				"name": "Aeroporto de Congonhas",
+				"city": "São Paulo",
+				"country": "Brasil",
+				"iata_code": "CGH"
+			}, {
+				"name": "Aeroporto de Guarulhos",
+				"city": "São Paulo",
+				"country": "Brasil"
+			}]`,
+			mockClosure: func(rc *mockResponseController) {},
+			mockUpsertBatch: func(ctx context.Context, batch []*airports.Airport) error {
+				if len(batch) != 1 || batch[0].IataCode != "CGH" {
+					return errors.New("unexpected batch")
+				}
+				return nil
+			},
+			expectedOutput:     `{"error":"[{\"field\":\"iata_code\",\"error\":\"iata_code is a required field\"}]"}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
 		{
 			name:               "invalid json structure",
 			input:              `["name": "Aeroporto de Congonhas"]`,
@@ -78,7 +196,7 @@ func TestHandleUpsert(t *testing.T) {
 				"iata_code": "CGH"
 			}]`,
 			mockClosure: func(rc *mockResponseController) {},
-			mockUpsertAirport: func(ctx context.Context, db *sql.DB, airport *airports.Airport) error {
+			mockUpsertBatch: func(ctx context.Context, batch []*airports.Airport) error {
 				return errors.New("database error")
 			},
 			expectedOutput:     `{"error":"error upserting airport: database error"}`,
@@ -93,10 +211,37 @@ func TestHandleUpsert(t *testing.T) {
 				"iata_code": "CGH"
 			}`,
 			mockClosure:        func(rc *mockResponseController) {},
-			mockUpsertAirport:  func(ctx context.Context, db *sql.DB, airport *airports.Airport) error { return nil },
+			mockUpsertBatch:    func(ctx context.Context, batch []*airports.Airport) error { return nil },
+			expectedOutput:     `{"error":"invalid JSON: expected ']' at end"}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "missing closing ] after whitespace",
+			input: `[{
+				"name": "Aeroporto de Congonhas",
+				"city": "São Paulo",
+				"country": "Brasil",
+				"iata_code": "CGH"
+			}
+			`,
+			mockClosure:        func(rc *mockResponseController) {},
+			mockUpsertBatch:    func(ctx context.Context, batch []*airports.Airport) error { return nil },
 			expectedOutput:     `{"error":"invalid JSON: expected ']' at end"}`,
 			expectedStatusCode: http.StatusBadRequest,
 		},
+		{
+			name: "missing airport after comma",
+			input: `[{
+				"name": "Aeroporto de Congonhas",
+				"city": "São Paulo",
+				"country": "Brasil",
+				"iata_code": "CGH"
+			},`,
+			mockClosure:        func(rc *mockResponseController) {},
+			mockUpsertBatch:    func(ctx context.Context, batch []*airports.Airport) error { return nil },
+			expectedOutput:     `{"error":"invalid JSON airport structure"}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
 		{
 			name: "flush error",
 			input: `[{
@@ -108,7 +253,7 @@ func TestHandleUpsert(t *testing.T) {
 			mockClosure: func(rc *mockResponseController) {
 				rc.FlushErr = errors.New("flush error")
 			},
-			mockUpsertAirport:  func(ctx context.Context, db *sql.DB, airport *airports.Airport) error { return nil },
+			mockUpsertBatch:    func(ctx context.Context, batch []*airports.Airport) error { return nil },
 			expectedOutput:     `{"error":"flush error"}`,
 			expectedStatusCode: http.StatusInternalServerError,
 		},
@@ -117,7 +262,6 @@ func TestHandleUpsert(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			rc := new(mockResponseController)
 			tc.mockClosure(rc)
-			upsertAirport = tc.mockUpsertAirport
 			newHttpResponseController = func(_ http.ResponseWriter) responseController {
 				return rc
 			}
@@ -126,7 +270,7 @@ func TestHandleUpsert(t *testing.T) {
 			req.Header.Set("Content-Type", "application/json")
 
 			rr := httptest.NewRecorder()
-			h := NewHandlers(nil)
+			h := NewHandlers(&mockStore{upsertBatch: tc.mockUpsertBatch}, autocomplete.NewIndex(), Limits{})
 			handler := http.HandlerFunc(h.HandleUpsert)
 			handler.ServeHTTP(rr, req)
 
@@ -136,6 +280,307 @@ func TestHandleUpsert(t *testing.T) {
 	}
 }
 
+func TestHandleUpsertTracing(t *testing.T) {
+	testCases := []struct {
+		name            string
+		input           string
+		mockUpsertBatch func(ctx context.Context, batch []*airports.Airport) error
+		expectedSpans   []string
+		expectedErrors  []string
+	}{
+		{
+			name:            "happy path",
+			input:           `[{"name": "Congonhas", "city": "São Paulo", "country": "Brasil", "iata_code": "CGH"}, {"name": "Guarulhos", "city": "São Paulo", "country": "Brasil", "iata_code": "GRU"}]`,
+			mockUpsertBatch: func(ctx context.Context, batch []*airports.Airport) error { return nil },
+			expectedSpans:   []string{"decode airport", "decode airport", "upsert batch"},
+		},
+		{
+			name:            "invalid airport",
+			input:           `[{"name": "Congonhas", "city": "São Paulo", "country": "Brasil", "iata_code": "CGH"}, {"name": "Guarulhos", "city": "São Paulo", "iata_code": "GRU"}]`,
+			mockUpsertBatch: func(ctx context.Context, batch []*airports.Airport) error { return nil },
+			expectedSpans:   []string{"decode airport", "decode airport", "upsert batch"},
+			expectedErrors:  []string{"decode airport"},
+		},
+		{
+			name:            "upsert error",
+			input:           `[{"name": "Congonhas", "city": "São Paulo", "country": "Brasil", "iata_code": "CGH"}]`,
+			mockUpsertBatch: func(ctx context.Context, batch []*airports.Airport) error { return errors.New("upsert error") },
+			expectedSpans:   []string{"decode airport", "upsert batch"},
+			expectedErrors:  []string{"upsert batch"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sr := tracetest.NewSpanRecorder()
+			originalProvider := otel.GetTracerProvider()
+			defer otel.SetTracerProvider(originalProvider)
+			provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+			otel.SetTracerProvider(provider)
+			newHttpResponseController = func(_ http.ResponseWriter) responseController {
+				return new(mockResponseController)
+			}
+
+			var storeSpans []trace.SpanContext
+			h := NewHandlers(&mockStore{upsertBatch: func(ctx context.Context, batch []*airports.Airport) error {
+				storeSpans = append(storeSpans, trace.SpanContextFromContext(ctx))
+				return tc.mockUpsertBatch(ctx, batch)
+			}}, autocomplete.NewIndex(), Limits{})
+			ctx, parent := provider.Tracer("test").Start(context.TODO(), "POST /api/v1/airports")
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/api/v1/airports", bytes.NewBufferString(tc.input))
+			require.NoError(t, err)
+			h.HandleUpsert(httptest.NewRecorder(), req)
+			parent.End()
+
+			spans := sr.Ended()
+			require.Len(t, spans, len(tc.expectedSpans)+1)
+			var names, failed []string
+			for _, span := range spans[:len(spans)-1] {
+				require.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID())
+				names = append(names, span.Name())
+				if span.Status().Code == codes.Error {
+					failed = append(failed, span.Name())
+				}
+				if span.Name() == "upsert batch" {
+					require.Equal(t, span.SpanContext(), storeSpans[0])
+					storeSpans = storeSpans[1:]
+				}
+			}
+			require.Equal(t, tc.expectedSpans, names)
+			require.Equal(t, tc.expectedErrors, failed)
+			require.Contains(t, spans[0].Attributes(), attribute.String("airport.iata_code", "CGH"))
+		})
+	}
+}
+
+func TestHandleUpsertIngestQuota(t *testing.T) {
+	const input = `[
+		{"name": "Congonhas", "city": "São Paulo", "country": "Brasil", "iata_code": "CGH"},
+		{"name": "Guarulhos", "city": "São Paulo", "country": "Brasil", "iata_code": "GRU"},
+		{"name": "Viracopos", "city": "Campinas", "country": "Brasil", "iata_code": "VCP"}
+	]`
+	testCases := []struct {
+		name               string
+		nonStreaming       bool
+		quota              int
+		dryRun             bool
+		expectedUpserted   []string
+		expectedOutput     string
+		expectedStatusCode int
+		expectedHeaders    map[string]string
+	}{
+		{
+			name:               "within quota",
+			quota:              3,
+			expectedUpserted:   []string{"CGH", "GRU", "VCP"},
+			expectedOutput:     `{"message":"airports upserted"}`,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "quota exceeded",
+			quota:              2,
+			expectedUpserted:   []string{"CGH", "GRU"},
+			expectedOutput:     `{"error":"ingest quota of 2 airports per minute exceeded"}`,
+			expectedStatusCode: http.StatusTooManyRequests,
+			expectedHeaders: map[string]string{
+				"RateLimit-Limit":     "2",
+				"RateLimit-Remaining": "0",
+				"Retry-After":         "30",
+			},
+		},
+		{
+			name:               "dry run not counted",
+			quota:              1,
+			dryRun:             true,
+			expectedOutput:     `{"added":[],"changed":[],"unchanged":3}`,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "within quota, non-streaming",
+			nonStreaming:       true,
+			quota:              3,
+			expectedUpserted:   []string{"CGH", "GRU", "VCP"},
+			expectedOutput:     `{"message":"airports upserted"}`,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "quota exceeded, non-streaming",
+			nonStreaming:       true,
+			quota:              2,
+			expectedUpserted:   []string{"CGH", "GRU"},
+			expectedOutput:     `{"error":"ingest quota of 2 airports per minute exceeded"}`,
+			expectedStatusCode: http.StatusTooManyRequests,
+			expectedHeaders: map[string]string{
+				"RateLimit-Limit":     "2",
+				"RateLimit-Remaining": "0",
+				"Retry-After":         "30",
+			},
+		},
+		{
+			name:               "dry run not counted, non-streaming",
+			nonStreaming:       true,
+			quota:              1,
+			dryRun:             true,
+			expectedOutput:     `{"added":[],"changed":[],"unchanged":3}`,
+			expectedStatusCode: http.StatusOK,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			newHttpResponseController = func(_ http.ResponseWriter) responseController {
+				return new(mockResponseController)
+			}
+			var upserted []string
+			h := NewHandlers(&mockStore{
+				upsertBatch: func(ctx context.Context, batch []*airports.Airport) error {
+					for _, airport := range batch {
+						upserted = append(upserted, airport.IataCode)
+					}
+					return nil
+				},
+				previewUpsert: func(ctx context.Context, batch []*airports.Airport) (airports.Diff, error) {
+					return airports.Diff{Unchanged: len(batch)}, nil
+				},
+			}, autocomplete.NewIndex(), Limits{})
+			target := "/api/v1/airports"
+			handler := h.HandleUpsert
+			if tc.nonStreaming {
+				target = "/api/v1/nonstreaming/airports"
+				handler = h.HandleNonStreamingUpsert
+			}
+			if tc.dryRun {
+				target += "?dry_run=true"
+			}
+			quota := ratelimit.NewQuota(ratelimit.NewLimiter(float64(tc.quota)/60, tc.quota), "api-key:0123456789abcdef")
+			req := httptest.NewRequest(http.MethodPost, target, bytes.NewBufferString(input))
+			req = req.WithContext(ratelimit.NewContext(req.Context(), quota))
+			rr := httptest.NewRecorder()
+			handler(rr, req)
+
+			require.Equal(t, tc.expectedStatusCode, rr.Code)
+			require.JSONEq(t, tc.expectedOutput, rr.Body.String())
+			require.Equal(t, tc.expectedUpserted, upserted)
+			for k, v := range tc.expectedHeaders {
+				require.Equal(t, v, rr.Header().Get(k), k)
+			}
+		})
+	}
+}
+
+func TestHandleUpsertLimits(t *testing.T) {
+	const (
+		congonhas = `{"name": "Congonhas", "city": "São Paulo", "country": "Brasil", "iata_code": "CGH"}`
+		guarulhos = `{"name": "Guarulhos", "city": "São Paulo", "country": "Brasil", "iata_code": "GRU"}`
+	)
+	huge := `{"name": "` + strings.Repeat("a", 2*maxBufferedReaderSize) + `", "city": "São Paulo", "country": "Brasil", "iata_code": "GRU"}`
+	testCases := []struct {
+		name               string
+		limits             Limits
+		input              string
+		maxBodyBytes       int64
+		bodyErr            error
+		expectedUpserted   []string
+		expectedOutput     string
+		expectedStatusCode int
+	}{
+		{
+			name:               "within limits",
+			limits:             Limits{MaxAirportBytes: 128, MaxAirports: 2, Strict: true},
+			input:              "[" + congonhas + ",\n" + guarulhos + "]",
+			expectedUpserted:   []string{"CGH", "GRU"},
+			expectedOutput:     `{"message":"airports upserted"}`,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "unknown fields ignored",
+			input:              `[{"name": "Congonhas", "city": "São Paulo", "country": "Brasil", "iata_code": "CGH", "icao_code": "SBSP"}]`,
+			expectedUpserted:   []string{"CGH"},
+			expectedOutput:     `{"message":"airports upserted"}`,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "unknown fields rejected in strict mode",
+			limits:             Limits{Strict: true},
+			input:              "[" + congonhas + `, {"name": "Guarulhos", "city": "São Paulo", "country": "Brasil", "iata_code": "GRU", "icao_code": "SBGR"}]`,
+			expectedUpserted:   []string{"CGH"},
+			expectedOutput:     `{"error":"invalid JSON airport structure: unknown field \"icao_code\""}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "airport too large",
+			limits:             Limits{MaxAirportBytes: 128},
+			input:              "[" + congonhas + ", " + huge + "]",
+			expectedUpserted:   []string{"CGH"},
+			expectedOutput:     `{"error":"airport too large: at most 128 bytes"}`,
+			expectedStatusCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:               "buffered airport too large",
+			limits:             Limits{MaxAirportBytes: 80},
+			input:              "[" + congonhas + "]",
+			expectedOutput:     `{"error":"airport too large: at most 80 bytes"}`,
+			expectedStatusCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:               "too many airports",
+			limits:             Limits{MaxAirports: 1},
+			input:              "[" + congonhas + ", " + guarulhos + "]",
+			expectedUpserted:   []string{"CGH"},
+			expectedOutput:     `{"error":"too many airports: at most 1 per request"}`,
+			expectedStatusCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:               "body too large",
+			input:              "[" + congonhas + ", " + guarulhos + "]",
+			maxBodyBytes:       100,
+			expectedUpserted:   []string{"CGH"},
+			expectedOutput:     `{"error":"request body too large: at most 100 bytes"}`,
+			expectedStatusCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:               "body too large before the first airport",
+			input:              "[" + congonhas + "]",
+			maxBodyBytes:       1,
+			expectedOutput:     `{"error":"request body too large: at most 1 bytes"}`,
+			expectedStatusCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:               "body too slow",
+			input:              "[" + congonhas + ", ",
+			bodyErr:            &middleware.SlowBodyError{MinRate: 1024},
+			expectedUpserted:   []string{"CGH"},
+			expectedOutput:     `{"error":"request body too slow: less than 1024 bytes per second"}`,
+			expectedStatusCode: http.StatusRequestTimeout,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			newHttpResponseController = func(_ http.ResponseWriter) responseController {
+				return new(mockResponseController)
+			}
+			var upserted []string
+			h := NewHandlers(&mockStore{upsertBatch: func(ctx context.Context, batch []*airports.Airport) error {
+				for _, airport := range batch {
+					upserted = append(upserted, airport.IataCode)
+				}
+				return nil
+			}}, autocomplete.NewIndex(), tc.limits)
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/airports", strings.NewReader(tc.input))
+			if tc.maxBodyBytes > 0 {
+				req.Body = http.MaxBytesReader(rr, req.Body, tc.maxBodyBytes)
+			}
+			if tc.bodyErr != nil {
+				req.Body = io.NopCloser(io.MultiReader(req.Body, iotest.ErrReader(tc.bodyErr)))
+			}
+			h.HandleUpsert(rr, req)
+
+			require.Equal(t, tc.expectedStatusCode, rr.Code)
+			require.JSONEq(t, tc.expectedOutput, rr.Body.String())
+			require.Equal(t, tc.expectedUpserted, upserted)
+		})
+	}
+}
+
 type mockResponseController struct {
 	FlushErr error
 }
@@ -143,3 +588,55 @@ type mockResponseController struct {
 func (m *mockResponseController) Flush() error {
 	return m.FlushErr
 }
+
+type mockStore struct {
+	upsertBatch    func(ctx context.Context, batch []*airports.Airport) error
+	get            func(ctx context.Context, iataCode string) (*airports.Airport, error)
+	list           func(ctx context.Context, filter airports.ListFilter) ([]airports.Airport, error)
+	delete         func(ctx context.Context, iataCode string) error
+	search         func(ctx context.Context, query string, limit int) ([]airports.SearchResult, error)
+	findDuplicates func(ctx context.Context, radiusMeters float64, fn func(airports.Duplicate) error) error
+	export         func(ctx context.Context, fn func(airports.Airport) error) error
+	replaceAll     func(ctx context.Context, batch []*airports.Airport) error
+	previewUpsert  func(ctx context.Context, batch []*airports.Airport) (airports.Diff, error)
+}
+
+func (m *mockStore) Upsert(ctx context.Context, airport *airports.Airport) error {
+	return m.upsertBatch(ctx, []*airports.Airport{airport})
+}
+
+func (m *mockStore) UpsertBatch(ctx context.Context, batch []*airports.Airport) error {
+	return m.upsertBatch(ctx, batch)
+}
+
+func (m *mockStore) Get(ctx context.Context, iataCode string) (*airports.Airport, error) {
+	return m.get(ctx, iataCode)
+}
+
+func (m *mockStore) List(ctx context.Context, filter airports.ListFilter) ([]airports.Airport, error) {
+	return m.list(ctx, filter)
+}
+
+func (m *mockStore) Delete(ctx context.Context, iataCode string) error {
+	return m.delete(ctx, iataCode)
+}
+
+func (m *mockStore) Search(ctx context.Context, query string, limit int) ([]airports.SearchResult, error) {
+	return m.search(ctx, query, limit)
+}
+
+func (m *mockStore) FindDuplicates(ctx context.Context, radiusMeters float64, fn func(airports.Duplicate) error) error {
+	return m.findDuplicates(ctx, radiusMeters, fn)
+}
+
+func (m *mockStore) Export(ctx context.Context, fn func(airports.Airport) error) error {
+	return m.export(ctx, fn)
+}
+
+func (m *mockStore) ReplaceAll(ctx context.Context, batch []*airports.Airport) error {
+	return m.replaceAll(ctx, batch)
+}
+
+func (m *mockStore) PreviewUpsert(ctx context.Context, batch []*airports.Airport) (airports.Diff, error) {
+	return m.previewUpsert(ctx, batch)
+}
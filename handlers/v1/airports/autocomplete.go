@@ -0,0 +1,34 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package airports
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/tiagomelo/go-airports-service/web"
+)
+
+const (
+	// defaultAutocompleteLimit is the number of suggestions returned when no limit is given.
+	defaultAutocompleteLimit = 10
+	// maxAutocompleteLimit is the maximum number of suggestions that can be returned.
+	maxAutocompleteLimit = 50
+)
+
+// HandleAutocomplete handles typeahead lookups against the in-memory index.
+func (h *handlers) HandleAutocomplete(w http.ResponseWriter, r *http.Request) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		web.RespondWithError(w, http.StatusBadRequest, "query parameter 'q' is required")
+		return
+	}
+	limit, err := parseLimit(r, defaultAutocompleteLimit, maxAutocompleteLimit)
+	if err != nil {
+		web.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	web.Respond(w, http.StatusOK, h.index.Search(query, limit))
+}
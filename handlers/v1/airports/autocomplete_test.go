@@ -0,0 +1,91 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package airports
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tiagomelo/go-airports-service/autocomplete"
+	"github.com/tiagomelo/go-airports-service/db/airports"
+)
+
+func TestHandleAutocomplete(t *testing.T) {
+	testCases := []struct {
+		name               string
+		url                string
+		expectedOutput     string
+		expectedStatusCode int
+	}{
+		{
+			name:               "happy path",
+			url:                "/api/v1/airports/autocomplete?q=lhr",
+			expectedOutput:     `[{"name":"Heathrow","city":"London","country":"United Kingdom","iata_code":"LHR","score":1000}]`,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "limit",
+			url:                "/api/v1/airports/autocomplete?q=lon&limit=1",
+			expectedOutput:     `[{"name":"Gatwick","city":"London","country":"United Kingdom","iata_code":"LGW","score":200}]`,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "missing query",
+			url:                "/api/v1/airports/autocomplete",
+			expectedOutput:     `{"error":"query parameter 'q' is required"}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "invalid limit",
+			url:                "/api/v1/airports/autocomplete?q=lon&limit=abc",
+			expectedOutput:     `{"error":"query parameter 'limit' must be an integer between 1 and 50"}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+	index := autocomplete.NewIndex()
+	index.Load([]airports.Airport{
+		{Name: "Heathrow", City: "London", Country: "United Kingdom", IataCode: "LHR"},
+		{Name: "Gatwick", City: "London", Country: "United Kingdom", IataCode: "LGW"},
+	})
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, tc.url, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			h := NewHandlers(nil, index, Limits{})
+			handler := http.HandlerFunc(h.HandleAutocomplete)
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.expectedStatusCode, rr.Code)
+			require.JSONEq(t, tc.expectedOutput, rr.Body.String())
+		})
+	}
+}
+
+func TestUpsertUpdatesAutocompleteIndex(t *testing.T) {
+	store := &mockStore{
+		upsertBatch: func(ctx context.Context, batch []*airports.Airport) error {
+			return nil
+		},
+	}
+	index := autocomplete.NewIndex()
+	h := NewHandlers(store, index, Limits{})
+	input := `[{"name": "Aeroporto de Congonhas", "city": "São Paulo", "country": "Brasil", "iata_code": "CGH"}]`
+
+	req, err := http.NewRequest(http.MethodPost, "/api/v1/nonstreaming/airports", bytes.NewBufferString(input))
+	require.NoError(t, err)
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(h.HandleNonStreamingUpsert).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	suggestions := index.Search("congo", 10)
+	require.Len(t, suggestions, 1)
+	require.Equal(t, "CGH", suggestions[0].IataCode)
+}
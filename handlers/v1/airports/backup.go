@@ -0,0 +1,46 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package airports
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/tiagomelo/go-airports-service/db/airports"
+	"github.com/tiagomelo/go-airports-service/web"
+)
+
+// For ease of unit testing.
+var (
+	// now is a function that returns the current time.
+	now = time.Now
+)
+
+// HandleBackup handles online backups of the database. A consistent
+// snapshot is taken without stopping reads and writes, and then streamed
+// as a SQLite database file, which the restore command can swap in place
+// of the live one. Stores that cannot take backups respond with 501.
+func (h *handlers) HandleBackup(w http.ResponseWriter, r *http.Request) {
+	backuper, ok := h.store.(airports.Backuper)
+	if !ok {
+		web.RespondWithError(w, http.StatusNotImplemented, "backups are not supported by the configured store")
+		return
+	}
+	backup, size, err := backuper.Backup(r.Context())
+	if err != nil {
+		web.RespondWithError(w, http.StatusInternalServerError, "error backing up database: "+err.Error())
+		return
+	}
+	defer backup.Close()
+	filename := fmt.Sprintf("airports-%s.db", now().UTC().Format("20060102T150405Z"))
+	w.Header().Set("Content-Type", "application/vnd.sqlite3")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+	w.WriteHeader(http.StatusOK)
+	_, _ = io.Copy(w, backup)
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package airports
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tiagomelo/go-airports-service/autocomplete"
+	"github.com/tiagomelo/go-airports-service/db/airports"
+)
+
+type mockBackupStore struct {
+	mockStore
+	backup func(ctx context.Context) (io.ReadCloser, int64, error)
+}
+
+func (m *mockBackupStore) Backup(ctx context.Context) (io.ReadCloser, int64, error) {
+	return m.backup(ctx)
+}
+
+func TestHandleBackup(t *testing.T) {
+	testCases := []struct {
+		name               string
+		store              airports.AirportStore
+		expectedHeaders    map[string]string
+		expectedOutput     string
+		expectedStatusCode int
+	}{
+		{
+			name: "happy path",
+			store: &mockBackupStore{
+				backup: func(ctx context.Context) (io.ReadCloser, int64, error) {
+					return io.NopCloser(strings.NewReader("SQLite format 3")), 15, nil
+				},
+			},
+			expectedHeaders: map[string]string{
+				"Content-Type":        "application/vnd.sqlite3",
+				"Content-Disposition": `attachment; filename="airports-20250102T030405Z.db"`,
+				"Content-Length":      "15",
+			},
+			expectedOutput:     "SQLite format 3",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "store without backups",
+			store:              &mockStore{},
+			expectedOutput:     `{"error":"backups are not supported by the configured store"}`,
+			expectedStatusCode: http.StatusNotImplemented,
+		},
+		{
+			name: "backup error",
+			store: &mockBackupStore{
+				backup: func(ctx context.Context) (io.ReadCloser, int64, error) {
+					return nil, 0, errors.New("disk I/O error")
+				},
+			},
+			expectedOutput:     `{"error":"error backing up database: disk I/O error"}`,
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+	originalNow := now
+	defer func() {
+		now = originalNow
+	}()
+	now = func() time.Time {
+		return time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/api/v1/admin/backup", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			h := NewHandlers(tc.store, autocomplete.NewIndex(), Limits{})
+			handler := http.HandlerFunc(h.HandleBackup)
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.expectedStatusCode, rr.Code)
+			require.Equal(t, tc.expectedOutput, rr.Body.String())
+			for k, v := range tc.expectedHeaders {
+				require.Equal(t, v, rr.Header().Get(k))
+			}
+		})
+	}
+}
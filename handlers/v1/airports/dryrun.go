@@ -0,0 +1,142 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package airports
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/tiagomelo/go-airports-service/db/airports"
+)
+
+// DryRunResponse represents a response to an upsert airports request made
+// with dry_run=true: how the airports would change the stored ones, had
+// they been upserted.
+type DryRunResponse struct {
+	Added     []airports.Airport       `json:"added"`
+	Changed   []airports.AirportChange `json:"changed"`
+	Unchanged int                      `json:"unchanged"`
+}
+
+// parseDryRun parses the optional 'dry_run' query parameter.
+func parseDryRun(r *http.Request) (bool, error) {
+	raw := strings.TrimSpace(r.URL.Query().Get("dry_run"))
+	if raw == "" {
+		return false, nil
+	}
+	dryRun, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, errors.New("query parameter 'dry_run' must be a boolean")
+	}
+	return dryRun, nil
+}
+
+// previewUpsert returns how upserting the given airports would change the
+// stored ones, without upserting them, previewing them in batches of
+// upsertBatchSize.
+func (h *handlers) previewUpsert(ctx context.Context, batch []*airports.Airport) (*DryRunResponse, *handlerError) {
+	preview := newDryRunPreview(h.store)
+	for start := 0; start < len(batch); start += upsertBatchSize {
+		if herr := preview.add(ctx, batch[start:min(start+upsertBatchSize, len(batch))]); herr != nil {
+			return nil, herr
+		}
+	}
+	return preview.response(), nil
+}
+
+// Outcomes of previewing the upsert of an airport.
+const (
+	outcomeAdded = iota + 1
+	outcomeChanged
+	outcomeUnchanged
+)
+
+// dryRunPreview previews the upsert of airports one batch at a time, each
+// one within its own transaction, merging how they would change the stored
+// ones as it goes.
+type dryRunPreview struct {
+	store airports.AirportStore
+	resp  *DryRunResponse
+	// outcomes holds the outcome of each airport previewed so far, by IATA
+	// code, so that the ones repeated in a later batch are reported once.
+	outcomes map[string]int
+}
+
+// newDryRunPreview creates a preview of upserts into the given store.
+func newDryRunPreview(store airports.AirportStore) *dryRunPreview {
+	return &dryRunPreview{
+		store: store,
+		resp: &DryRunResponse{
+			Added:   []airports.Airport{},
+			Changed: []airports.AirportChange{},
+		},
+		outcomes: make(map[string]int),
+	}
+}
+
+// add previews the upsert of a batch of airports and merges the result
+// into the response. Since each batch is previewed on its own, an airport
+// repeated in a later batch is reported as that occurrence alone would
+// change the stored one.
+func (d *dryRunPreview) add(ctx context.Context, batch []*airports.Airport) *handlerError {
+	if len(batch) == 0 {
+		return nil
+	}
+	diff, err := d.store.PreviewUpsert(ctx, batch)
+	if err != nil {
+		return &handlerError{http.StatusInternalServerError, fmt.Sprintf("%s: %v", "error previewing upsert", err)}
+	}
+	outcomes := make(map[string]int, len(batch))
+	for _, a := range batch {
+		outcomes[a.IataCode] = outcomeUnchanged
+	}
+	for _, a := range diff.Added {
+		outcomes[a.IataCode] = outcomeAdded
+	}
+	for _, c := range diff.Changed {
+		outcomes[c.IataCode] = outcomeChanged
+	}
+	for iataCode, outcome := range outcomes {
+		d.forget(iataCode)
+		if outcome == outcomeUnchanged {
+			d.resp.Unchanged++
+		}
+		d.outcomes[iataCode] = outcome
+	}
+	d.resp.Added = append(d.resp.Added, diff.Added...)
+	d.resp.Changed = append(d.resp.Changed, diff.Changed...)
+	return nil
+}
+
+// forget removes the outcome of a previously previewed airport from the
+// response, if any.
+func (d *dryRunPreview) forget(iataCode string) {
+	switch d.outcomes[iataCode] {
+	case outcomeAdded:
+		d.resp.Added = slices.DeleteFunc(d.resp.Added, func(a airports.Airport) bool {
+			return a.IataCode == iataCode
+		})
+	case outcomeChanged:
+		d.resp.Changed = slices.DeleteFunc(d.resp.Changed, func(c airports.AirportChange) bool {
+			return c.IataCode == iataCode
+		})
+	case outcomeUnchanged:
+		d.resp.Unchanged--
+	}
+}
+
+// response returns how the airports previewed so far would change the
+// stored ones, each list ordered by IATA code.
+func (d *dryRunPreview) response() *DryRunResponse {
+	sort.Slice(d.resp.Added, func(i, j int) bool { return d.resp.Added[i].IataCode < d.resp.Added[j].IataCode })
+	sort.Slice(d.resp.Changed, func(i, j int) bool { return d.resp.Changed[i].IataCode < d.resp.Changed[j].IataCode })
+	return d.resp
+}
@@ -0,0 +1,259 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package airports
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tiagomelo/go-airports-service/autocomplete"
+	"github.com/tiagomelo/go-airports-service/db/airports"
+)
+
+func TestHandleUpsertDryRun(t *testing.T) {
+	const input = `[{
+		"name": "Aeroporto de Congonhas",
+		"city": "São Paulo",
+		"country": "Brasil",
+		"iata_code": "CGH"
+	}, {
+		"name": "Aeroporto de Guarulhos",
+		"city": "Guarulhos",
+		"country": "Brasil",
+		"iata_code": "GRU"
+	}]`
+	testCases := []struct {
+		name               string
+		path               string
+		input              string
+		mockPreviewUpsert  func(ctx context.Context, batch []*airports.Airport) (airports.Diff, error)
+		expectedOutput     string
+		expectedStatusCode int
+	}{
+		{
+			name:  "happy path",
+			path:  "/api/v1/airports?dry_run=true",
+			input: input,
+			mockPreviewUpsert: func(ctx context.Context, batch []*airports.Airport) (airports.Diff, error) {
+				if len(batch) != 2 || batch[0].IataCode != "CGH" || batch[1].IataCode != "GRU" {
+					return airports.Diff{}, errors.New("unexpected batch")
+				}
+				return airports.Diff{
+					Added: []airports.Airport{*batch[1]},
+					Changed: []airports.AirportChange{
+						{IataCode: "CGH", Fields: []airports.FieldChange{{Field: airports.FieldCity, Old: "Sao Paulo", New: "São Paulo"}}},
+					},
+				}, nil
+			},
+			expectedOutput:     `{"added":[{"name":"Aeroporto de Guarulhos","city":"Guarulhos","country":"Brasil","iata_code":"GRU"}],"changed":[{"iata_code":"CGH","fields":[{"field":"city","old":"Sao Paulo","new":"São Paulo"}]}],"unchanged":0}`,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:  "happy path, non-streaming",
+			path:  "/api/v1/nonstreaming/airports?dry_run=true",
+			input: input,
+			mockPreviewUpsert: func(ctx context.Context, batch []*airports.Airport) (airports.Diff, error) {
+				return airports.Diff{
+					Added:     []airports.Airport{},
+					Removed:   []airports.Airport{},
+					Changed:   []airports.AirportChange{},
+					Unchanged: len(batch),
+				}, nil
+			},
+			expectedOutput:     `{"added":[],"changed":[],"unchanged":2}`,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "invalid dry_run",
+			path:               "/api/v1/airports?dry_run=maybe",
+			input:              input,
+			expectedOutput:     `{"error":"query parameter 'dry_run' must be a boolean"}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "invalid dry_run, non-streaming",
+			path:               "/api/v1/nonstreaming/airports?dry_run=maybe",
+			input:              input,
+			expectedOutput:     `{"error":"query parameter 'dry_run' must be a boolean"}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "validation error",
+			path: "/api/v1/airports?dry_run=true",
+			input: `[{
+				"name": "Aeroporto de Congonhas",
+				"city": "São Paulo",
+				"country": "Brasil",
+				"iata_code": "CGH"
+			}, {
+				"name": "Aeroporto de Guarulhos",
+				"city": "Guarulhos",
+				"country": "Brasil"
+			}]`,
+			expectedOutput:     `{"error":"[{\"field\":\"iata_code\",\"error\":\"iata_code is a required field\"}]"}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:  "database error",
+			path:  "/api/v1/airports?dry_run=true",
+			input: input,
+			mockPreviewUpsert: func(ctx context.Context, batch []*airports.Airport) (airports.Diff, error) {
+				return airports.Diff{}, errors.New("upserting airport: constraint failed")
+			},
+			expectedOutput:     `{"error":"error previewing upsert: upserting airport: constraint failed"}`,
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name:  "database error, non-streaming",
+			path:  "/api/v1/nonstreaming/airports?dry_run=true",
+			input: input,
+			mockPreviewUpsert: func(ctx context.Context, batch []*airports.Airport) (airports.Diff, error) {
+				return airports.Diff{}, errors.New("upserting airport: constraint failed")
+			},
+			expectedOutput:     `{"error":"error previewing upsert: upserting airport: constraint failed"}`,
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			newHttpResponseController = func(_ http.ResponseWriter) responseController {
+				return new(mockResponseController)
+			}
+			req, err := http.NewRequest(http.MethodPost, tc.path, bytes.NewBuffer([]byte(tc.input)))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+
+			rr := httptest.NewRecorder()
+			index := autocomplete.NewIndex()
+			h := NewHandlers(&mockStore{
+				upsertBatch: func(ctx context.Context, batch []*airports.Airport) error {
+					return errors.New("airports must not be upserted on dry runs")
+				},
+				previewUpsert: tc.mockPreviewUpsert,
+			}, index, Limits{})
+			handler := http.HandlerFunc(h.HandleUpsert)
+			if req.URL.Path == "/api/v1/nonstreaming/airports" {
+				handler = h.HandleNonStreamingUpsert
+			}
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.expectedStatusCode, rr.Code)
+			require.JSONEq(t, tc.expectedOutput, rr.Body.String())
+			require.Zero(t, index.Len())
+		})
+	}
+}
+
+func TestHandleUpsertDryRunBatches(t *testing.T) {
+	// QAA is repeated in the second batch, along with ZZZ.
+	var input []UpsertAirportRequest
+	for i := 0; i < upsertBatchSize; i++ {
+		iataCode := string([]byte{'Q', byte('A' + i/26), byte('A' + i%26)})
+		input = append(input, UpsertAirportRequest{Name: "Airport " + iataCode, City: "City", Country: "Country", IataCode: iataCode})
+	}
+	input = append(input,
+		UpsertAirportRequest{Name: "Renamed QAA", City: "City", Country: "Country", IataCode: "QAA"},
+		UpsertAirportRequest{Name: "Airport ZZZ", City: "City", Country: "Country", IataCode: "ZZZ"},
+	)
+	body, err := json.Marshal(input)
+	require.NoError(t, err)
+	testCases := []struct {
+		name               string
+		path               string
+		limits             Limits
+		expectedBatchSizes []int
+		expectedAdded      int
+		expectedChanged    []string
+		expectedStatusCode int
+	}{
+		{
+			name:               "streaming",
+			path:               "/api/v1/airports?dry_run=true",
+			expectedBatchSizes: []int{upsertBatchSize, 2},
+			expectedAdded:      upsertBatchSize,
+			expectedChanged:    []string{"QAA"},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "non-streaming",
+			path:               "/api/v1/nonstreaming/airports?dry_run=true",
+			expectedBatchSizes: []int{upsertBatchSize, 2},
+			expectedAdded:      upsertBatchSize,
+			expectedChanged:    []string{"QAA"},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "too many airports",
+			path:               "/api/v1/airports?dry_run=true",
+			limits:             Limits{MaxAirports: 100},
+			expectedStatusCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:               "too many airports, non-streaming",
+			path:               "/api/v1/nonstreaming/airports?dry_run=true",
+			limits:             Limits{MaxAirports: 100},
+			expectedStatusCode: http.StatusRequestEntityTooLarge,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			newHttpResponseController = func(_ http.ResponseWriter) responseController {
+				return new(mockResponseController)
+			}
+			req, err := http.NewRequest(http.MethodPost, tc.path, bytes.NewBuffer(body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+
+			var batchSizes []int
+			rr := httptest.NewRecorder()
+			h := NewHandlers(&mockStore{
+				previewUpsert: func(ctx context.Context, batch []*airports.Airport) (airports.Diff, error) {
+					batchSizes = append(batchSizes, len(batch))
+					diff := airports.Diff{}
+					for _, a := range batch {
+						if len(batchSizes) > 1 && a.IataCode == "QAA" {
+							diff.Changed = append(diff.Changed, airports.AirportChange{
+								IataCode: a.IataCode,
+								Fields:   []airports.FieldChange{{Field: airports.FieldName, Old: "Airport QAA", New: a.Name}},
+							})
+							continue
+						}
+						diff.Added = append(diff.Added, *a)
+					}
+					return diff, nil
+				},
+			}, autocomplete.NewIndex(), tc.limits)
+			handler := http.HandlerFunc(h.HandleUpsert)
+			if req.URL.Path == "/api/v1/nonstreaming/airports" {
+				handler = h.HandleNonStreamingUpsert
+			}
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.expectedStatusCode, rr.Code)
+			if tc.expectedStatusCode != http.StatusOK {
+				require.Empty(t, batchSizes)
+				return
+			}
+			require.Equal(t, tc.expectedBatchSizes, batchSizes)
+			var resp DryRunResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Len(t, resp.Added, tc.expectedAdded)
+			require.True(t, sort.SliceIsSorted(resp.Added, func(i, j int) bool { return resp.Added[i].IataCode < resp.Added[j].IataCode }))
+			var changed []string
+			for _, c := range resp.Changed {
+				changed = append(changed, c.IataCode)
+			}
+			require.Equal(t, tc.expectedChanged, changed)
+			require.Zero(t, resp.Unchanged)
+		})
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package airports
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/tiagomelo/go-airports-service/db/airports"
+	"github.com/tiagomelo/go-airports-service/web"
+)
+
+const (
+	// defaultDuplicateRadiusMeters is the distance under which airports are
+	// considered duplicates when no radius is given.
+	defaultDuplicateRadiusMeters = 500
+	// maxDuplicateRadiusMeters is the maximum radius that can be given.
+	maxDuplicateRadiusMeters = 10000
+)
+
+// HandleDuplicatesReport handles the report of probable duplicate airports.
+// The report is streamed as duplicates are found, in the following shape:
+//
+//	{"duplicates":[{...},{...}],"total":2}
+//
+// Since the status code is sent before scanning starts, an error found
+// midway is reported in an "error" field instead of "total".
+func (h *handlers) HandleDuplicatesReport(w http.ResponseWriter, r *http.Request) {
+	radius, err := parseRadius(r)
+	if err != nil {
+		web.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	ctr := newHttpResponseController(w)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(`{"duplicates":[`))
+	total := 0
+	err = h.store.FindDuplicates(r.Context(), radius, func(d airports.Duplicate) error {
+		item, err := json.Marshal(d)
+		if err != nil {
+			return err
+		}
+		if total > 0 {
+			_, _ = w.Write([]byte(","))
+		}
+		if _, err := w.Write(item); err != nil {
+			return err
+		}
+		total++
+		return ctr.Flush()
+	})
+	if err != nil {
+		errMsg, _ := json.Marshal("error finding duplicates: " + err.Error())
+		_, _ = w.Write([]byte(`],"error":` + string(errMsg) + `}`))
+		return
+	}
+	_, _ = w.Write([]byte(`],"total":` + strconv.Itoa(total) + `}`))
+}
+
+// parseRadius parses the optional 'radius' query parameter, in meters.
+func parseRadius(r *http.Request) (float64, error) {
+	raw := r.URL.Query().Get("radius")
+	if raw == "" {
+		return defaultDuplicateRadiusMeters, nil
+	}
+	radius, err := strconv.ParseFloat(raw, 64)
+	if err != nil || radius <= 0 || radius > maxDuplicateRadiusMeters {
+		return 0, fmt.Errorf("query parameter 'radius' must be a number of meters greater than 0 and up to %d", maxDuplicateRadiusMeters)
+	}
+	return radius, nil
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package airports
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tiagomelo/go-airports-service/autocomplete"
+	"github.com/tiagomelo/go-airports-service/db/airports"
+)
+
+func TestHandleDuplicatesReport(t *testing.T) {
+	testCases := []struct {
+		name               string
+		url                string
+		mockFindDuplicates func(ctx context.Context, radiusMeters float64, fn func(airports.Duplicate) error) error
+		expectedOutput     string
+		expectedStatusCode int
+	}{
+		{
+			name: "happy path",
+			url:  "/api/v1/admin/airports/duplicates?radius=250",
+			mockFindDuplicates: func(ctx context.Context, radiusMeters float64, fn func(airports.Duplicate) error) error {
+				if radiusMeters != 250 {
+					return errors.New("unexpected radius")
+				}
+				if err := fn(airports.Duplicate{
+					Reason: airports.DuplicateReasonSameNameAndCity,
+					Airports: []airports.Airport{
+						{Name: "Midway", City: "Chicago", Country: "United States", IataCode: "MDW"},
+						{Name: "Midway", City: "Chicago", Country: "United States", IataCode: "XMD"},
+					},
+				}); err != nil {
+					return err
+				}
+				distance := 120.5
+				return fn(airports.Duplicate{
+					Reason: airports.DuplicateReasonNearby,
+					Airports: []airports.Airport{
+						{Name: "Congonhas", City: "Sao Paulo", Country: "Brazil", IataCode: "CGH", Geoloc: &airports.Geoloc{Lat: -23.62, Lng: -46.65}},
+						{Name: "Congonhas Airport", City: "Sao Paulo", Country: "Brazil", IataCode: "XCG", Geoloc: &airports.Geoloc{Lat: -23.62, Lng: -46.65}},
+					},
+					DistanceMeters: &distance,
+				})
+			},
+			expectedOutput: `{"duplicates":[
+				{"reason":"same_name_and_city","airports":[
+					{"name":"Midway","city":"Chicago","country":"United States","iata_code":"MDW"},
+					{"name":"Midway","city":"Chicago","country":"United States","iata_code":"XMD"}
+				]},
+				{"reason":"nearby","airports":[
+					{"name":"Congonhas","city":"Sao Paulo","country":"Brazil","iata_code":"CGH","geoloc":{"lat":-23.62,"lng":-46.65}},
+					{"name":"Congonhas Airport","city":"Sao Paulo","country":"Brazil","iata_code":"XCG","geoloc":{"lat":-23.62,"lng":-46.65}}
+				],"distance_meters":120.5}
+			],"total":2}`,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: "no duplicates",
+			url:  "/api/v1/admin/airports/duplicates",
+			mockFindDuplicates: func(ctx context.Context, radiusMeters float64, fn func(airports.Duplicate) error) error {
+				return nil
+			},
+			expectedOutput:     `{"duplicates":[],"total":0}`,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "invalid radius",
+			url:                "/api/v1/admin/airports/duplicates?radius=-1",
+			expectedOutput:     `{"error":"query parameter 'radius' must be a number of meters greater than 0 and up to 10000"}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "database error",
+			url:  "/api/v1/admin/airports/duplicates",
+			mockFindDuplicates: func(ctx context.Context, radiusMeters float64, fn func(airports.Duplicate) error) error {
+				return errors.New("database error")
+			},
+			expectedOutput:     `{"duplicates":[],"error":"error finding duplicates: database error"}`,
+			expectedStatusCode: http.StatusOK,
+		},
+	}
+	originalNewHttpResponseController := newHttpResponseController
+	defer func() {
+		newHttpResponseController = originalNewHttpResponseController
+	}()
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			newHttpResponseController = func(_ http.ResponseWriter) responseController {
+				return new(mockResponseController)
+			}
+
+			req, err := http.NewRequest(http.MethodGet, tc.url, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			h := NewHandlers(&mockStore{findDuplicates: tc.mockFindDuplicates}, autocomplete.NewIndex(), Limits{})
+			handler := http.HandlerFunc(h.HandleDuplicatesReport)
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.expectedStatusCode, rr.Code)
+			require.JSONEq(t, tc.expectedOutput, rr.Body.String())
+		})
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package airports
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/tiagomelo/go-airports-service/db/airports"
+	"github.com/tiagomelo/go-airports-service/web"
+	"golang.org/x/text/language"
+)
+
+// HandleGet handles the lookup of a single airport by its IATA code.
+// The airport's name is localized according to the Accept-Language header
+// when a matching localized name exists.
+func (h *handlers) HandleGet(w http.ResponseWriter, r *http.Request) {
+	iataCode := strings.ToUpper(mux.Vars(r)["iata_code"])
+	airport, err := h.store.Get(r.Context(), iataCode)
+	if err != nil {
+		if errors.Is(err, airports.ErrNotFound) {
+			web.RespondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		web.RespondWithError(w, http.StatusInternalServerError, errors.Wrap(err, "error getting airport").Error())
+		return
+	}
+	if name, lang, ok := localizedName(airport.Names, r.Header.Get("Accept-Language")); ok {
+		airport.Name = name
+		w.Header().Set("Content-Language", lang)
+	}
+	web.Respond(w, http.StatusOK, airport)
+}
+
+// DeleteAirportResponse represents a response to a delete airport request.
+type DeleteAirportResponse struct {
+	Message string `json:"message"`
+}
+
+// HandleDelete handles the deletion of a single airport by its IATA code.
+func (h *handlers) HandleDelete(w http.ResponseWriter, r *http.Request) {
+	iataCode := strings.ToUpper(mux.Vars(r)["iata_code"])
+	if err := h.store.Delete(r.Context(), iataCode); err != nil {
+		if errors.Is(err, airports.ErrNotFound) {
+			web.RespondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		web.RespondWithError(w, http.StatusInternalServerError, errors.Wrap(err, "error deleting airport").Error())
+		return
+	}
+	h.index.Remove(iataCode)
+	web.Respond(w, http.StatusOK, DeleteAirportResponse{Message: "airport deleted"})
+}
+
+// HandleList handles the listing of airports, optionally filtered by
+// country and city.
+func (h *handlers) HandleList(w http.ResponseWriter, r *http.Request) {
+	filter := airports.ListFilter{
+		Country: strings.TrimSpace(r.URL.Query().Get("country")),
+		City:    strings.TrimSpace(r.URL.Query().Get("city")),
+	}
+	all, err := h.store.List(r.Context(), filter)
+	if err != nil {
+		web.RespondWithError(w, http.StatusInternalServerError, errors.Wrap(err, "error listing airports").Error())
+		return
+	}
+	web.Respond(w, http.StatusOK, all)
+}
+
+// localizedName picks the name that best matches the given Accept-Language
+// header, preferring official names over aliases within the same language.
+// It reports false when no name matches any of the accepted languages.
+func localizedName(names []airports.LocalizedName, acceptLanguage string) (string, string, bool) {
+	if len(names) == 0 || acceptLanguage == "" {
+		return "", "", false
+	}
+	prefs, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(prefs) == 0 {
+		return "", "", false
+	}
+	var (
+		langs []string
+		tags  []language.Tag
+	)
+	seen := make(map[string]bool)
+	for _, n := range names {
+		if seen[n.Lang] {
+			continue
+		}
+		tag, err := language.Parse(n.Lang)
+		if err != nil {
+			continue
+		}
+		seen[n.Lang] = true
+		langs = append(langs, n.Lang)
+		tags = append(tags, tag)
+	}
+	if len(tags) == 0 {
+		return "", "", false
+	}
+	_, index, confidence := language.NewMatcher(tags).Match(prefs...)
+	if confidence == language.No {
+		return "", "", false
+	}
+	lang := langs[index]
+	var alias string
+	for _, n := range names {
+		if n.Lang != lang {
+			continue
+		}
+		if n.Type != airports.NameTypeAlias {
+			return n.Value, lang, true
+		}
+		if alias == "" {
+			alias = n.Value
+		}
+	}
+	return alias, lang, true
+}
@@ -0,0 +1,241 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package airports
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+	"github.com/tiagomelo/go-airports-service/autocomplete"
+	"github.com/tiagomelo/go-airports-service/db/airports"
+)
+
+func TestHandleGet(t *testing.T) {
+	cdg := func() *airports.Airport {
+		return &airports.Airport{
+			Name:     "Charles De Gaulle",
+			City:     "Paris",
+			Country:  "France",
+			IataCode: "CDG",
+			Names: []airports.LocalizedName{
+				{Lang: "fr", Value: "Roissy", Type: airports.NameTypeAlias},
+				{Lang: "fr", Value: "Aéroport Paris-Charles-de-Gaulle", Type: airports.NameTypeOfficial},
+				{Lang: "pt-BR", Value: "Aeroporto Charles de Gaulle", Type: airports.NameTypeOfficial},
+			},
+		}
+	}
+	testCases := []struct {
+		name                    string
+		acceptLanguage          string
+		mockGetAirport          func(ctx context.Context, iataCode string) (*airports.Airport, error)
+		expectedName            string
+		expectedContentLanguage string
+		expectedOutput          string
+		expectedStatusCode      int
+	}{
+		{
+			name: "no Accept-Language",
+			mockGetAirport: func(ctx context.Context, iataCode string) (*airports.Airport, error) {
+				return cdg(), nil
+			},
+			expectedName:       "Charles De Gaulle",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:           "official name preferred over alias",
+			acceptLanguage: "fr-FR,fr;q=0.9,en;q=0.8",
+			mockGetAirport: func(ctx context.Context, iataCode string) (*airports.Airport, error) {
+				return cdg(), nil
+			},
+			expectedName:            "Aéroport Paris-Charles-de-Gaulle",
+			expectedContentLanguage: "fr",
+			expectedStatusCode:      http.StatusOK,
+		},
+		{
+			name:           "regional variant",
+			acceptLanguage: "pt",
+			mockGetAirport: func(ctx context.Context, iataCode string) (*airports.Airport, error) {
+				return cdg(), nil
+			},
+			expectedName:            "Aeroporto Charles de Gaulle",
+			expectedContentLanguage: "pt-BR",
+			expectedStatusCode:      http.StatusOK,
+		},
+		{
+			name:           "no matching language",
+			acceptLanguage: "ja",
+			mockGetAirport: func(ctx context.Context, iataCode string) (*airports.Airport, error) {
+				return cdg(), nil
+			},
+			expectedName:       "Charles De Gaulle",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: "not found",
+			mockGetAirport: func(ctx context.Context, iataCode string) (*airports.Airport, error) {
+				return nil, airports.ErrNotFound
+			},
+			expectedOutput:     `{"error":"airport not found"}`,
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name: "database error",
+			mockGetAirport: func(ctx context.Context, iataCode string) (*airports.Airport, error) {
+				return nil, errors.New("database error")
+			},
+			expectedOutput:     `{"error":"error getting airport: database error"}`,
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/api/v1/airports/cdg", nil)
+			require.NoError(t, err)
+			req = mux.SetURLVars(req, map[string]string{"iata_code": "cdg"})
+			if tc.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tc.acceptLanguage)
+			}
+
+			rr := httptest.NewRecorder()
+			h := NewHandlers(&mockStore{get: tc.mockGetAirport}, autocomplete.NewIndex(), Limits{})
+			handler := http.HandlerFunc(h.HandleGet)
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.expectedStatusCode, rr.Code)
+			if tc.expectedOutput != "" {
+				require.JSONEq(t, tc.expectedOutput, rr.Body.String())
+				return
+			}
+			var output airports.Airport
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &output))
+			require.Equal(t, tc.expectedName, output.Name)
+			require.Equal(t, "CDG", output.IataCode)
+			require.Len(t, output.Names, 3)
+			require.Equal(t, tc.expectedContentLanguage, rr.Header().Get("Content-Language"))
+		})
+	}
+}
+
+func TestHandleDelete(t *testing.T) {
+	testCases := []struct {
+		name               string
+		mockDelete         func(ctx context.Context, iataCode string) error
+		expectedOutput     string
+		expectedStatusCode int
+	}{
+		{
+			name: "happy path",
+			mockDelete: func(ctx context.Context, iataCode string) error {
+				if iataCode != "CDG" {
+					return errors.New("unexpected IATA code")
+				}
+				return nil
+			},
+			expectedOutput:     `{"message":"airport deleted"}`,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: "not found",
+			mockDelete: func(ctx context.Context, iataCode string) error {
+				return airports.ErrNotFound
+			},
+			expectedOutput:     `{"error":"airport not found"}`,
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name: "database error",
+			mockDelete: func(ctx context.Context, iataCode string) error {
+				return errors.New("database error")
+			},
+			expectedOutput:     `{"error":"error deleting airport: database error"}`,
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodDelete, "/api/v1/airports/cdg", nil)
+			require.NoError(t, err)
+			req = mux.SetURLVars(req, map[string]string{"iata_code": "cdg"})
+
+			index := autocomplete.NewIndex()
+			index.Add(airports.Airport{Name: "Charles De Gaulle", City: "Paris", Country: "France", IataCode: "CDG"})
+			rr := httptest.NewRecorder()
+			h := NewHandlers(&mockStore{delete: tc.mockDelete}, index, Limits{})
+			handler := http.HandlerFunc(h.HandleDelete)
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.expectedStatusCode, rr.Code)
+			require.JSONEq(t, tc.expectedOutput, rr.Body.String())
+			if tc.expectedStatusCode == http.StatusOK {
+				require.Zero(t, index.Len())
+			} else {
+				require.Equal(t, 1, index.Len())
+			}
+		})
+	}
+}
+
+func TestHandleList(t *testing.T) {
+	testCases := []struct {
+		name               string
+		url                string
+		mockListAirports   func(ctx context.Context, filter airports.ListFilter) ([]airports.Airport, error)
+		expectedOutput     string
+		expectedStatusCode int
+	}{
+		{
+			name: "happy path",
+			url:  "/api/v1/airports",
+			mockListAirports: func(ctx context.Context, filter airports.ListFilter) ([]airports.Airport, error) {
+				return []airports.Airport{
+					{Name: "Charles De Gaulle", City: "Paris", Country: "France", IataCode: "CDG"},
+				}, nil
+			},
+			expectedOutput:     `[{"name":"Charles De Gaulle","city":"Paris","country":"France","iata_code":"CDG"}]`,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: "filtered by country and city",
+			url:  "/api/v1/airports?country=France&city=+Paris+",
+			mockListAirports: func(ctx context.Context, filter airports.ListFilter) ([]airports.Airport, error) {
+				if filter != (airports.ListFilter{Country: "France", City: "Paris"}) {
+					return nil, errors.New("unexpected filter")
+				}
+				return []airports.Airport{}, nil
+			},
+			expectedOutput:     `[]`,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: "database error",
+			url:  "/api/v1/airports",
+			mockListAirports: func(ctx context.Context, filter airports.ListFilter) ([]airports.Airport, error) {
+				return nil, errors.New("database error")
+			},
+			expectedOutput:     `{"error":"error listing airports: database error"}`,
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, tc.url, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			h := NewHandlers(&mockStore{list: tc.mockListAirports}, autocomplete.NewIndex(), Limits{})
+			handler := http.HandlerFunc(h.HandleList)
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.expectedStatusCode, rr.Code)
+			require.JSONEq(t, tc.expectedOutput, rr.Body.String())
+		})
+	}
+}
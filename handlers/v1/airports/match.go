@@ -0,0 +1,106 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package airports
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/tiagomelo/go-airports-service/db/airports"
+	"github.com/tiagomelo/go-airports-service/fuzzy"
+	"github.com/tiagomelo/go-airports-service/web"
+)
+
+const (
+	// defaultMatchLimit is the number of candidates returned when no limit is given.
+	defaultMatchLimit = 5
+	// maxMatchLimit is the maximum number of candidates that can be returned.
+	maxMatchLimit = 50
+	// defaultMinMatchScore is the score below which candidates are discarded
+	// when no min_score is given.
+	defaultMinMatchScore = 0.5
+	// nameWeight is how much the name similarity weighs in the final
+	// score when a city is given; the city similarity weighs the rest.
+	nameWeight = 0.75
+)
+
+// MatchCandidate is an existing airport that may correspond to the given
+// name and city, along with how similar they are (from 0 to 1).
+type MatchCandidate struct {
+	airports.Airport
+	Score     float64  `json:"score"`
+	NameScore float64  `json:"name_score"`
+	CityScore *float64 `json:"city_score,omitempty"`
+}
+
+// HandleMatch handles the fuzzy matching of a possibly misspelled airport
+// name (and optionally city) against existing airports, as held by the
+// autocomplete index, so that no database round-trip is made. The name is
+// compared with the airports' names along with their localized names and
+// aliases, and scored by the closest one.
+func (h *handlers) HandleMatch(w http.ResponseWriter, r *http.Request) {
+	name := fuzzy.Normalize(r.URL.Query().Get("name"))
+	if name == "" {
+		web.RespondWithError(w, http.StatusBadRequest, "query parameter 'name' is required")
+		return
+	}
+	city := fuzzy.Normalize(r.URL.Query().Get("city"))
+	limit, err := parseLimit(r, defaultMatchLimit, maxMatchLimit)
+	if err != nil {
+		web.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	minScore, err := parseMinScore(r)
+	if err != nil {
+		web.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	candidates := []MatchCandidate{}
+	h.index.Each(func(a airports.Airport) {
+		c := MatchCandidate{Airport: a}
+		c.Names = nil
+		c.NameScore = fuzzy.Similarity(name, fuzzy.Normalize(a.Name))
+		for _, n := range a.Names {
+			c.NameScore = math.Max(c.NameScore, fuzzy.Similarity(name, fuzzy.Normalize(n.Value)))
+		}
+		c.Score = c.NameScore
+		if city != "" {
+			cityScore := fuzzy.Similarity(city, fuzzy.Normalize(a.City))
+			c.CityScore = &cityScore
+			c.Score = nameWeight*c.NameScore + (1-nameWeight)*cityScore
+		}
+		if c.Score >= minScore {
+			candidates = append(candidates, c)
+		}
+	})
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		return candidates[i].IataCode < candidates[j].IataCode
+	})
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	web.Respond(w, http.StatusOK, candidates)
+}
+
+// parseMinScore parses the optional 'min_score' query parameter, which
+// must be between 0 and 1.
+func parseMinScore(r *http.Request) (float64, error) {
+	raw := strings.TrimSpace(r.URL.Query().Get("min_score"))
+	if raw == "" {
+		return defaultMinMatchScore, nil
+	}
+	minScore, err := strconv.ParseFloat(raw, 64)
+	if err != nil || minScore < 0 || minScore > 1 {
+		return 0, errors.New("query parameter 'min_score' must be a number between 0 and 1")
+	}
+	return minScore, nil
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package airports
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tiagomelo/go-airports-service/autocomplete"
+	"github.com/tiagomelo/go-airports-service/db/airports"
+)
+
+func TestHandleMatch(t *testing.T) {
+	existing := []airports.Airport{
+		{Name: "Chicago O'Hare International", City: "Chicago", Country: "United States", IataCode: "ORD"},
+		{Name: "Chicago Midway International", City: "Chicago", Country: "United States", IataCode: "MDW"},
+		{Name: "Heathrow", City: "London", Country: "United Kingdom", IataCode: "LHR"},
+		{
+			Name: "Aeroporto Internacional de Guarulhos", City: "Guarulhos", Country: "Brasil", IataCode: "GRU",
+			Names: []airports.LocalizedName{{Lang: "pt", Value: "Cumbica", Type: "alias"}},
+		},
+	}
+	testCases := []struct {
+		name               string
+		url                string
+		expectedIataCodes  []string
+		expectedOutput     string
+		expectedStatusCode int
+	}{
+		{
+			name:               "happy path",
+			url:                "/api/v1/airports/match?name=Chicago+Ohare+Intl",
+			expectedIataCodes:  []string{"ORD", "MDW"},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "with city and limit",
+			url:                "/api/v1/airports/match?name=Chicago+Ohare+Intl&city=chicago&limit=1",
+			expectedIataCodes:  []string{"ORD"},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "min score filters out weak candidates",
+			url:                "/api/v1/airports/match?name=Chicago+Ohare+Intl&min_score=0.99",
+			expectedIataCodes:  []string{"ORD"},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "alias",
+			url:                "/api/v1/airports/match?name=cumbika",
+			expectedIataCodes:  []string{"GRU"},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "no candidates",
+			url:                "/api/v1/airports/match?name=zzzzzz&min_score=0.9",
+			expectedIataCodes:  []string{},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "missing name",
+			url:                "/api/v1/airports/match?city=chicago",
+			expectedOutput:     `{"error":"query parameter 'name' is required"}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "invalid min score",
+			url:                "/api/v1/airports/match?name=ohare&min_score=2",
+			expectedOutput:     `{"error":"query parameter 'min_score' must be a number between 0 and 1"}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, tc.url, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			index := autocomplete.NewIndex()
+			index.Load(existing)
+			h := NewHandlers(&mockStore{}, index, Limits{})
+			handler := http.HandlerFunc(h.HandleMatch)
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.expectedStatusCode, rr.Code)
+			if tc.expectedOutput != "" {
+				require.JSONEq(t, tc.expectedOutput, rr.Body.String())
+				return
+			}
+			var candidates []MatchCandidate
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &candidates))
+			iataCodes := []string{}
+			for _, c := range candidates {
+				iataCodes = append(iataCodes, c.IataCode)
+				require.Nil(t, c.Names)
+			}
+			require.Equal(t, tc.expectedIataCodes, iataCodes)
+		})
+	}
+}
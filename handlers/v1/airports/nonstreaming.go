@@ -5,6 +5,7 @@
 package airports
 
 import (
+	"bytes"
 	"encoding/json"
 	"io"
 	"net/http"
@@ -12,6 +13,9 @@ import (
 	"runtime/debug"
 
 	"github.com/pkg/errors"
+	"github.com/tiagomelo/go-airports-service/db/airports"
+	"github.com/tiagomelo/go-airports-service/metrics"
+	"github.com/tiagomelo/go-airports-service/ratelimit"
 	"github.com/tiagomelo/go-airports-service/validate"
 	"github.com/tiagomelo/go-airports-service/web"
 )
@@ -23,31 +27,95 @@ var (
 )
 
 // HandleNonStreamingUpsert handles the upsert of airports by reading the entire JSON array into memory.
+// With dry_run=true, nothing is upserted: the response tells how the airports would change the stored ones instead.
 func (h *handlers) HandleNonStreamingUpsert(w http.ResponseWriter, r *http.Request) {
+	dryRun, err := parseDryRun(r)
+	if err != nil {
+		web.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	// read full request body into memory.
 	body, err := ioReadAll(r.Body)
 	if err != nil {
-		web.RespondWithError(w, http.StatusBadRequest, "failed to read request body")
+		herr := bodyError(err, "failed to read request body")
+		web.RespondWithError(w, herr.code, herr.Error())
 		return
 	}
-	var airportsToBeUpserted []UpsertAirportRequest
-	if err := jsonUnmarshal(body, &airportsToBeUpserted); err != nil {
+	var elements []json.RawMessage
+	if err := jsonUnmarshal(body, &elements); err != nil {
 		web.RespondWithError(w, http.StatusBadRequest, "invalid JSON format")
 		return
 	}
-	for _, request := range airportsToBeUpserted {
+	if h.limits.MaxAirports > 0 && len(elements) > h.limits.MaxAirports {
+		herr := tooManyAirports(h.limits.MaxAirports)
+		web.RespondWithError(w, herr.code, herr.Error())
+		return
+	}
+	airportsToBeUpserted := make([]UpsertAirportRequest, len(elements))
+	for i, element := range elements {
+		if err := h.unmarshalAirport(element, &airportsToBeUpserted[i]); err != nil {
+			herr := h.decodeError(err, "invalid JSON format")
+			web.RespondWithError(w, herr.code, herr.Error())
+			return
+		}
+	}
+	if dryRun {
+		batch := make([]*airports.Airport, 0, len(airportsToBeUpserted))
+		for _, request := range airportsToBeUpserted {
+			if err := validate.Check(request); err != nil {
+				web.RespondWithError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			batch = append(batch, request.ToAirport())
+		}
+		resp, herr := h.previewUpsert(r.Context(), batch)
+		if herr != nil {
+			web.RespondWithError(w, herr.code, herr.Error())
+			return
+		}
+		web.Respond(w, http.StatusOK, resp)
+		return
+	}
+	quota := ratelimit.FromContext(r.Context())
+	for i, request := range airportsToBeUpserted {
 		if err := validate.Check(request); err != nil {
+			metrics.ObserveUpsertRequest(metrics.EndpointNonStreaming, i, 1)
 			web.RespondWithError(w, http.StatusBadRequest, err.Error())
 			return
 		}
-		if err := upsertAirport(r.Context(), h.db, request.ToAirport()); err != nil {
+		if quota != nil {
+			if herr := takeQuota(w, quota, 1); herr != nil {
+				metrics.ObserveUpsertRequest(metrics.EndpointNonStreaming, i, 1)
+				web.RespondWithError(w, herr.code, herr.Error())
+				return
+			}
+		}
+		airport := request.ToAirport()
+		if err := h.store.Upsert(r.Context(), airport); err != nil {
+			metrics.ObserveUpsertRequest(metrics.EndpointNonStreaming, i, 1)
 			web.RespondWithError(w, http.StatusInternalServerError, errors.Wrap(err, "error upserting airport").Error())
 			return
 		}
+		h.index.Add(*airport)
 	}
+	metrics.ObserveUpsertRequest(metrics.EndpointNonStreaming, len(airportsToBeUpserted), 0)
 	web.Respond(w, http.StatusOK, UpsertAirportResponse{Message: "airports upserted"})
 
 	// manually trigger garbage collection to free up memory.
 	runtime.GC()
 	debug.FreeOSMemory()
 }
+
+// unmarshalAirport unmarshals a single element of the JSON array, failing
+// with errElementTooLarge when it is larger than the maximum size of an
+// airport and rejecting unknown fields in strict mode.
+func (h *handlers) unmarshalAirport(element json.RawMessage, req *UpsertAirportRequest) error {
+	if maxBytes := h.limits.MaxAirportBytes; maxBytes > 0 && int64(len(element)) > maxBytes {
+		return errElementTooLarge
+	}
+	dec := json.NewDecoder(bytes.NewReader(element))
+	if h.limits.Strict {
+		dec.DisallowUnknownFields()
+	}
+	return dec.Decode(req)
+}
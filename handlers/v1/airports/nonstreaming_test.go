@@ -3,14 +3,15 @@ package airports
 import (
 	"bytes"
 	"context"
-	"database/sql"
 	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	"github.com/tiagomelo/go-airports-service/autocomplete"
 	"github.com/tiagomelo/go-airports-service/db/airports"
 )
 
@@ -20,7 +21,7 @@ func TestHandleNonStreamingUpsert(t *testing.T) {
 		input              string
 		mockIoReadAll      func(r io.Reader) ([]byte, error)
 		mockJsonUnmarshal  func(data []byte, v any) error
-		mockUpsertAirport  func(ctx context.Context, db *sql.DB, airport *airports.Airport) error
+		mockUpsertBatch    func(ctx context.Context, batch []*airports.Airport) error
 		expectedOutput     string
 		expectedStatusCode int
 	}{
@@ -32,7 +33,7 @@ func TestHandleNonStreamingUpsert(t *testing.T) {
 				"country": "Brasil",
 				"iata_code": "CGH"
 			}]`,
-			mockUpsertAirport: func(ctx context.Context, db *sql.DB, airport *airports.Airport) error {
+			mockUpsertBatch: func(ctx context.Context, batch []*airports.Airport) error {
 				return nil
 			},
 			expectedOutput:     `{"message":"airports upserted"}`,
@@ -71,7 +72,7 @@ func TestHandleNonStreamingUpsert(t *testing.T) {
 			mockJsonUnmarshal: func(data []byte, v any) error {
 				return io.ErrUnexpectedEOF
 			},
-			mockUpsertAirport: func(ctx context.Context, db *sql.DB, airport *airports.Airport) error {
+			mockUpsertBatch: func(ctx context.Context, batch []*airports.Airport) error {
 				return nil
 			},
 			expectedOutput:     `{"error":"invalid JSON format"}`,
@@ -84,7 +85,7 @@ func TestHandleNonStreamingUpsert(t *testing.T) {
 				"city": "São Paulo",
 				"country": "Brasil"
 			}]`,
-			mockUpsertAirport: func(ctx context.Context, db *sql.DB, airport *airports.Airport) error {
+			mockUpsertBatch: func(ctx context.Context, batch []*airports.Airport) error {
 				return nil
 			},
 			expectedOutput:     `{"error":"[{\"field\":\"iata_code\",\"error\":\"iata_code is a required field\"}]"}`,
@@ -98,7 +99,7 @@ func TestHandleNonStreamingUpsert(t *testing.T) {
 				"country": "Brasil",
 				"iata_code": "CGH"
 			}]`,
-			mockUpsertAirport: func(ctx context.Context, db *sql.DB, airport *airports.Airport) error {
+			mockUpsertBatch: func(ctx context.Context, batch []*airports.Airport) error {
 				return errors.New("database error")
 			},
 			expectedOutput:     `{"error":"error upserting airport: database error"}`,
@@ -119,14 +120,13 @@ func TestHandleNonStreamingUpsert(t *testing.T) {
 			if tc.mockJsonUnmarshal != nil {
 				jsonUnmarshal = tc.mockJsonUnmarshal
 			}
-			upsertAirport = tc.mockUpsertAirport
 
 			req, err := http.NewRequest(http.MethodPost, "/api/v1/nonstreaming/airports", bytes.NewBuffer([]byte(tc.input)))
 			require.NoError(t, err)
 			req.Header.Set("Content-Type", "application/json")
 
 			rr := httptest.NewRecorder()
-			h := NewHandlers(nil)
+			h := NewHandlers(&mockStore{upsertBatch: tc.mockUpsertBatch}, autocomplete.NewIndex(), Limits{})
 			handler := http.HandlerFunc(h.HandleNonStreamingUpsert)
 			handler.ServeHTTP(rr, req)
 
@@ -135,3 +135,90 @@ func TestHandleNonStreamingUpsert(t *testing.T) {
 		})
 	}
 }
+
+func TestHandleNonStreamingUpsertLimits(t *testing.T) {
+	const (
+		congonhas = `{"name": "Congonhas", "city": "São Paulo", "country": "Brasil", "iata_code": "CGH"}`
+		guarulhos = `{"name": "Guarulhos", "city": "São Paulo", "country": "Brasil", "iata_code": "GRU"}`
+	)
+	testCases := []struct {
+		name               string
+		limits             Limits
+		input              string
+		maxBodyBytes       int64
+		expectedUpserted   []string
+		expectedOutput     string
+		expectedStatusCode int
+	}{
+		{
+			name:               "within limits",
+			limits:             Limits{MaxAirportBytes: 128, MaxAirports: 2, Strict: true},
+			input:              "[" + congonhas + ", " + guarulhos + "]",
+			expectedUpserted:   []string{"CGH", "GRU"},
+			expectedOutput:     `{"message":"airports upserted"}`,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "unknown fields ignored",
+			input:              `[{"name": "Congonhas", "city": "São Paulo", "country": "Brasil", "iata_code": "CGH", "icao_code": "SBSP"}]`,
+			expectedUpserted:   []string{"CGH"},
+			expectedOutput:     `{"message":"airports upserted"}`,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "unknown fields rejected in strict mode",
+			limits:             Limits{Strict: true},
+			input:              "[" + congonhas + `, {"name": "Guarulhos", "city": "São Paulo", "country": "Brasil", "iata_code": "GRU", "icao_code": "SBGR"}]`,
+			expectedOutput:     `{"error":"invalid JSON format: unknown field \"icao_code\""}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "invalid airport",
+			input:              `[{"name": 1}]`,
+			expectedOutput:     `{"error":"invalid JSON format"}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "airport too large",
+			limits:             Limits{MaxAirportBytes: 80},
+			input:              "[" + congonhas + "]",
+			expectedOutput:     `{"error":"airport too large: at most 80 bytes"}`,
+			expectedStatusCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:               "too many airports",
+			limits:             Limits{MaxAirports: 1},
+			input:              "[" + congonhas + ", " + guarulhos + "]",
+			expectedOutput:     `{"error":"too many airports: at most 1 per request"}`,
+			expectedStatusCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:               "body too large",
+			input:              "[" + congonhas + ", " + guarulhos + "]",
+			maxBodyBytes:       100,
+			expectedOutput:     `{"error":"request body too large: at most 100 bytes"}`,
+			expectedStatusCode: http.StatusRequestEntityTooLarge,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var upserted []string
+			h := NewHandlers(&mockStore{upsertBatch: func(ctx context.Context, batch []*airports.Airport) error {
+				for _, airport := range batch {
+					upserted = append(upserted, airport.IataCode)
+				}
+				return nil
+			}}, autocomplete.NewIndex(), tc.limits)
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/nonstreaming/airports", strings.NewReader(tc.input))
+			if tc.maxBodyBytes > 0 {
+				req.Body = http.MaxBytesReader(rr, req.Body, tc.maxBodyBytes)
+			}
+			h.HandleNonStreamingUpsert(rr, req)
+
+			require.Equal(t, tc.expectedStatusCode, rr.Code)
+			require.JSONEq(t, tc.expectedOutput, rr.Body.String())
+			require.Equal(t, tc.expectedUpserted, upserted)
+		})
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package airports
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/tiagomelo/go-airports-service/web"
+)
+
+const (
+	// defaultSearchLimit is the number of results returned when no limit is given.
+	defaultSearchLimit = 20
+	// maxSearchLimit is the maximum number of results a search can return.
+	maxSearchLimit = 100
+)
+
+// HandleSearch handles the full-text search of airports.
+func (h *handlers) HandleSearch(w http.ResponseWriter, r *http.Request) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		web.RespondWithError(w, http.StatusBadRequest, "query parameter 'q' is required")
+		return
+	}
+	limit, err := parseLimit(r, defaultSearchLimit, maxSearchLimit)
+	if err != nil {
+		web.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	results, err := h.store.Search(r.Context(), query, limit)
+	if err != nil {
+		web.RespondWithError(w, http.StatusInternalServerError, errors.Wrap(err, "error searching airports").Error())
+		return
+	}
+	web.Respond(w, http.StatusOK, results)
+}
+
+// parseLimit parses the optional 'limit' query parameter, which must be
+// between 1 and max. It returns def when the parameter is absent.
+func parseLimit(r *http.Request, def, max int) (int, error) {
+	raw := r.URL.Query().Get("limit")
+	if raw == "" {
+		return def, nil
+	}
+	limit, err := strconv.Atoi(raw)
+	if err != nil || limit < 1 || limit > max {
+		return 0, fmt.Errorf("query parameter 'limit' must be an integer between 1 and %d", max)
+	}
+	return limit, nil
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package airports
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tiagomelo/go-airports-service/autocomplete"
+	"github.com/tiagomelo/go-airports-service/db/airports"
+)
+
+func TestHandleSearch(t *testing.T) {
+	testCases := []struct {
+		name               string
+		url                string
+		mockSearchAirports func(ctx context.Context, query string, limit int) ([]airports.SearchResult, error)
+		expectedOutput     string
+		expectedStatusCode int
+	}{
+		{
+			name: "happy path",
+			url:  "/api/v1/airports/search?q=heath&limit=5",
+			mockSearchAirports: func(ctx context.Context, query string, limit int) ([]airports.SearchResult, error) {
+				if query != "heath" || limit != 5 {
+					return nil, errors.New("unexpected arguments")
+				}
+				return []airports.SearchResult{
+					{
+						Airport: airports.Airport{Name: "Heathrow", City: "London", Country: "United Kingdom", IataCode: "LHR"},
+						Score:   1.5,
+					},
+				}, nil
+			},
+			expectedOutput:     `[{"name":"Heathrow","city":"London","country":"United Kingdom","iata_code":"LHR","score":1.5}]`,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "missing query",
+			url:                "/api/v1/airports/search?q=%20",
+			expectedOutput:     `{"error":"query parameter 'q' is required"}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "invalid limit",
+			url:                "/api/v1/airports/search?q=heath&limit=1000",
+			expectedOutput:     `{"error":"query parameter 'limit' must be an integer between 1 and 100"}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "database error",
+			url:  "/api/v1/airports/search?q=heath",
+			mockSearchAirports: func(ctx context.Context, query string, limit int) ([]airports.SearchResult, error) {
+				return nil, errors.New("database error")
+			},
+			expectedOutput:     `{"error":"error searching airports: database error"}`,
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, tc.url, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			h := NewHandlers(&mockStore{search: tc.mockSearchAirports}, autocomplete.NewIndex(), Limits{})
+			handler := http.HandlerFunc(h.HandleSearch)
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.expectedStatusCode, rr.Code)
+			require.JSONEq(t, tc.expectedOutput, rr.Body.String())
+		})
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package apikeys
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/tiagomelo/go-airports-service/apikeys"
+	"github.com/tiagomelo/go-airports-service/auth"
+	"github.com/tiagomelo/go-airports-service/validate"
+	"github.com/tiagomelo/go-airports-service/web"
+)
+
+// CreateAPIKeyRequest represents a request to create an API key.
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name" validate:"required"`
+	Scopes []string `json:"scopes" validate:"required,min=1,dive,oneof=airports:read airports:write admin"`
+}
+
+// CreateAPIKeyResponse represents a response to a create API key request,
+// the only one the key's secret is ever sent in.
+type CreateAPIKeyResponse struct {
+	apikeys.Key
+	Secret string `json:"key"`
+}
+
+// RevokeAPIKeyResponse represents a response to a revoke API key request.
+type RevokeAPIKeyResponse struct {
+	Message string `json:"message"`
+}
+
+// handlers struct holds the API key store.
+type handlers struct {
+	store apikeys.Store
+}
+
+// NewHandlers initializes a new instance of handlers with the API key
+// store.
+func NewHandlers(store apikeys.Store) *handlers {
+	return &handlers{store: store}
+}
+
+// HandleCreate handles the creation of an API key granting the given
+// scopes.
+func (h *handlers) HandleCreate(w http.ResponseWriter, r *http.Request) {
+	var req CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		web.RespondWithError(w, http.StatusBadRequest, "invalid JSON format")
+		return
+	}
+	if err := validate.Check(req); err != nil {
+		web.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	// the scopes were already validated.
+	scopes, _ := auth.ParseScopes(req.Scopes)
+	key, secret := apikeys.New(req.Name, scopes)
+	if err := h.store.Insert(r.Context(), key, apikeys.Hash(secret)); err != nil {
+		web.RespondWithError(w, http.StatusInternalServerError, errors.Wrap(err, "error creating api key").Error())
+		return
+	}
+	web.Respond(w, http.StatusCreated, CreateAPIKeyResponse{Key: key, Secret: secret})
+}
+
+// HandleList handles the listing of API keys, revoked ones included,
+// oldest first. Keys themselves are never listed.
+func (h *handlers) HandleList(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.store.List(r.Context())
+	if err != nil {
+		web.RespondWithError(w, http.StatusInternalServerError, errors.Wrap(err, "error listing api keys").Error())
+		return
+	}
+	web.Respond(w, http.StatusOK, keys)
+}
+
+// HandleRevoke handles the revocation of an API key by its ID.
+func (h *handlers) HandleRevoke(w http.ResponseWriter, r *http.Request) {
+	if err := h.store.Revoke(r.Context(), mux.Vars(r)["id"]); err != nil {
+		if errors.Is(err, apikeys.ErrNotFound) {
+			web.RespondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		web.RespondWithError(w, http.StatusInternalServerError, errors.Wrap(err, "error revoking api key").Error())
+		return
+	}
+	web.Respond(w, http.StatusOK, RevokeAPIKeyResponse{Message: "api key revoked"})
+}
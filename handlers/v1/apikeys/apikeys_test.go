@@ -0,0 +1,194 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package apikeys
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+	"github.com/tiagomelo/go-airports-service/apikeys"
+	"github.com/tiagomelo/go-airports-service/auth"
+)
+
+type failingStore struct {
+	*apikeys.MemoryStore
+	err error
+}
+
+func (s *failingStore) Insert(ctx context.Context, key apikeys.Key, hash string) error {
+	if s.err != nil {
+		return s.err
+	}
+	return s.MemoryStore.Insert(ctx, key, hash)
+}
+
+func (s *failingStore) List(ctx context.Context) ([]apikeys.Key, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.MemoryStore.List(ctx)
+}
+
+func (s *failingStore) Revoke(ctx context.Context, id string) error {
+	if s.err != nil {
+		return s.err
+	}
+	return s.MemoryStore.Revoke(ctx, id)
+}
+
+func TestHandleCreate(t *testing.T) {
+	testCases := []struct {
+		name               string
+		input              string
+		storeErr           error
+		expectedStatusCode int
+		expectedOutput     string
+	}{
+		{
+			name:               "happy path",
+			input:              `{"name": "feed importer", "scopes": ["airports:read", "airports:write"]}`,
+			expectedStatusCode: http.StatusCreated,
+		},
+		{
+			name:               "invalid JSON",
+			input:              `{"name": "feed importer"`,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedOutput:     `{"error":"invalid JSON format"}`,
+		},
+		{
+			name:               "missing scopes",
+			input:              `{"name": "feed importer"}`,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedOutput:     `{"error":"[{\"field\":\"scopes\",\"error\":\"scopes is a required field\"}]"}`,
+		},
+		{
+			name:               "invalid scope",
+			input:              `{"name": "feed importer", "scopes": ["airports:delete"]}`,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedOutput:     `{"error":"[{\"field\":\"scopes[0]\",\"error\":\"scopes[0] must be one of [airports:read airports:write admin]\"}]"}`,
+		},
+		{
+			name:               "store error",
+			input:              `{"name": "feed importer", "scopes": ["admin"]}`,
+			storeErr:           errors.New("store error"),
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedOutput:     `{"error":"error creating api key: store error"}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := apikeys.NewMemoryStore()
+			h := NewHandlers(&failingStore{MemoryStore: store, err: tc.storeErr})
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/api-keys", strings.NewReader(tc.input))
+			rr := httptest.NewRecorder()
+			h.HandleCreate(rr, req)
+			require.Equal(t, tc.expectedStatusCode, rr.Code)
+			if tc.expectedOutput != "" {
+				require.JSONEq(t, tc.expectedOutput, rr.Body.String())
+				return
+			}
+			var resp CreateAPIKeyResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, "feed importer", resp.Name)
+			require.Equal(t, []auth.Scope{auth.ScopeAirportsRead, auth.ScopeAirportsWrite}, resp.Scopes)
+			require.True(t, strings.HasPrefix(resp.Secret, "ak_"))
+			// only the hash of the key is stored.
+			stored, err := store.GetByHash(context.TODO(), apikeys.Hash(resp.Secret))
+			require.NoError(t, err)
+			require.Equal(t, resp.ID, stored.ID)
+		})
+	}
+}
+
+func TestHandleList(t *testing.T) {
+	createdAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	testCases := []struct {
+		name               string
+		storeErr           error
+		expectedStatusCode int
+		expectedOutput     string
+	}{
+		{
+			name:               "happy path",
+			expectedStatusCode: http.StatusOK,
+			expectedOutput:     `[{"id":"0123456789abcdef","name":"feed importer","scopes":["airports:write"],"created_at":"2025-01-02T03:04:05Z"}]`,
+		},
+		{
+			name:               "store error",
+			storeErr:           errors.New("store error"),
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedOutput:     `{"error":"error listing api keys: store error"}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := apikeys.NewMemoryStore()
+			require.NoError(t, store.Insert(context.TODO(), apikeys.Key{
+				ID:        "0123456789abcdef",
+				Name:      "feed importer",
+				Scopes:    []auth.Scope{auth.ScopeAirportsWrite},
+				CreatedAt: createdAt,
+			}, "hash"))
+			h := NewHandlers(&failingStore{MemoryStore: store, err: tc.storeErr})
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/api-keys", nil)
+			rr := httptest.NewRecorder()
+			h.HandleList(rr, req)
+			require.Equal(t, tc.expectedStatusCode, rr.Code)
+			require.JSONEq(t, tc.expectedOutput, rr.Body.String())
+			require.NotContains(t, rr.Body.String(), "hash")
+		})
+	}
+}
+
+func TestHandleRevoke(t *testing.T) {
+	testCases := []struct {
+		name               string
+		id                 string
+		storeErr           error
+		expectedStatusCode int
+		expectedOutput     string
+	}{
+		{
+			name:               "happy path",
+			id:                 "0123456789abcdef",
+			expectedStatusCode: http.StatusOK,
+			expectedOutput:     `{"message":"api key revoked"}`,
+		},
+		{
+			name:               "not found",
+			id:                 "fedcba9876543210",
+			expectedStatusCode: http.StatusNotFound,
+			expectedOutput:     `{"error":"api key not found"}`,
+		},
+		{
+			name:               "store error",
+			id:                 "0123456789abcdef",
+			storeErr:           errors.New("store error"),
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedOutput:     `{"error":"error revoking api key: store error"}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := apikeys.NewMemoryStore()
+			require.NoError(t, store.Insert(context.TODO(), apikeys.Key{ID: "0123456789abcdef"}, "hash"))
+			h := NewHandlers(&failingStore{MemoryStore: store, err: tc.storeErr})
+			req := httptest.NewRequest(http.MethodDelete, "/api/v1/admin/api-keys/"+tc.id, nil)
+			req = mux.SetURLVars(req, map[string]string{"id": tc.id})
+			rr := httptest.NewRecorder()
+			h.HandleRevoke(rr, req)
+			require.Equal(t, tc.expectedStatusCode, rr.Code)
+			require.JSONEq(t, tc.expectedOutput, rr.Body.String())
+		})
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package snapshots
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/tiagomelo/go-airports-service/autocomplete"
+	"github.com/tiagomelo/go-airports-service/db/airports"
+	"github.com/tiagomelo/go-airports-service/snapshots"
+	"github.com/tiagomelo/go-airports-service/web"
+)
+
+// SnapshotMessageResponse represents a response to a rollback or delete
+// snapshot request.
+type SnapshotMessageResponse struct {
+	Message  string              `json:"message"`
+	Snapshot *snapshots.Snapshot `json:"snapshot,omitempty"`
+}
+
+// handlers struct holds the airport store, the snapshots store and the
+// autocomplete index to reload on rollbacks.
+type handlers struct {
+	store     airports.AirportStore
+	snapshots *snapshots.Store
+	index     *autocomplete.Index
+}
+
+// NewHandlers initializes a new instance of handlers with an airport
+// store, the snapshots store and the autocomplete index to reload on
+// rollbacks.
+func NewHandlers(store airports.AirportStore, snapshotStore *snapshots.Store, index *autocomplete.Index) *handlers {
+	return &handlers{
+		store:     store,
+		snapshots: snapshotStore,
+		index:     index,
+	}
+}
+
+// HandleCreate handles taking a snapshot of all airports.
+func (h *handlers) HandleCreate(w http.ResponseWriter, r *http.Request) {
+	snapshot, err := h.snapshots.Create(r.Context(), h.store)
+	if err != nil {
+		web.RespondWithError(w, http.StatusInternalServerError, errors.Wrap(err, "error creating snapshot").Error())
+		return
+	}
+	web.Respond(w, http.StatusCreated, snapshot)
+}
+
+// HandleList handles the listing of snapshots, oldest first.
+func (h *handlers) HandleList(w http.ResponseWriter, r *http.Request) {
+	all, err := h.snapshots.List()
+	if err != nil {
+		web.RespondWithError(w, http.StatusInternalServerError, errors.Wrap(err, "error listing snapshots").Error())
+		return
+	}
+	web.Respond(w, http.StatusOK, all)
+}
+
+// HandleDiff handles the comparison of the current airports against a
+// snapshot: airports added and removed since it was taken, the fields
+// changed in the remaining ones, and how many were left unchanged.
+func (h *handlers) HandleDiff(w http.ResponseWriter, r *http.Request) {
+	_, before, ok := h.getSnapshot(w, r)
+	if !ok {
+		return
+	}
+	after := []airports.Airport{}
+	err := h.store.Export(r.Context(), func(a airports.Airport) error {
+		after = append(after, a)
+		return nil
+	})
+	if err != nil {
+		web.RespondWithError(w, http.StatusInternalServerError, errors.Wrap(err, "error exporting airports").Error())
+		return
+	}
+	web.Respond(w, http.StatusOK, airports.DiffAirports(before, after))
+}
+
+// HandleRollback handles rolling all airports back to a snapshot at once,
+// reloading the autocomplete index afterwards.
+func (h *handlers) HandleRollback(w http.ResponseWriter, r *http.Request) {
+	snapshot, all, ok := h.getSnapshot(w, r)
+	if !ok {
+		return
+	}
+	batch := make([]*airports.Airport, len(all))
+	for i := range all {
+		batch[i] = &all[i]
+	}
+	if err := h.store.ReplaceAll(r.Context(), batch); err != nil {
+		web.RespondWithError(w, http.StatusInternalServerError, errors.Wrap(err, "error rolling back airports").Error())
+		return
+	}
+	var exported []airports.Airport
+	if err := h.store.Export(r.Context(), func(a airports.Airport) error {
+		exported = append(exported, a)
+		return nil
+	}); err != nil {
+		web.RespondWithError(w, http.StatusInternalServerError, errors.Wrap(err, "error reloading the autocomplete index").Error())
+		return
+	}
+	h.index.Replace(exported)
+	web.Respond(w, http.StatusOK, SnapshotMessageResponse{Message: "airports rolled back", Snapshot: snapshot})
+}
+
+// HandleDelete handles the deletion of a snapshot.
+func (h *handlers) HandleDelete(w http.ResponseWriter, r *http.Request) {
+	if err := h.snapshots.Delete(mux.Vars(r)["id"]); err != nil {
+		if errors.Is(err, snapshots.ErrNotFound) {
+			web.RespondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		web.RespondWithError(w, http.StatusInternalServerError, errors.Wrap(err, "error deleting snapshot").Error())
+		return
+	}
+	web.Respond(w, http.StatusOK, SnapshotMessageResponse{Message: "snapshot deleted"})
+}
+
+// getSnapshot gets the snapshot the request refers to along with its
+// airports. It responds with an error and reports false when it cannot.
+func (h *handlers) getSnapshot(w http.ResponseWriter, r *http.Request) (*snapshots.Snapshot, []airports.Airport, bool) {
+	snapshot, all, err := h.snapshots.Get(mux.Vars(r)["id"])
+	if err != nil {
+		if errors.Is(err, snapshots.ErrNotFound) {
+			web.RespondWithError(w, http.StatusNotFound, err.Error())
+			return nil, nil, false
+		}
+		web.RespondWithError(w, http.StatusInternalServerError, errors.Wrap(err, "error getting snapshot").Error())
+		return nil, nil, false
+	}
+	return snapshot, all, true
+}
@@ -0,0 +1,258 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package snapshots
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+	"github.com/tiagomelo/go-airports-service/autocomplete"
+	"github.com/tiagomelo/go-airports-service/db/airports"
+	"github.com/tiagomelo/go-airports-service/snapshots"
+)
+
+const unknownID = "20000101T000000.000000000Z"
+
+type failingStore struct {
+	*airports.MemoryStore
+	exportErr     error
+	replaceAllErr error
+}
+
+func (s *failingStore) Export(ctx context.Context, fn func(airports.Airport) error) error {
+	if s.exportErr != nil {
+		return s.exportErr
+	}
+	return s.MemoryStore.Export(ctx, fn)
+}
+
+func (s *failingStore) ReplaceAll(ctx context.Context, all []*airports.Airport) error {
+	if s.replaceAllErr != nil {
+		return s.replaceAllErr
+	}
+	return s.MemoryStore.ReplaceAll(ctx, all)
+}
+
+// setup returns a store holding CDG and GRU, along with a snapshots store
+// holding a snapshot of it taken before GRU was added.
+func setup(t *testing.T) (*airports.MemoryStore, *snapshots.Store, *snapshots.Snapshot) {
+	ctx := context.TODO()
+	store := airports.NewMemoryStore()
+	require.NoError(t, store.Upsert(ctx, &airports.Airport{Name: "Charles de Gaulle", City: "Paris", Country: "France", IataCode: "CDG"}))
+	snapshotStore, err := snapshots.NewStore(t.TempDir())
+	require.NoError(t, err)
+	snapshot, err := snapshotStore.Create(ctx, store)
+	require.NoError(t, err)
+	require.NoError(t, store.Upsert(ctx, &airports.Airport{Name: "Guarulhos", City: "Sao Paulo", Country: "Brazil", IataCode: "GRU"}))
+	return store, snapshotStore, snapshot
+}
+
+func TestHandleCreate(t *testing.T) {
+	testCases := []struct {
+		name               string
+		exportErr          error
+		expectedOutput     string
+		expectedStatusCode int
+	}{
+		{
+			name:               "happy path",
+			expectedStatusCode: http.StatusCreated,
+		},
+		{
+			name:               "error",
+			exportErr:          errors.New("database error"),
+			expectedOutput:     `{"error":"error creating snapshot: exporting airports: database error"}`,
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store, snapshotStore, _ := setup(t)
+			h := NewHandlers(&failingStore{MemoryStore: store, exportErr: tc.exportErr}, snapshotStore, autocomplete.NewIndex())
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/snapshots", nil)
+			rr := httptest.NewRecorder()
+			h.HandleCreate(rr, req)
+			require.Equal(t, tc.expectedStatusCode, rr.Code)
+			if tc.expectedOutput != "" {
+				require.JSONEq(t, tc.expectedOutput, rr.Body.String())
+				return
+			}
+			var snapshot snapshots.Snapshot
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &snapshot))
+			require.Equal(t, 2, snapshot.Airports)
+			all, err := snapshotStore.List()
+			require.NoError(t, err)
+			require.Len(t, all, 2)
+		})
+	}
+}
+
+func TestHandleList(t *testing.T) {
+	store, snapshotStore, snapshot := setup(t)
+	h := NewHandlers(store, snapshotStore, autocomplete.NewIndex())
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/snapshots", nil)
+	rr := httptest.NewRecorder()
+	h.HandleList(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var all []snapshots.Snapshot
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &all))
+	require.Len(t, all, 1)
+	require.Equal(t, snapshot.ID, all[0].ID)
+	require.Equal(t, 1, all[0].Airports)
+}
+
+func TestHandleDiff(t *testing.T) {
+	testCases := []struct {
+		name               string
+		id                 string
+		exportErr          error
+		expectedOutput     string
+		expectedStatusCode int
+	}{
+		{
+			name:               "happy path",
+			expectedOutput:     `{"added":[{"name":"Guarulhos","city":"Sao Paulo","country":"Brazil","iata_code":"GRU"}],"removed":[],"changed":[],"unchanged":1}`,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "snapshot not found",
+			id:                 unknownID,
+			expectedOutput:     `{"error":"snapshot not found"}`,
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "error exporting airports",
+			exportErr:          errors.New("database error"),
+			expectedOutput:     `{"error":"error exporting airports: database error"}`,
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store, snapshotStore, snapshot := setup(t)
+			id := tc.id
+			if id == "" {
+				id = snapshot.ID
+			}
+			h := NewHandlers(&failingStore{MemoryStore: store, exportErr: tc.exportErr}, snapshotStore, autocomplete.NewIndex())
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/snapshots/"+id+"/diff", nil)
+			req = mux.SetURLVars(req, map[string]string{"id": id})
+			rr := httptest.NewRecorder()
+			h.HandleDiff(rr, req)
+			require.Equal(t, tc.expectedStatusCode, rr.Code)
+			require.JSONEq(t, tc.expectedOutput, rr.Body.String())
+		})
+	}
+}
+
+func TestHandleRollback(t *testing.T) {
+	testCases := []struct {
+		name               string
+		id                 string
+		replaceAllErr      error
+		expectedOutput     string
+		expectedIataCodes  []string
+		expectedStatusCode int
+	}{
+		{
+			name:               "happy path",
+			expectedIataCodes:  []string{"CDG"},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "snapshot not found",
+			id:                 unknownID,
+			expectedOutput:     `{"error":"snapshot not found"}`,
+			expectedIataCodes:  []string{"CDG", "GRU"},
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "error replacing airports",
+			replaceAllErr:      errors.New("database error"),
+			expectedOutput:     `{"error":"error rolling back airports: database error"}`,
+			expectedIataCodes:  []string{"CDG", "GRU"},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.TODO()
+			store, snapshotStore, snapshot := setup(t)
+			id := tc.id
+			if id == "" {
+				id = snapshot.ID
+			}
+			all, err := store.List(ctx, airports.ListFilter{})
+			require.NoError(t, err)
+			index := autocomplete.NewIndex()
+			index.Load(all)
+			h := NewHandlers(&failingStore{MemoryStore: store, replaceAllErr: tc.replaceAllErr}, snapshotStore, index)
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/snapshots/"+id+"/rollback", nil)
+			req = mux.SetURLVars(req, map[string]string{"id": id})
+			rr := httptest.NewRecorder()
+			h.HandleRollback(rr, req)
+			require.Equal(t, tc.expectedStatusCode, rr.Code)
+			if tc.expectedOutput != "" {
+				require.JSONEq(t, tc.expectedOutput, rr.Body.String())
+			} else {
+				var resp SnapshotMessageResponse
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+				require.Equal(t, "airports rolled back", resp.Message)
+				require.Equal(t, snapshot.ID, resp.Snapshot.ID)
+			}
+			all, err = store.List(ctx, airports.ListFilter{})
+			require.NoError(t, err)
+			var iataCodes []string
+			for _, a := range all {
+				iataCodes = append(iataCodes, a.IataCode)
+			}
+			require.ElementsMatch(t, tc.expectedIataCodes, iataCodes)
+			require.Equal(t, len(tc.expectedIataCodes), index.Len())
+		})
+	}
+}
+
+func TestHandleDelete(t *testing.T) {
+	testCases := []struct {
+		name               string
+		id                 string
+		expectedOutput     string
+		expectedStatusCode int
+	}{
+		{
+			name:               "happy path",
+			expectedOutput:     `{"message":"snapshot deleted"}`,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "snapshot not found",
+			id:                 unknownID,
+			expectedOutput:     `{"error":"snapshot not found"}`,
+			expectedStatusCode: http.StatusNotFound,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store, snapshotStore, snapshot := setup(t)
+			id := tc.id
+			if id == "" {
+				id = snapshot.ID
+			}
+			h := NewHandlers(store, snapshotStore, autocomplete.NewIndex())
+			req := httptest.NewRequest(http.MethodDelete, "/api/v1/snapshots/"+id, nil)
+			req = mux.SetURLVars(req, map[string]string{"id": id})
+			rr := httptest.NewRecorder()
+			h.HandleDelete(rr, req)
+			require.Equal(t, tc.expectedStatusCode, rr.Code)
+			require.JSONEq(t, tc.expectedOutput, rr.Body.String())
+		})
+	}
+}
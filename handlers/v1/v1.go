@@ -5,39 +5,213 @@
 package v1
 
 import (
-	"database/sql"
 	"log/slog"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/tiagomelo/go-airports-service/apikeys"
+	"github.com/tiagomelo/go-airports-service/auth"
+	"github.com/tiagomelo/go-airports-service/autocomplete"
+	dbairports "github.com/tiagomelo/go-airports-service/db/airports"
 	"github.com/tiagomelo/go-airports-service/handlers/v1/airports"
+	apikeyshandlers "github.com/tiagomelo/go-airports-service/handlers/v1/apikeys"
+	snapshotshandlers "github.com/tiagomelo/go-airports-service/handlers/v1/snapshots"
 	"github.com/tiagomelo/go-airports-service/middleware"
+	"github.com/tiagomelo/go-airports-service/ratelimit"
+	"github.com/tiagomelo/go-airports-service/snapshots"
 )
 
-// Config struct holds the database connection and logger.
+// Config struct holds what the v1 routes are served with.
 type Config struct {
-	Db  *sql.DB
+	// Store is where airports are kept.
+	Store dbairports.AirportStore
+	// Index is the autocomplete index, kept up to date with the store.
+	Index *autocomplete.Index
+	// Snapshots is where snapshots of the dataset are kept.
+	Snapshots *snapshots.Store
+	// Log is the logger access and audit records are written to.
 	Log *slog.Logger
+	// AccessLogSampleRate is the fraction of successful requests logged.
+	AccessLogSampleRate float64
+	// Authenticator checks callers, nil when authentication is disabled.
+	Authenticator auth.Authenticator
+	// APIKeys is the API key store, nil when API keys are not accepted.
+	APIKeys apikeys.Store
+	// RateLimiter limits the requests each client sends, nil when
+	// unlimited.
+	RateLimiter *ratelimit.Limiter
+	// IPRateLimiter limits the requests each IP address sends before
+	// authentication, nil when unlimited.
+	IPRateLimiter *ratelimit.Limiter
+	// IngestQuota limits the airports each client ingests, nil when
+	// unlimited.
+	IngestQuota *ratelimit.Limiter
+	// BodyLimits are the maximum sizes of request bodies.
+	BodyLimits BodyLimits
+	// UpsertLimits bound the airports upsert requests carry.
+	UpsertLimits airports.Limits
+	// MinUploadRate is the minimum throughput of streaming uploads, in
+	// bytes per second, measured over MinUploadRateWindow. Zero means
+	// unchecked.
+	MinUploadRate       int64
+	MinUploadRateWindow time.Duration
+	// CORS are the cross-origin requests browsers are allowed to send,
+	// nil when none are.
+	CORS *middleware.CORSOptions
+}
+
+// BodyLimits are the maximum sizes of request bodies, in bytes. Zero values
+// mean unlimited.
+type BodyLimits struct {
+	// Upsert applies to the streaming upsert.
+	Upsert int64
+	// NonStreamingUpsert applies to the non-streaming upsert, which reads
+	// whole bodies into memory.
+	NonStreamingUpsert int64
+	// Default applies to every other route.
+	Default int64
 }
 
 // Routes initializes and returns a new router with configured routes.
 func Routes(c *Config) *mux.Router {
 	router := mux.NewRouter()
-	initializeRoutes(c.Db, router)
-	router.Use(
+	initializeRoutes(c, router)
+	middlewares := []mux.MiddlewareFunc{
+		middleware.RequestID,
+		middleware.Tracing,
 		func(h http.Handler) http.Handler {
-			return middleware.Logger(c.Log, h)
+			return middleware.Logger(c.Log, c.AccessLogSampleRate, h)
 		},
-		middleware.Compress,
-		middleware.PanicRecovery,
-	)
+		middleware.Metrics,
+	}
+	if c.CORS != nil {
+		// preflight requests are answered by the CORS middleware, but
+		// only get to it when some route matches them. Those for paths no
+		// route serves are left to handleOptions, which answers them with
+		// 404 Not Found.
+		router.PathPrefix("/").Methods(http.MethodOptions).HandlerFunc(handleOptions(router))
+		middlewares = append(middlewares, func(h http.Handler) http.Handler {
+			cors := middleware.CORS(*c.CORS, h)
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodOptions && len(allowedMethods(router, r)) == 0 {
+					h.ServeHTTP(w, r)
+					return
+				}
+				cors.ServeHTTP(w, r)
+			})
+		})
+	}
+	router.Use(append(middlewares, middleware.Compress, middleware.PanicRecovery)...)
 	return router
 }
 
-// initializeRoutes sets up the routes for airport operations.
-func initializeRoutes(db *sql.DB, router *mux.Router) {
-	airportsHandler := airports.NewHandlers(db)
+// routeMethods are the methods routes are looked up with to tell which ones
+// a path allows.
+var routeMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+// handleOptions returns a handler answering OPTIONS requests other than
+// CORS preflights, which no route allows, with 405 Method Not Allowed and
+// the methods the router allows for the path in the Allow header, or with
+// 404 Not Found when it allows none.
+func handleOptions(router *mux.Router) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		allowed := allowedMethods(router, r)
+		if len(allowed) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// allowedMethods returns the methods the router allows for the path of r.
+func allowedMethods(router *mux.Router, r *http.Request) []string {
+	var allowed []string
+	for _, method := range routeMethods {
+		req := r.WithContext(r.Context())
+		req.Method = method
+		var match mux.RouteMatch
+		if router.Match(req, &match) && match.MatchErr == nil {
+			allowed = append(allowed, method)
+		}
+	}
+	return allowed
+}
+
+// initializeRoutes sets up the routes for airport, snapshot and API key
+// operations, each one requiring its scope when authentication is
+// enabled, rate limited per IP address before authentication and per
+// client after it when limits are set, and with bodies no larger than the
+// route allows. Requests to routes requiring more than the read scope are
+// audited. The airports both upserts ingest are taken from the client's
+// quota, and the ones the streaming upsert ingests must be uploaded fast
+// enough. API key routes are only set up along with authentication.
+func initializeRoutes(c *Config, router *mux.Router) {
+	airportsHandler := airports.NewHandlers(c.Store, c.Index, c.UpsertLimits)
+	snapshotsHandler := snapshotshandlers.NewHandlers(c.Store, c.Snapshots, c.Index)
+	handle := func(scope auth.Scope, maxBodyBytes int64, h http.HandlerFunc) http.Handler {
+		var handler http.Handler = h
+		if maxBodyBytes > 0 {
+			handler = middleware.MaxBodySize(maxBodyBytes, handler)
+		}
+		if c.RateLimiter != nil {
+			handler = middleware.RateLimit(c.RateLimiter, handler)
+		}
+		if c.Authenticator != nil {
+			handler = middleware.Authorize(c.Authenticator, scope, handler)
+		}
+		if c.IPRateLimiter != nil {
+			handler = middleware.IPRateLimit(c.IPRateLimiter, handler)
+		}
+		if scope == auth.ScopeAirportsRead {
+			return handler
+		}
+		return middleware.Audit(c.Log, handler)
+	}
+	authorize := func(scope auth.Scope, h http.HandlerFunc) http.Handler {
+		return handle(scope, c.BodyLimits.Default, h)
+	}
+	quota := func(h http.HandlerFunc) http.HandlerFunc {
+		if c.IngestQuota != nil {
+			h = middleware.IngestQuota(c.IngestQuota, h).ServeHTTP
+		}
+		return h
+	}
+	stream := func(h http.HandlerFunc) http.HandlerFunc {
+		if c.MinUploadRate > 0 {
+			h = middleware.MinThroughput(c.MinUploadRate, c.MinUploadRateWindow, h).ServeHTTP
+		}
+		return quota(h)
+	}
+	var (
+		read  = auth.ScopeAirportsRead
+		write = auth.ScopeAirportsWrite
+		admin = auth.ScopeAdmin
+	)
 	apiRouter := router.PathPrefix("/api/v1").Subrouter()
-	apiRouter.HandleFunc("/airports", airportsHandler.HandleUpsert).Methods(http.MethodPost)
-	apiRouter.HandleFunc("/nonstreaming/airports", airportsHandler.HandleNonStreamingUpsert).Methods(http.MethodPost)
+	apiRouter.Handle("/airports", handle(write, c.BodyLimits.Upsert, stream(airportsHandler.HandleUpsert))).Methods(http.MethodPost)
+	apiRouter.Handle("/airports", authorize(read, airportsHandler.HandleList)).Methods(http.MethodGet)
+	apiRouter.Handle("/airports/autocomplete", authorize(read, airportsHandler.HandleAutocomplete)).Methods(http.MethodGet)
+	apiRouter.Handle("/airports/match", authorize(read, airportsHandler.HandleMatch)).Methods(http.MethodGet)
+	apiRouter.Handle("/airports/search", authorize(read, airportsHandler.HandleSearch)).Methods(http.MethodGet)
+	apiRouter.Handle("/airports/{iata_code:[A-Za-z]{3}}", authorize(read, airportsHandler.HandleGet)).Methods(http.MethodGet)
+	apiRouter.Handle("/airports/{iata_code:[A-Za-z]{3}}", authorize(write, airportsHandler.HandleDelete)).Methods(http.MethodDelete)
+	apiRouter.Handle("/nonstreaming/airports", handle(write, c.BodyLimits.NonStreamingUpsert, quota(airportsHandler.HandleNonStreamingUpsert))).Methods(http.MethodPost)
+	apiRouter.Handle("/admin/airports/duplicates", authorize(admin, airportsHandler.HandleDuplicatesReport)).Methods(http.MethodGet)
+	apiRouter.Handle("/admin/backup", authorize(admin, airportsHandler.HandleBackup)).Methods(http.MethodGet)
+	apiRouter.Handle("/snapshots", authorize(admin, snapshotsHandler.HandleCreate)).Methods(http.MethodPost)
+	apiRouter.Handle("/snapshots", authorize(admin, snapshotsHandler.HandleList)).Methods(http.MethodGet)
+	apiRouter.Handle("/snapshots/{id:"+snapshots.IDPattern+"}", authorize(admin, snapshotsHandler.HandleDelete)).Methods(http.MethodDelete)
+	apiRouter.Handle("/snapshots/{id:"+snapshots.IDPattern+"}/diff", authorize(admin, snapshotsHandler.HandleDiff)).Methods(http.MethodGet)
+	apiRouter.Handle("/snapshots/{id:"+snapshots.IDPattern+"}/rollback", authorize(admin, snapshotsHandler.HandleRollback)).Methods(http.MethodPost)
+	if c.Authenticator == nil || c.APIKeys == nil {
+		return
+	}
+	apiKeysHandler := apikeyshandlers.NewHandlers(c.APIKeys)
+	apiRouter.Handle("/admin/api-keys", authorize(admin, apiKeysHandler.HandleCreate)).Methods(http.MethodPost)
+	apiRouter.Handle("/admin/api-keys", authorize(admin, apiKeysHandler.HandleList)).Methods(http.MethodGet)
+	apiRouter.Handle("/admin/api-keys/{id:"+apikeys.IDPattern+"}", authorize(admin, apiKeysHandler.HandleRevoke)).Methods(http.MethodDelete)
 }
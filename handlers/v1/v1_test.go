@@ -6,38 +6,68 @@ package v1_test
 
 import (
 	"bytes"
-	"database/sql"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
+	"github.com/tiagomelo/go-airports-service/apikeys"
+	"github.com/tiagomelo/go-airports-service/autocomplete"
 	"github.com/tiagomelo/go-airports-service/db"
 	"github.com/tiagomelo/go-airports-service/db/airports"
 	"github.com/tiagomelo/go-airports-service/handlers"
+	"github.com/tiagomelo/go-airports-service/middleware"
+	"github.com/tiagomelo/go-airports-service/ratelimit"
+	"github.com/tiagomelo/go-airports-service/snapshots"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 var (
-	testDb     *sql.DB
+	testDb     *db.SqlitePools
 	testServer *httptest.Server
 )
 
 func TestMain(m *testing.M) {
 	const sqliteDbFile = "../../db/airportsRestApiTest.db"
+	if _, err := db.MigrateSqlite(db.DefaultSqliteConfig(sqliteDbFile), db.MigrateAuto); err != nil {
+		// the schema needs FTS5, so without the sqlite_fts5 build tag these
+		// tests fail here, asking for it.
+		fmt.Println("error when migrating the test database:", err)
+		os.Remove(sqliteDbFile)
+		os.Exit(1)
+	}
 	var err error
-	testDb, err = db.ConnectToSqlite(sqliteDbFile)
+	testDb, err = db.ConnectToSqlite(db.DefaultSqliteConfig(sqliteDbFile))
 	if err != nil {
 		fmt.Println("error when connecting to the test database:", err)
 		os.Exit(1)
 	}
+	snapshotsDir, err := os.MkdirTemp("", "snapshots")
+	if err != nil {
+		fmt.Println("error when creating the test snapshots directory:", err)
+		os.Exit(1)
+	}
+	defer os.RemoveAll(snapshotsDir)
+	snapshotStore, err := snapshots.NewStore(snapshotsDir)
+	if err != nil {
+		fmt.Println("error when opening the test snapshots store:", err)
+		os.Exit(1)
+	}
 	log := slog.New(slog.NewJSONHandler(os.Stdout, nil))
 	apiMux := handlers.NewApiMux(&handlers.ApiMuxConfig{
-		Db:  testDb,
-		Log: log,
+		Store:     airports.NewSqliteStore(testDb.Writer, testDb.Reader),
+		Index:     autocomplete.NewIndex(),
+		Snapshots: snapshotStore,
+		Log:       log,
 	})
 	testServer = httptest.NewServer(apiMux)
 	defer testServer.Close()
@@ -100,7 +130,7 @@ func TestHandleUpsert(t *testing.T) {
 			require.Equal(t, tc.expectedStatus, resp.StatusCode)
 			require.JSONEq(t, string(expectedOutput), string(body))
 
-			rows, err := testDb.Query("SELECT iata_code FROM airports")
+			rows, err := testDb.Reader.Query("SELECT iata_code FROM airports")
 			require.NoError(t, err)
 			defer rows.Close()
 
@@ -116,3 +146,448 @@ func TestHandleUpsert(t *testing.T) {
 		})
 	}
 }
+
+func TestHandleUpsertRepeatedNames(t *testing.T) {
+	input := `[{"name": "Repeated Names Intl", "city": "Test", "country": "Test", "iata_code": "RNI", "names": [
+		{"lang": "fr", "value": "Répété"},
+		{"lang": "fr", "value": "Répété", "type": "alias"}
+	]}]`
+	for _, path := range []string{"/api/v1/airports", "/api/v1/nonstreaming/airports"} {
+		resp, err := http.Post(testServer.URL+path, "application/json", bytes.NewBufferString(input))
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode, path)
+
+		var count int
+		require.NoError(t, testDb.Reader.QueryRow("SELECT COUNT(*) FROM airport_names WHERE iata_code = 'RNI'").Scan(&count))
+		require.Equal(t, 1, count, path)
+	}
+}
+
+func TestHandleUpsertFailures(t *testing.T) {
+	// airports with the FAI IATA code fail to be upserted.
+	_, err := testDb.Writer.Exec(`CREATE TRIGGER reject_fai BEFORE INSERT ON airports WHEN NEW.iata_code = 'FAI' BEGIN SELECT RAISE(ABORT, 'rejected'); END`)
+	require.NoError(t, err)
+	defer func() {
+		_, err := testDb.Writer.Exec("DROP TRIGGER reject_fai")
+		require.NoError(t, err)
+	}()
+	airport := func(iataCode string) map[string]string {
+		return map[string]string{"name": "Failure " + iataCode, "city": "Test", "country": "Test", "iata_code": iataCode}
+	}
+	// stored reports whether the given airport is stored.
+	stored := func(iataCode string) bool {
+		var found int
+		require.NoError(t, testDb.Reader.QueryRow("SELECT COUNT(*) FROM airports WHERE iata_code = ?", iataCode).Scan(&found))
+		return found == 1
+	}
+	// the streaming endpoint upserts airports in batches of 500, so the
+	// batch the airport that failed belongs to is rolled back, but the
+	// ones before it are kept.
+	var firstBatch []string
+	for i := 0; len(firstBatch) < 500; i++ {
+		firstBatch = append(firstBatch, string([]byte{'Q', byte('A' + i/26), byte('A' + i%26)}))
+	}
+	var input []map[string]string
+	for _, iataCode := range firstBatch {
+		input = append(input, airport(iataCode))
+	}
+	input = append(input, airport("XAA"), airport("FAI"), airport("XBA"))
+	b, err := json.Marshal(input)
+	require.NoError(t, err)
+	resp, err := http.Post(testServer.URL+"/api/v1/airports", "application/json", bytes.NewBuffer(b))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	for _, iataCode := range firstBatch {
+		require.True(t, stored(iataCode), iataCode)
+	}
+	require.False(t, stored("XAA"))
+	require.False(t, stored("FAI"))
+	require.False(t, stored("XBA"))
+
+	// the non-streaming endpoint upserts airports one at a time, so the
+	// ones before the airport that failed are kept, and the ones after it
+	// are not upserted.
+	b, err = json.Marshal([]map[string]string{airport("XAB"), airport("FAI"), airport("XBB")})
+	require.NoError(t, err)
+	resp, err = http.Post(testServer.URL+"/api/v1/nonstreaming/airports", "application/json", bytes.NewBuffer(b))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	require.True(t, stored("XAB"))
+	require.False(t, stored("FAI"))
+	require.False(t, stored("XBB"))
+}
+
+func TestHandleUpsertDryRun(t *testing.T) {
+	input := `[{"name": "Dry Run Intl", "city": "Nowhere", "country": "Nowhere", "iata_code": "ZZY"}]`
+	for _, path := range []string{"/api/v1/airports", "/api/v1/nonstreaming/airports"} {
+		t.Run(path, func(t *testing.T) {
+			resp, err := http.Post(testServer.URL+path+"?dry_run=true", "application/json", bytes.NewBufferString(input))
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			require.JSONEq(t, `{"added":[{"name":"Dry Run Intl","city":"Nowhere","country":"Nowhere","iata_code":"ZZY"}],"changed":[],"unchanged":0}`, string(body))
+
+			var count int
+			require.NoError(t, testDb.Reader.QueryRow("SELECT COUNT(*) FROM airports WHERE iata_code = 'ZZY'").Scan(&count))
+			require.Zero(t, count)
+		})
+	}
+}
+
+func TestHandleBackup(t *testing.T) {
+	resp, err := http.Get(testServer.URL + "/api/v1/admin/backup")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "application/vnd.sqlite3", resp.Header.Get("Content-Type"))
+
+	dir := t.TempDir()
+	backupPath := filepath.Join(dir, "backup.db")
+	f, err := os.Create(backupPath)
+	require.NoError(t, err)
+	_, err = io.Copy(f, resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	restoredPath := filepath.Join(dir, "restored.db")
+	version, previous, err := db.RestoreSqlite(backupPath, restoredPath)
+	require.NoError(t, err)
+	require.Equal(t, uint(5), version)
+	require.Empty(t, previous)
+
+	restored, err := db.ConnectToSqlite(db.DefaultSqliteConfig(restoredPath))
+	require.NoError(t, err)
+	defer restored.Close()
+	var expectedCount, count int
+	require.NoError(t, testDb.Reader.QueryRow("SELECT COUNT(*) FROM airports").Scan(&expectedCount))
+	require.NoError(t, restored.Reader.QueryRow("SELECT COUNT(*) FROM airports").Scan(&count))
+	require.Equal(t, expectedCount, count)
+}
+
+func TestSnapshots(t *testing.T) {
+	resp, err := http.Post(testServer.URL+"/api/v1/snapshots", "application/json", nil)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var snapshot snapshots.Snapshot
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&snapshot))
+
+	input := `[{"name": "Snapshot Test Intl", "city": "Nowhere", "country": "Nowhere", "iata_code": "ZZZ"}]`
+	resp, err = http.Post(testServer.URL+"/api/v1/airports", "application/json", bytes.NewBufferString(input))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = http.Get(testServer.URL + "/api/v1/snapshots/" + snapshot.ID + "/diff")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var diff airports.Diff
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&diff))
+	require.Len(t, diff.Added, 1)
+	require.Equal(t, "ZZZ", diff.Added[0].IataCode)
+	require.Empty(t, diff.Removed)
+	require.Empty(t, diff.Changed)
+	require.Equal(t, snapshot.Airports, diff.Unchanged)
+
+	resp, err = http.Post(testServer.URL+"/api/v1/snapshots/"+snapshot.ID+"/rollback", "application/json", nil)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = http.Get(testServer.URL + "/api/v1/airports/ZZZ")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	req, err := http.NewRequest(http.MethodDelete, testServer.URL+"/api/v1/snapshots/"+snapshot.ID, nil)
+	require.NoError(t, err)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestRequestID(t *testing.T) {
+	testCases := []struct {
+		name         string
+		requestID    string
+		expectEchoed bool
+	}{
+		{name: "sent by the client", requestID: "6f1c0e52-8f1e-4c1a-9d43-2f4b8f3c7a10", expectEchoed: true},
+		{name: "generated"},
+		{name: "invalid one replaced", requestID: "not valid"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, testServer.URL+"/api/v1/airports/ZZX", nil)
+			require.NoError(t, err)
+			if tc.requestID != "" {
+				req.Header.Set("X-Request-ID", tc.requestID)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+			id := resp.Header.Get("X-Request-ID")
+			require.NotEmpty(t, id)
+			if tc.expectEchoed {
+				require.Equal(t, tc.requestID, id)
+			} else {
+				require.NotEqual(t, tc.requestID, id)
+			}
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			require.JSONEq(t, `{"error":"airport not found","request_id":"`+id+`"}`, string(body))
+		})
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	resp, err := http.Get(testServer.URL + "/api/v1/airports/ZZW")
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	resp, err = http.Get(testServer.URL + "/metrics")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Contains(t, string(body), `http_requests_total{method="GET",route="/api/v1/airports/{iata_code:[A-Za-z]{3}}",status="404"}`)
+	require.Contains(t, string(body), "airports_upserted_per_request_count")
+}
+
+func TestTracing(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	originalProvider := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(originalProvider)
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)))
+
+	body := `[
+		{"name": "Tracing One", "city": "Nowhere", "country": "Nowhere", "iata_code": "ZTA"},
+		{"name": "Tracing Two", "city": "Nowhere", "country": "Nowhere", "iata_code": "ZTB"}
+	]`
+	resp, err := http.Post(testServer.URL+"/api/v1/airports", "application/json", bytes.NewBufferString(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// the server span ends once the response is written, which may be
+	// after the client has read it.
+	spansByName := func() map[string][]sdktrace.ReadOnlySpan {
+		spans := map[string][]sdktrace.ReadOnlySpan{}
+		for _, span := range sr.Ended() {
+			spans[span.Name()] = append(spans[span.Name()], span)
+		}
+		return spans
+	}
+	require.Eventually(t, func() bool {
+		return len(spansByName()["POST /api/v1/airports"]) == 1
+	}, time.Second, 10*time.Millisecond)
+	spans := spansByName()
+	server := spans["POST /api/v1/airports"][0]
+	require.Len(t, spans["decode airport"], 2)
+	for _, span := range spans["decode airport"] {
+		require.Equal(t, server.SpanContext().SpanID(), span.Parent().SpanID())
+	}
+	require.Len(t, spans["upsert batch"], 1)
+	batch := spans["upsert batch"][0]
+	require.Equal(t, server.SpanContext().SpanID(), batch.Parent().SpanID())
+	require.Len(t, spans["upsert airports"], 2)
+	for _, span := range spans["upsert airports"] {
+		require.Equal(t, batch.SpanContext().SpanID(), span.Parent().SpanID())
+		require.Equal(t, server.SpanContext().TraceID(), span.SpanContext().TraceID())
+	}
+}
+
+func TestAuthentication(t *testing.T) {
+	const bootstrapKey = "bootstrap-secret"
+	keys := apikeys.NewSQLStore(testDb.Writer, testDb.Reader)
+	server := httptest.NewServer(handlers.NewApiMux(&handlers.ApiMuxConfig{
+		Store:         airports.NewSqliteStore(testDb.Writer, testDb.Reader),
+		Index:         autocomplete.NewIndex(),
+		Log:           slog.New(slog.NewJSONHandler(io.Discard, nil)),
+		Authenticator: apikeys.NewAuthenticator(keys, bootstrapKey),
+		APIKeys:       keys,
+	}))
+	defer server.Close()
+
+	do := func(method, path, apiKey, body string) (int, []byte) {
+		req, err := http.NewRequest(method, server.URL+path, bytes.NewBufferString(body))
+		require.NoError(t, err)
+		if apiKey != "" {
+			req.Header.Set(apikeys.Header, apiKey)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, b
+	}
+
+	status, _ := do(http.MethodGet, "/api/v1/airports", "", "")
+	require.Equal(t, http.StatusUnauthorized, status)
+	status, _ = do(http.MethodGet, "/api/v1/airports", "ak_unknown", "")
+	require.Equal(t, http.StatusUnauthorized, status)
+
+	status, body := do(http.MethodPost, "/api/v1/admin/api-keys", bootstrapKey, `{"name": "dashboard", "scopes": ["airports:read"]}`)
+	require.Equal(t, http.StatusCreated, status)
+	var created struct {
+		ID  string `json:"id"`
+		Key string `json:"key"`
+	}
+	require.NoError(t, json.Unmarshal(body, &created))
+
+	status, _ = do(http.MethodGet, "/api/v1/airports", created.Key, "")
+	require.Equal(t, http.StatusOK, status)
+	status, body = do(http.MethodPost, "/api/v1/airports", created.Key, "[]")
+	require.Equal(t, http.StatusForbidden, status)
+	require.Contains(t, string(body), "missing scope airports:write")
+	status, _ = do(http.MethodGet, "/api/v1/admin/api-keys", created.Key, "")
+	require.Equal(t, http.StatusForbidden, status)
+
+	status, body = do(http.MethodGet, "/api/v1/admin/api-keys", bootstrapKey, "")
+	require.Equal(t, http.StatusOK, status)
+	require.Contains(t, string(body), created.ID)
+	require.NotContains(t, string(body), created.Key)
+
+	status, _ = do(http.MethodDelete, "/api/v1/admin/api-keys/"+created.ID, bootstrapKey, "")
+	require.Equal(t, http.StatusOK, status)
+	status, _ = do(http.MethodGet, "/api/v1/airports", created.Key, "")
+	require.Equal(t, http.StatusUnauthorized, status)
+	status, _ = do(http.MethodDelete, "/api/v1/admin/api-keys/"+created.ID, bootstrapKey, "")
+	require.Equal(t, http.StatusNotFound, status)
+
+	// metrics require the admin scope.
+	status, _ = do(http.MethodGet, "/metrics", "", "")
+	require.Equal(t, http.StatusUnauthorized, status)
+	status, _ = do(http.MethodGet, "/metrics", bootstrapKey, "")
+	require.Equal(t, http.StatusOK, status)
+}
+
+func TestRateLimit(t *testing.T) {
+	server := httptest.NewServer(handlers.NewApiMux(&handlers.ApiMuxConfig{
+		Store:       airports.NewSqliteStore(testDb.Writer, testDb.Reader),
+		Index:       autocomplete.NewIndex(),
+		Log:         slog.New(slog.NewJSONHandler(io.Discard, nil)),
+		RateLimiter: ratelimit.NewLimiter(1, 3),
+		IngestQuota: ratelimit.NewLimiter(2.0/60, 2),
+	}))
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/api/v1/airports", "application/json", bytes.NewBufferString(`[
+		{"name": "Rate Limit A", "city": "Test", "country": "Test", "iata_code": "RLA"},
+		{"name": "Rate Limit B", "city": "Test", "country": "Test", "iata_code": "RLB"},
+		{"name": "Rate Limit C", "city": "Test", "country": "Test", "iata_code": "RLC"}
+	]`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	require.Equal(t, "30", resp.Header.Get("Retry-After"))
+
+	for iataCode, expectedStatus := range map[string]int{"RLB": http.StatusOK, "RLC": http.StatusNotFound} {
+		resp, err := http.Get(server.URL + "/api/v1/airports/" + iataCode)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, expectedStatus, resp.StatusCode, iataCode)
+		require.NotEmpty(t, resp.Header.Get("RateLimit-Remaining"))
+	}
+	resp, err = http.Get(server.URL + "/api/v1/airports/RLA")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	require.Equal(t, "1", resp.Header.Get("Retry-After"))
+}
+
+func TestIPRateLimit(t *testing.T) {
+	keys := apikeys.NewSQLStore(testDb.Writer, testDb.Reader)
+	server := httptest.NewServer(handlers.NewApiMux(&handlers.ApiMuxConfig{
+		Store:         airports.NewSqliteStore(testDb.Writer, testDb.Reader),
+		Index:         autocomplete.NewIndex(),
+		Log:           slog.New(slog.NewJSONHandler(io.Discard, nil)),
+		Authenticator: apikeys.NewAuthenticator(keys, "bootstrap-secret"),
+		APIKeys:       keys,
+		IPRateLimiter: ratelimit.NewLimiter(1, 2),
+	}))
+	defer server.Close()
+
+	// requests with invalid credentials are limited before being denied.
+	for _, expectedStatus := range []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests} {
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/api/v1/airports", nil)
+		require.NoError(t, err)
+		req.Header.Set(apikeys.Header, "ak_guessed")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, expectedStatus, resp.StatusCode)
+	}
+}
+
+func TestCORS(t *testing.T) {
+	server := httptest.NewServer(handlers.NewApiMux(&handlers.ApiMuxConfig{
+		Store: airports.NewSqliteStore(testDb.Writer, testDb.Reader),
+		Index: autocomplete.NewIndex(),
+		Log:   slog.New(slog.NewJSONHandler(io.Discard, nil)),
+		CORS: &middleware.CORSOptions{
+			AllowedOrigins: []string{"https://admin.example.com"},
+			AllowedMethods: []string{http.MethodGet, http.MethodPost, http.MethodDelete},
+			AllowedHeaders: []string{"Content-Type"},
+			ExposedHeaders: []string{"X-Request-ID"},
+		},
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodOptions, server.URL+"/api/v1/airports/GRU", nil)
+	require.NoError(t, err)
+	req.Header.Set("Origin", "https://admin.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodDelete)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	require.Equal(t, "https://admin.example.com", resp.Header.Get("Access-Control-Allow-Origin"))
+	require.Equal(t, "GET, POST, DELETE", resp.Header.Get("Access-Control-Allow-Methods"))
+
+	req, err = http.NewRequest(http.MethodGet, server.URL+"/api/v1/airports/XXX", nil)
+	require.NoError(t, err)
+	req.Header.Set("Origin", "https://admin.example.com")
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	require.Equal(t, "https://admin.example.com", resp.Header.Get("Access-Control-Allow-Origin"))
+	require.Equal(t, "X-Request-ID", resp.Header.Get("Access-Control-Expose-Headers"))
+
+	req, err = http.NewRequest(http.MethodOptions, server.URL+"/api/v1/unknown", nil)
+	require.NoError(t, err)
+	req.Header.Set("Origin", "https://admin.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	require.Empty(t, resp.Header.Get("Access-Control-Allow-Origin"))
+
+	req, err = http.NewRequest(http.MethodOptions, server.URL+"/api/v1/airports", nil)
+	require.NoError(t, err)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+	require.Equal(t, "GET, POST", resp.Header.Get("Allow"))
+
+	req, err = http.NewRequest(http.MethodOptions, server.URL+"/api/v1/unknown", nil)
+	require.NoError(t, err)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
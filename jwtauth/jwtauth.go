@@ -0,0 +1,122 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+// Package jwtauth authenticates callers by the JWT bearer tokens issued by
+// an OpenID Connect provider, verified against the keys it publishes in a
+// JWKS document.
+package jwtauth
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/tiagomelo/go-airports-service/auth"
+)
+
+const (
+	// DefaultScopesClaim is the claim scopes are read from by default.
+	DefaultScopesClaim = "scope"
+	// leeway is the clock skew tolerated when checking the times a token
+	// is valid between.
+	leeway = 30 * time.Second
+)
+
+// Config holds the claims tokens must carry and where scopes are read
+// from.
+type Config struct {
+	// Issuer, if not empty, is the iss claim tokens must carry.
+	Issuer string
+	// Audience, if not empty, is one of the aud claims tokens must carry.
+	Audience string
+	// ScopesClaim is the claim scopes are read from, either as a
+	// space-separated string or an array of strings.
+	ScopesClaim string
+}
+
+// Authenticator authenticates requests by the JWT bearer token in their
+// Authorization header. Only RS256 and ES256 signed tokens with an
+// expiration time and a subject are accepted.
+type Authenticator struct {
+	keys        *KeySet
+	parser      *jwt.Parser
+	scopesClaim string
+}
+
+// NewAuthenticator creates an authenticator verifying tokens with the
+// keys of the given key set.
+func NewAuthenticator(keys *KeySet, c Config) *Authenticator {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg()}),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(leeway),
+		jwt.WithTimeFunc(func() time.Time { return now() }),
+	}
+	if c.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(c.Issuer))
+	}
+	if c.Audience != "" {
+		opts = append(opts, jwt.WithAudience(c.Audience))
+	}
+	scopesClaim := c.ScopesClaim
+	if scopesClaim == "" {
+		scopesClaim = DefaultScopesClaim
+	}
+	return &Authenticator{
+		keys:        keys,
+		parser:      jwt.NewParser(opts...),
+		scopesClaim: scopesClaim,
+	}
+}
+
+// Authenticate resolves the request's bearer token to an identity whose
+// subject is jwt:<sub>, granted the scopes the token carries that the
+// service knows of. Others, such as openid or profile, are ignored.
+// Tokens that cannot be verified are rejected with
+// auth.ErrInvalidCredentials, along with the reason why.
+func (a *Authenticator) Authenticate(r *http.Request) (*auth.Identity, error) {
+	header := r.Header.Get("Authorization")
+	const prefix = "Bearer "
+	if len(header) <= len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		return nil, auth.ErrNoCredentials
+	}
+	claims := jwt.MapClaims{}
+	_, err := a.parser.ParseWithClaims(header[len(prefix):], claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return a.keys.Key(r.Context(), kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", auth.ErrInvalidCredentials, err)
+	}
+	subject, err := claims.GetSubject()
+	if err != nil || subject == "" {
+		return nil, fmt.Errorf("%w: token has no subject", auth.ErrInvalidCredentials)
+	}
+	return &auth.Identity{Subject: "jwt:" + subject, Scopes: scopes(claims[a.scopesClaim])}, nil
+}
+
+// scopes returns the known scopes in the given claim, either a
+// space-separated string or an array of strings.
+func scopes(claim any) []auth.Scope {
+	var names []string
+	switch v := claim.(type) {
+	case string:
+		names = strings.Fields(v)
+	case []any:
+		for _, name := range v {
+			if s, ok := name.(string); ok {
+				names = append(names, s)
+			}
+		}
+	}
+	known := []auth.Scope{}
+	for _, name := range names {
+		if s, err := auth.ParseScopes([]string{name}); err == nil {
+			known = append(known, s...)
+		}
+	}
+	return known
+}
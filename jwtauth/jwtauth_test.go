@@ -0,0 +1,200 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package jwtauth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+	"github.com/tiagomelo/go-airports-service/auth"
+)
+
+// sign returns a token with the given claims, signed with the given
+// method and key and carrying the given key ID, if any.
+func sign(t *testing.T, method jwt.SigningMethod, key any, kid string, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func TestAuthenticate(t *testing.T) {
+	originalNow := now
+	defer func() {
+		now = originalNow
+	}()
+	issuedAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	now = func() time.Time {
+		return issuedAt.Add(time.Minute)
+	}
+	keys, err := NewKeySet(context.TODO(), writeJWKS(t,
+		rsaJWK("rsa", &testKeys.rsa.PublicKey),
+		ecJWK("ec", &testKeys.ec.PublicKey),
+	), time.Hour, discardLog)
+	require.NoError(t, err)
+	authenticator := NewAuthenticator(keys, Config{Issuer: "https://sso.example.com", Audience: "airports-service"})
+
+	// claims returns valid claims, overridden by the given ones.
+	claims := func(overrides jwt.MapClaims) jwt.MapClaims {
+		c := jwt.MapClaims{
+			"iss":   "https://sso.example.com",
+			"aud":   []string{"airports-service", "other-service"},
+			"sub":   "alice",
+			"iat":   issuedAt.Unix(),
+			"exp":   issuedAt.Add(time.Hour).Unix(),
+			"scope": "openid airports:read airports:write",
+		}
+		for k, v := range overrides {
+			if v == nil {
+				delete(c, k)
+				continue
+			}
+			c[k] = v
+		}
+		return c
+	}
+	testCases := []struct {
+		name             string
+		authorization    string
+		expectedIdentity *auth.Identity
+		expectedError    error
+		expectedMessage  string
+	}{
+		{
+			name:          "RS256",
+			authorization: "Bearer " + sign(t, jwt.SigningMethodRS256, testKeys.rsa, "rsa", claims(nil)),
+			expectedIdentity: &auth.Identity{
+				Subject: "jwt:alice",
+				Scopes:  []auth.Scope{auth.ScopeAirportsRead, auth.ScopeAirportsWrite},
+			},
+		},
+		{
+			name:          "ES256 with scopes array",
+			authorization: "bearer " + sign(t, jwt.SigningMethodES256, testKeys.ec, "ec", claims(jwt.MapClaims{"scope": []string{"admin", "profile"}})),
+			expectedIdentity: &auth.Identity{
+				Subject: "jwt:alice",
+				Scopes:  []auth.Scope{auth.ScopeAdmin},
+			},
+		},
+		{
+			name:          "no scopes",
+			authorization: "Bearer " + sign(t, jwt.SigningMethodRS256, testKeys.rsa, "rsa", claims(jwt.MapClaims{"scope": nil})),
+			expectedIdentity: &auth.Identity{
+				Subject: "jwt:alice",
+				Scopes:  []auth.Scope{},
+			},
+		},
+		{
+			name:          "no token",
+			expectedError: auth.ErrNoCredentials,
+		},
+		{
+			name:          "other scheme",
+			authorization: "Basic YWxpY2U6c2VjcmV0",
+			expectedError: auth.ErrNoCredentials,
+		},
+		{
+			name:            "malformed token",
+			authorization:   "Bearer not-a-token",
+			expectedError:   auth.ErrInvalidCredentials,
+			expectedMessage: "invalid credentials: token is malformed: token contains an invalid number of segments",
+		},
+		{
+			name:            "expired",
+			authorization:   "Bearer " + sign(t, jwt.SigningMethodRS256, testKeys.rsa, "rsa", claims(jwt.MapClaims{"exp": issuedAt.Add(-time.Minute).Unix()})),
+			expectedError:   auth.ErrInvalidCredentials,
+			expectedMessage: "invalid credentials: token has invalid claims: token is expired",
+		},
+		{
+			name:            "no expiration",
+			authorization:   "Bearer " + sign(t, jwt.SigningMethodRS256, testKeys.rsa, "rsa", claims(jwt.MapClaims{"exp": nil})),
+			expectedError:   auth.ErrInvalidCredentials,
+			expectedMessage: "invalid credentials: token has invalid claims: token is missing required claim: exp claim is required",
+		},
+		{
+			name:            "wrong issuer",
+			authorization:   "Bearer " + sign(t, jwt.SigningMethodRS256, testKeys.rsa, "rsa", claims(jwt.MapClaims{"iss": "https://evil.example.com"})),
+			expectedError:   auth.ErrInvalidCredentials,
+			expectedMessage: "invalid credentials: token has invalid claims: token has invalid issuer",
+		},
+		{
+			name:            "wrong audience",
+			authorization:   "Bearer " + sign(t, jwt.SigningMethodRS256, testKeys.rsa, "rsa", claims(jwt.MapClaims{"aud": "other-service"})),
+			expectedError:   auth.ErrInvalidCredentials,
+			expectedMessage: "invalid credentials: token has invalid claims: token has invalid audience",
+		},
+		{
+			name:            "no subject",
+			authorization:   "Bearer " + sign(t, jwt.SigningMethodRS256, testKeys.rsa, "rsa", claims(jwt.MapClaims{"sub": nil})),
+			expectedError:   auth.ErrInvalidCredentials,
+			expectedMessage: "invalid credentials: token has no subject",
+		},
+		{
+			name:            "unknown key",
+			authorization:   "Bearer " + sign(t, jwt.SigningMethodRS256, testKeys.rsa, "rotated", claims(nil)),
+			expectedError:   auth.ErrInvalidCredentials,
+			expectedMessage: `invalid credentials: token is unverifiable: error while executing keyfunc: unknown signing key "rotated"`,
+		},
+		{
+			name:            "signed with another key",
+			authorization:   "Bearer " + sign(t, jwt.SigningMethodRS256, mustGenerateRSAKey(), "rsa", claims(nil)),
+			expectedError:   auth.ErrInvalidCredentials,
+			expectedMessage: "invalid credentials: token signature is invalid: crypto/rsa: verification error",
+		},
+		{
+			name:            "HS256 rejected",
+			authorization:   "Bearer " + sign(t, jwt.SigningMethodHS256, []byte("secret"), "rsa", claims(nil)),
+			expectedError:   auth.ErrInvalidCredentials,
+			expectedMessage: "invalid credentials: token signature is invalid: signing method HS256 is invalid",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/airports", nil)
+			if tc.authorization != "" {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+			id, err := authenticator.Authenticate(req)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf(`expected no error, got "%v"`, err)
+				}
+				require.ErrorIs(t, err, tc.expectedError)
+				if tc.expectedMessage != "" {
+					require.Equal(t, tc.expectedMessage, err.Error())
+				}
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf(`expected error "%v", got nil`, tc.expectedError)
+				}
+				require.Equal(t, tc.expectedIdentity, id)
+			}
+		})
+	}
+}
+
+func TestAuthenticateScopesClaim(t *testing.T) {
+	keys, err := NewKeySet(context.TODO(), writeJWKS(t, ecJWK("ec", &testKeys.ec.PublicKey)), time.Hour, discardLog)
+	require.NoError(t, err)
+	authenticator := NewAuthenticator(keys, Config{ScopesClaim: "roles"})
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/airports", nil)
+	req.Header.Set("Authorization", "Bearer "+sign(t, jwt.SigningMethodES256, testKeys.ec, "", jwt.MapClaims{
+		"sub":   "feed-importer",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"scope": "admin",
+		"roles": []string{"airports:write"},
+	}))
+	id, err := authenticator.Authenticate(req)
+	require.NoError(t, err)
+	require.Equal(t, &auth.Identity{Subject: "jwt:feed-importer", Scopes: []auth.Scope{auth.ScopeAirportsWrite}}, id)
+}
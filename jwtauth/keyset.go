@@ -0,0 +1,254 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package jwtauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// maxJWKSSize is the maximum size of a JWKS document.
+	maxJWKSSize = 1 << 20
+	// minRefreshInterval is how long the key set waits between reloads
+	// triggered by tokens signed with unknown keys, so that such tokens
+	// cannot hammer the JWKS source.
+	minRefreshInterval = time.Minute
+)
+
+// For ease of unit testing.
+var (
+	// now is a function that returns the current time.
+	now = time.Now
+)
+
+// jwk is a JSON Web Key, as defined by RFC 7517, holding an RSA or an
+// elliptic curve public key.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// KeySet holds the public keys tokens are verified with, loaded from a
+// JWKS document. It reloads them once they are older than the refresh
+// interval, and when a token is signed with an unknown key, so that keys
+// can be rotated.
+type KeySet struct {
+	source          string
+	client          *http.Client
+	refreshInterval time.Duration
+	log             *slog.Logger
+
+	mu        sync.RWMutex
+	keys      map[string]crypto.PublicKey
+	loaded    time.Time
+	attempted time.Time
+}
+
+// NewKeySet loads the key set from the given JWKS source, which is either
+// an http:// or https:// URL or a file path, logging the keys skipped
+// because they are unsupported or malformed.
+func NewKeySet(ctx context.Context, source string, refreshInterval time.Duration, log *slog.Logger) (*KeySet, error) {
+	s := &KeySet{
+		source:          source,
+		client:          &http.Client{Timeout: 10 * time.Second},
+		refreshInterval: refreshInterval,
+		log:             log,
+	}
+	if err := s.load(ctx); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Key returns the key with the given ID. Tokens without a key ID can only
+// be verified when the set holds a single key.
+func (s *KeySet) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	key, ok, stale, due := s.lookup(kid)
+	if due && (!ok || stale) {
+		// keys that could not be reloaded are kept.
+		if err := s.load(ctx); err == nil {
+			key, ok, _, _ = s.lookup(kid)
+		}
+	}
+	if !ok {
+		return nil, errors.Errorf("unknown signing key %q", kid)
+	}
+	return key, nil
+}
+
+// lookup returns the key with the given ID, if any, along with whether
+// keys are older than the refresh interval and whether they may be
+// reloaded already, that is, whether the last attempt to do so was long
+// enough ago.
+func (s *KeySet) lookup(kid string) (key crypto.PublicKey, ok, stale, due bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	t := now()
+	stale = t.Sub(s.loaded) > s.refreshInterval
+	due = t.Sub(s.attempted) > minRefreshInterval
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, true, stale, due
+		}
+	}
+	key, ok = s.keys[kid]
+	return key, ok, stale, due
+}
+
+// load reads and parses the JWKS document, replacing the keys held.
+func (s *KeySet) load(ctx context.Context) error {
+	s.mu.Lock()
+	s.attempted = now()
+	s.mu.Unlock()
+	data, err := s.read(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "reading JWKS from %s", s.source)
+	}
+	keys, skipped, err := parseJWKS(data)
+	for _, err := range skipped {
+		s.log.WarnContext(ctx, "skipping JWKS key", slog.String("source", s.source), slog.Any("err", err))
+	}
+	if err != nil {
+		return errors.Wrapf(err, "parsing JWKS from %s", s.source)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+	s.loaded = now()
+	return nil
+}
+
+// read returns the JWKS document.
+func (s *KeySet) read(ctx context.Context) ([]byte, error) {
+	if !strings.HasPrefix(s.source, "http://") && !strings.HasPrefix(s.source, "https://") {
+		return os.ReadFile(s.source)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.source, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected status %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxJWKSSize))
+}
+
+// parseJWKS parses the signing keys of a JWKS document, keyed by ID. Keys
+// meant for encryption or of unsupported types are skipped, and so are
+// unsupported or malformed RSA and EC keys, which are returned along with
+// why, so that a single bad key does not make the whole document unusable.
+// It fails when no key is left.
+func parseJWKS(data []byte) (map[string]crypto.PublicKey, []error, error) {
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, nil, err
+	}
+	keys := make(map[string]crypto.PublicKey, len(doc.Keys))
+	var skipped []error
+	for _, k := range doc.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		var (
+			key crypto.PublicKey
+			err error
+		)
+		switch k.Kty {
+		case "RSA":
+			key, err = k.rsaKey()
+		case "EC":
+			key, err = k.ecKey()
+		default:
+			continue
+		}
+		if err != nil {
+			skipped = append(skipped, errors.Wrapf(err, "key %q", k.Kid))
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, skipped, errors.New("no usable RSA or EC signing keys")
+	}
+	return keys, skipped, nil
+}
+
+// rsaKey returns the RSA public key the JWK holds.
+func (k jwk) rsaKey() (*rsa.PublicKey, error) {
+	n, err := decodeInt(k.N)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding modulus")
+	}
+	e, err := decodeInt(k.E)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding exponent")
+	}
+	if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+		return nil, errors.New("invalid exponent")
+	}
+	return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+}
+
+// ecKey returns the P-256 public key the JWK holds, the only curve ES256
+// signatures are made with.
+func (k jwk) ecKey() (*ecdsa.PublicKey, error) {
+	if k.Crv != "P-256" {
+		return nil, errors.Errorf("unsupported curve %q", k.Crv)
+	}
+	x, err := decodeInt(k.X)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding x")
+	}
+	y, err := decodeInt(k.Y)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding y")
+	}
+	curve := elliptic.P256()
+	if !curve.IsOnCurve(x, y) {
+		return nil, errors.New("point is not on curve P-256")
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+// decodeInt decodes a base64url-encoded big-endian unsigned integer.
+func decodeInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, errors.New("empty value")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
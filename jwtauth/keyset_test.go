@@ -0,0 +1,268 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package jwtauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// discardLog is the logger key sets log skipped keys to in tests.
+var discardLog = slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+// testKeys are the keys tests sign tokens with, generated once.
+var testKeys = struct {
+	rsa *rsa.PrivateKey
+	ec  *ecdsa.PrivateKey
+}{
+	rsa: mustGenerateRSAKey(),
+	ec:  mustGenerateECKey(),
+}
+
+func mustGenerateRSAKey() *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+func mustGenerateECKey() *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+// encodeInt base64url-encodes an unsigned integer.
+func encodeInt(i *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(i.Bytes())
+}
+
+// rsaJWK returns the JWK of the given RSA public key.
+func rsaJWK(kid string, key *rsa.PublicKey) jwk {
+	return jwk{Kty: "RSA", Kid: kid, Use: "sig", N: encodeInt(key.N), E: encodeInt(big.NewInt(int64(key.E)))}
+}
+
+// ecJWK returns the JWK of the given P-256 public key.
+func ecJWK(kid string, key *ecdsa.PublicKey) jwk {
+	return jwk{Kty: "EC", Kid: kid, Crv: "P-256", X: encodeInt(key.X), Y: encodeInt(key.Y)}
+}
+
+// jwks returns the JWKS document holding the given keys.
+func jwks(t *testing.T, keys ...jwk) []byte {
+	data, err := json.Marshal(map[string][]jwk{"keys": keys})
+	require.NoError(t, err)
+	return data
+}
+
+// writeJWKS writes the JWKS document holding the given keys to a file,
+// returning its path.
+func writeJWKS(t *testing.T, keys ...jwk) string {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, jwks(t, keys...), 0o600))
+	return path
+}
+
+func TestParseJWKS(t *testing.T) {
+	rsaKey, ecKey := rsaJWK("rsa", &testKeys.rsa.PublicKey), ecJWK("ec", &testKeys.ec.PublicKey)
+	offCurve := ecKey
+	offCurve.Y = encodeInt(big.NewInt(1))
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	p384 := jwk{Kty: "EC", Kid: "p384", Crv: "P-384", X: encodeInt(p384Key.X), Y: encodeInt(p384Key.Y)}
+	testCases := []struct {
+		name            string
+		input           []byte
+		expectedOutput  map[string]crypto.PublicKey
+		expectedSkipped []string
+		expectedError   string
+	}{
+		{
+			name:  "rsa and ec keys",
+			input: jwks(t, rsaKey, ecKey),
+			expectedOutput: map[string]crypto.PublicKey{
+				"rsa": &testKeys.rsa.PublicKey,
+				"ec":  &testKeys.ec.PublicKey,
+			},
+		},
+		{
+			name:           "encryption and unsupported keys skipped",
+			input:          jwks(t, rsaKey, jwk{Kty: "RSA", Kid: "enc", Use: "enc"}, jwk{Kty: "oct", Kid: "hmac"}),
+			expectedOutput: map[string]crypto.PublicKey{"rsa": &testKeys.rsa.PublicKey},
+		},
+		{
+			name:            "mixed P-256 and P-384 keys",
+			input:           jwks(t, p384, ecKey),
+			expectedOutput:  map[string]crypto.PublicKey{"ec": &testKeys.ec.PublicKey},
+			expectedSkipped: []string{`key "p384": unsupported curve "P-384"`},
+		},
+		{
+			name:            "malformed keys skipped",
+			input:           jwks(t, jwk{Kty: "RSA", Kid: "bad", N: "!", E: "AQAB"}, offCurve, rsaKey),
+			expectedOutput:  map[string]crypto.PublicKey{"rsa": &testKeys.rsa.PublicKey},
+			expectedSkipped: []string{`key "bad": decoding modulus: illegal base64 data at input byte 0`, `key "ec": point is not on curve P-256`},
+		},
+		{
+			name:          "no signing keys",
+			input:         jwks(t, jwk{Kty: "oct", Kid: "hmac"}),
+			expectedError: "no usable RSA or EC signing keys",
+		},
+		{
+			name:          "invalid json",
+			input:         []byte("{"),
+			expectedError: "unexpected end of JSON input",
+		},
+		{
+			name:            "invalid exponent",
+			input:           jwks(t, jwk{Kty: "RSA", Kid: "rsa", N: rsaKey.N, E: "AQ"}),
+			expectedSkipped: []string{`key "rsa": invalid exponent`},
+			expectedError:   "no usable RSA or EC signing keys",
+		},
+		{
+			name:            "only P-384 keys",
+			input:           jwks(t, p384),
+			expectedSkipped: []string{`key "p384": unsupported curve "P-384"`},
+			expectedError:   "no usable RSA or EC signing keys",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			output, skipped, err := parseJWKS(tc.input)
+			var skippedMessages []string
+			for _, err := range skipped {
+				skippedMessages = append(skippedMessages, err.Error())
+			}
+			require.Equal(t, tc.expectedSkipped, skippedMessages)
+			if err != nil {
+				if tc.expectedError == "" {
+					t.Fatalf(`expected no error, got "%v"`, err)
+				}
+				require.Equal(t, tc.expectedError, err.Error())
+			} else {
+				if tc.expectedError != "" {
+					t.Fatalf(`expected error "%v", got nil`, tc.expectedError)
+				}
+				require.Equal(t, tc.expectedOutput, output)
+			}
+		})
+	}
+}
+
+func TestNewKeySet(t *testing.T) {
+	t.Run("from file", func(t *testing.T) {
+		keys, err := NewKeySet(context.TODO(), writeJWKS(t, rsaJWK("rsa", &testKeys.rsa.PublicKey)), time.Hour, discardLog)
+		require.NoError(t, err)
+		key, err := keys.Key(context.TODO(), "rsa")
+		require.NoError(t, err)
+		require.Equal(t, &testKeys.rsa.PublicKey, key)
+	})
+
+	t.Run("from url", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write(jwks(t, ecJWK("ec", &testKeys.ec.PublicKey)))
+		}))
+		defer srv.Close()
+		keys, err := NewKeySet(context.TODO(), srv.URL, time.Hour, discardLog)
+		require.NoError(t, err)
+		key, err := keys.Key(context.TODO(), "ec")
+		require.NoError(t, err)
+		require.Equal(t, &testKeys.ec.PublicKey, key)
+	})
+
+	t.Run("skipped keys logged", func(t *testing.T) {
+		var logs strings.Builder
+		path := writeJWKS(t, jwk{Kty: "EC", Kid: "p384", Crv: "P-384"}, rsaJWK("rsa", &testKeys.rsa.PublicKey))
+		keys, err := NewKeySet(context.TODO(), path, time.Hour, slog.New(slog.NewJSONHandler(&logs, nil)))
+		require.NoError(t, err)
+		_, err = keys.Key(context.TODO(), "rsa")
+		require.NoError(t, err)
+		require.Contains(t, logs.String(), `"msg":"skipping JWKS key"`)
+		require.Contains(t, logs.String(), `"err":"key \"p384\": unsupported curve \"P-384\""`)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "jwks.json")
+		_, err := NewKeySet(context.TODO(), path, time.Hour, discardLog)
+		require.EqualError(t, err, "reading JWKS from "+path+": open "+path+": no such file or directory")
+	})
+
+	t.Run("unexpected status", func(t *testing.T) {
+		srv := httptest.NewServer(http.NotFoundHandler())
+		defer srv.Close()
+		_, err := NewKeySet(context.TODO(), srv.URL, time.Hour, discardLog)
+		require.EqualError(t, err, "reading JWKS from "+srv.URL+": unexpected status 404 Not Found")
+	})
+}
+
+func TestKeySetKey(t *testing.T) {
+	originalNow := now
+	defer func() {
+		now = originalNow
+	}()
+	current := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	now = func() time.Time {
+		return current
+	}
+	var (
+		served atomic.Value
+		loads  atomic.Int32
+	)
+	served.Store(jwks(t, rsaJWK("rsa", &testKeys.rsa.PublicKey)))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		loads.Add(1)
+		_, _ = w.Write(served.Load().([]byte))
+	}))
+	defer srv.Close()
+	keys, err := NewKeySet(context.TODO(), srv.URL, time.Hour, discardLog)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, loads.Load())
+
+	key, err := keys.Key(context.TODO(), "")
+	require.NoError(t, err)
+	require.Equal(t, &testKeys.rsa.PublicKey, key, "single key without id")
+
+	// keys are rotated: the unknown key is only looked for again once the
+	// last attempt is old enough.
+	served.Store(jwks(t, rsaJWK("rsa", &testKeys.rsa.PublicKey), ecJWK("ec", &testKeys.ec.PublicKey)))
+	_, err = keys.Key(context.TODO(), "ec")
+	require.EqualError(t, err, `unknown signing key "ec"`)
+	require.EqualValues(t, 1, loads.Load())
+	current = current.Add(2 * time.Minute)
+	key, err = keys.Key(context.TODO(), "ec")
+	require.NoError(t, err)
+	require.Equal(t, &testKeys.ec.PublicKey, key)
+	require.EqualValues(t, 2, loads.Load())
+
+	_, err = keys.Key(context.TODO(), "")
+	require.EqualError(t, err, `unknown signing key ""`, "several keys without id")
+
+	// keys that could not be reloaded once stale are kept.
+	srv.Config.Handler = http.NotFoundHandler()
+	current = current.Add(2 * time.Hour)
+	key, err = keys.Key(context.TODO(), "rsa")
+	require.NoError(t, err)
+	require.Equal(t, &testKeys.rsa.PublicKey, key)
+}
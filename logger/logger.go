@@ -0,0 +1,77 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+// Package logger provides the service's structured logger.
+package logger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+
+	"github.com/pkg/errors"
+	"github.com/tiagomelo/go-airports-service/auth"
+	"github.com/tiagomelo/go-airports-service/requestid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// New creates a logger writing JSON records of the given level or above to
+// w, each one carrying the ID of the request it was emitted while serving,
+// the subject of its caller and the IDs of the trace and span it belongs
+// to, if any.
+func New(w io.Writer, level slog.Leveler) *slog.Logger {
+	return slog.New(NewContextHandler(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})))
+}
+
+// ParseLevel parses a log level name, such as debug, info, warn or error.
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return 0, errors.Wrapf(err, "invalid log level %q", name)
+	}
+	return level, nil
+}
+
+// ContextHandler is a slog.Handler that adds the request ID, the caller's
+// subject and the trace and span IDs found in the context of each record,
+// if any, before handing it over to the wrapped handler. Records must be
+// emitted with the *Context methods of slog.Logger for them to be found.
+type ContextHandler struct {
+	slog.Handler
+}
+
+// NewContextHandler wraps the given handler in a ContextHandler.
+func NewContextHandler(h slog.Handler) *ContextHandler {
+	return &ContextHandler{Handler: h}
+}
+
+// Handle adds the request ID, the caller's subject and the trace and span
+// IDs found in ctx, if any, to the record.
+func (h *ContextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := requestid.FromContext(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if id := auth.FromContext(ctx); id != nil {
+		r.AddAttrs(slog.String("subject", id.Subject))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(
+			slog.String("trace_id", sc.TraceID().String()),
+			slog.String("span_id", sc.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs returns a ContextHandler whose wrapped handler has the given
+// attributes.
+func (h *ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &ContextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup returns a ContextHandler whose wrapped handler has the given
+// group.
+func (h *ContextHandler) WithGroup(name string) slog.Handler {
+	return &ContextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tiagomelo/go-airports-service/auth"
+	"github.com/tiagomelo/go-airports-service/requestid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestContextHandler(t *testing.T) {
+	testCases := []struct {
+		name           string
+		ctx            context.Context
+		log            func(log *slog.Logger, ctx context.Context)
+		expectedFields map[string]any
+	}{
+		{
+			name: "with request id",
+			ctx:  requestid.NewContext(context.TODO(), "abc"),
+			log: func(log *slog.Logger, ctx context.Context) {
+				log.InfoContext(ctx, "hello", slog.Int("n", 1))
+			},
+			expectedFields: map[string]any{"msg": "hello", "n": float64(1), "request_id": "abc"},
+		},
+		{
+			name: "without request id",
+			ctx:  context.TODO(),
+			log: func(log *slog.Logger, ctx context.Context) {
+				log.InfoContext(ctx, "hello")
+			},
+			expectedFields: map[string]any{"msg": "hello"},
+		},
+		{
+			name: "with attributes",
+			ctx:  requestid.NewContext(context.TODO(), "abc"),
+			log: func(log *slog.Logger, ctx context.Context) {
+				log.With(slog.String("component", "api")).InfoContext(ctx, "hello")
+			},
+			expectedFields: map[string]any{"msg": "hello", "component": "api", "request_id": "abc"},
+		},
+		{
+			name: "with caller",
+			ctx:  auth.NewContext(requestid.NewContext(context.TODO(), "abc"), &auth.Identity{Subject: "jwt:alice"}),
+			log: func(log *slog.Logger, ctx context.Context) {
+				log.InfoContext(ctx, "hello")
+			},
+			expectedFields: map[string]any{"msg": "hello", "request_id": "abc", "subject": "jwt:alice"},
+		},
+		{
+			name: "with span",
+			ctx: trace.ContextWithSpanContext(context.TODO(), trace.NewSpanContext(trace.SpanContextConfig{
+				TraceID: trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+				SpanID:  trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+			})),
+			log: func(log *slog.Logger, ctx context.Context) {
+				log.InfoContext(ctx, "hello")
+			},
+			expectedFields: map[string]any{"msg": "hello", "trace_id": "4bf92f3577b34da6a3ce929d0e0e4736", "span_id": "00f067aa0ba902b7"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tc.log(New(&buf, slog.LevelInfo), tc.ctx)
+			var record map[string]any
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+			delete(record, "time")
+			delete(record, "level")
+			require.Equal(t, tc.expectedFields, record)
+		})
+	}
+}
+
+func TestLevel(t *testing.T) {
+	level, err := ParseLevel("warn")
+	require.NoError(t, err)
+	require.Equal(t, slog.LevelWarn, level)
+	_, err = ParseLevel("loud")
+	require.EqualError(t, err, `invalid log level "loud": slog: level string "loud": unknown name`)
+
+	var buf bytes.Buffer
+	log := New(&buf, level)
+	log.Info("hidden")
+	require.Empty(t, buf.String())
+	log.Warn("shown")
+	require.Contains(t, buf.String(), `"msg":"shown"`)
+}
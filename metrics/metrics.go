@@ -0,0 +1,121 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+// Package metrics collects the service's Prometheus metrics: HTTP requests
+// per route and status, airports upserted and failed per request, the
+// throughput of streaming ingests, the latency of airport store operations
+// and database connection pool statistics.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Endpoints airports are upserted through.
+const (
+	EndpointStreaming    = "streaming"
+	EndpointNonStreaming = "nonstreaming"
+)
+
+// Registry holds every metric of the service, along with the Go runtime
+// and process ones.
+var Registry = newRegistry()
+
+var (
+	httpRequests = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Number of HTTP requests served, per route, method and status.",
+	}, []string{"route", "method", "status"})
+
+	httpRequestDuration = promauto.With(Registry).NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Time taken to serve HTTP requests, per route, method and status.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	airportsUpserted = promauto.With(Registry).NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "airports_upserted_per_request",
+		Help:    "Number of airports upserted per upsert request, per endpoint.",
+		Buckets: prometheus.ExponentialBuckets(1, 4, 9),
+	}, []string{"endpoint"})
+
+	airportsFailed = promauto.With(Registry).NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "airports_failed_per_request",
+		Help:    "Number of airports received but not upserted per upsert request, because they were invalid or could not be stored, per endpoint.",
+		Buckets: prometheus.ExponentialBuckets(1, 4, 9),
+	}, []string{"endpoint"})
+
+	ingestRate = promauto.With(Registry).NewHistogram(prometheus.HistogramOpts{
+		Name:    "airports_streaming_ingest_records_per_second",
+		Help:    "Airports upserted per second by the streaming endpoint, observed after each batch, decoding included.",
+		Buckets: prometheus.ExponentialBuckets(10, 2, 14),
+	})
+
+	storeDuration = promauto.With(Registry).NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "airports_store_duration_seconds",
+		Help:    "Time taken by airport store operations, per operation and outcome.",
+		Buckets: prometheus.ExponentialBuckets(0.0001, 4, 10),
+	}, []string{"operation", "outcome"})
+)
+
+// newRegistry creates a registry holding the Go runtime and process
+// metrics.
+func newRegistry() *prometheus.Registry {
+	r := prometheus.NewRegistry()
+	r.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return r
+}
+
+// Handler returns the handler exposing the metrics in Registry.
+// Compressing responses is left to the API's middleware.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{DisableCompression: true})
+}
+
+// ObserveRequest records an HTTP request served by the given route, which
+// is its path template, so that the number of series stays bounded.
+func ObserveRequest(route, method string, status int, duration time.Duration) {
+	code := strconv.Itoa(status)
+	httpRequests.WithLabelValues(route, method, code).Inc()
+	httpRequestDuration.WithLabelValues(route, method, code).Observe(duration.Seconds())
+}
+
+// ObserveUpsertRequest records how many airports an upsert request through
+// the given endpoint upserted, and how many it failed to.
+func ObserveUpsertRequest(endpoint string, upserted, failed int) {
+	airportsUpserted.WithLabelValues(endpoint).Observe(float64(upserted))
+	airportsFailed.WithLabelValues(endpoint).Observe(float64(failed))
+}
+
+// ObserveIngestBatch records the throughput of a batch of airports upserted
+// by the streaming endpoint, over the time it took to receive, decode and
+// upsert them.
+func ObserveIngestBatch(records int, duration time.Duration) {
+	if duration <= 0 {
+		return
+	}
+	ingestRate.Observe(float64(records) / duration.Seconds())
+}
+
+// RegisterDBStats exposes the statistics of the given connection pool,
+// such as open, in use and idle connections and wait counts, labeled with
+// the given name.
+func RegisterDBStats(name string, db *sql.DB) error {
+	if err := Registry.Register(collectors.NewDBStatsCollector(db, name)); err != nil {
+		return errors.Wrapf(err, "registering %s connection pool metrics", name)
+	}
+	return nil
+}
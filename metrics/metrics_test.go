@@ -0,0 +1,118 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"github.com/tiagomelo/go-airports-service/db/airports"
+)
+
+func TestObserveRequest(t *testing.T) {
+	before := testutil.ToFloat64(httpRequests.WithLabelValues("/api/v1/airports/{iata_code:[A-Za-z]{3}}", http.MethodGet, "404"))
+	ObserveRequest("/api/v1/airports/{iata_code:[A-Za-z]{3}}", http.MethodGet, http.StatusNotFound, time.Millisecond)
+	require.Equal(t, before+1, testutil.ToFloat64(httpRequests.WithLabelValues("/api/v1/airports/{iata_code:[A-Za-z]{3}}", http.MethodGet, "404")))
+}
+
+func TestObserveUpsertRequest(t *testing.T) {
+	labels := map[string]string{"endpoint": EndpointNonStreaming}
+	before := sampleCount(t, "airports_upserted_per_request", labels)
+	ObserveUpsertRequest(EndpointNonStreaming, 3, 1)
+	require.Equal(t, before+1, sampleCount(t, "airports_upserted_per_request", labels))
+	require.Equal(t, before+1, sampleCount(t, "airports_failed_per_request", labels))
+}
+
+func TestObserveIngestBatch(t *testing.T) {
+	ObserveIngestBatch(500, 0)
+	require.Zero(t, sampleCount(t, "airports_streaming_ingest_records_per_second", nil))
+	ObserveIngestBatch(500, time.Second)
+	require.Equal(t, uint64(1), sampleCount(t, "airports_streaming_ingest_records_per_second", nil))
+}
+
+func TestRegisterDBStats(t *testing.T) {
+	db, _, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, RegisterDBStats("test", db))
+	err = RegisterDBStats("test", db)
+	require.ErrorContains(t, err, "registering test connection pool metrics")
+}
+
+func TestHandler(t *testing.T) {
+	ObserveRequest("/metrics", http.MethodGet, http.StatusOK, time.Millisecond)
+	rr := httptest.NewRecorder()
+	Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Contains(t, rr.Body.String(), `http_requests_total{method="GET",route="/metrics",status="200"}`)
+	require.Contains(t, rr.Body.String(), "go_goroutines")
+}
+
+type backuperStore struct {
+	*airports.MemoryStore
+}
+
+func (s *backuperStore) Backup(ctx context.Context) (io.ReadCloser, int64, error) {
+	return nil, 0, errors.New("not implemented")
+}
+
+type failingStore struct {
+	*airports.MemoryStore
+}
+
+func (s *failingStore) Get(ctx context.Context, iataCode string) (*airports.Airport, error) {
+	return nil, sql.ErrConnDone
+}
+
+func TestInstrumentStore(t *testing.T) {
+	ctx := context.TODO()
+	store := InstrumentStore(airports.NewMemoryStore())
+	_, ok := store.(airports.Backuper)
+	require.False(t, ok)
+	_, ok = InstrumentStore(&backuperStore{airports.NewMemoryStore()}).(airports.Backuper)
+	require.True(t, ok)
+
+	require.NoError(t, store.Upsert(ctx, &airports.Airport{Name: "Guarulhos", City: "Sao Paulo", Country: "Brazil", IataCode: "GRU"}))
+	a, err := store.Get(ctx, "GRU")
+	require.NoError(t, err)
+	require.Equal(t, "Guarulhos", a.Name)
+	_, err = InstrumentStore(&failingStore{airports.NewMemoryStore()}).Get(ctx, "GRU")
+	require.Equal(t, sql.ErrConnDone, err)
+
+	require.Equal(t, uint64(1), sampleCount(t, "airports_store_duration_seconds", map[string]string{"operation": "upsert", "outcome": "success"}))
+	require.Equal(t, uint64(1), sampleCount(t, "airports_store_duration_seconds", map[string]string{"operation": "get", "outcome": "success"}))
+	require.Equal(t, uint64(1), sampleCount(t, "airports_store_duration_seconds", map[string]string{"operation": "get", "outcome": "error"}))
+}
+
+// sampleCount returns how many observations the histogram with the given
+// name and labels holds.
+func sampleCount(t *testing.T, name string, labels map[string]string) uint64 {
+	families, err := Registry.Gather()
+	require.NoError(t, err)
+	for _, f := range families {
+		if f.GetName() != name {
+			continue
+		}
+	metrics:
+		for _, m := range f.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if labels[l.GetName()] != l.GetValue() {
+					continue metrics
+				}
+			}
+			return m.GetHistogram().GetSampleCount()
+		}
+	}
+	return 0
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/tiagomelo/go-airports-service/db/airports"
+)
+
+// instrumentedStore is an airport store that records the latency of the
+// operations of the store it wraps. Export and FindDuplicates are not
+// recorded, since they take as long as the callbacks they are given.
+type instrumentedStore struct {
+	airports.AirportStore
+}
+
+// instrumentedBackuperStore is an instrumentedStore wrapping a store that
+// can take backups, so that it can still be told apart from one that
+// cannot.
+type instrumentedBackuperStore struct {
+	*instrumentedStore
+	airports.Backuper
+}
+
+// InstrumentStore wraps an airport store so that the latency of its
+// operations is recorded.
+func InstrumentStore(store airports.AirportStore) airports.AirportStore {
+	s := &instrumentedStore{AirportStore: store}
+	if backuper, ok := store.(airports.Backuper); ok {
+		return &instrumentedBackuperStore{instrumentedStore: s, Backuper: backuper}
+	}
+	return s
+}
+
+// observe records the time elapsed since start by the given operation.
+func observe(operation string, start time.Time, err error) {
+	outcome := "success"
+	if err != nil {
+		outcome = "error"
+	}
+	storeDuration.WithLabelValues(operation, outcome).Observe(time.Since(start).Seconds())
+}
+
+func (s *instrumentedStore) Upsert(ctx context.Context, airport *airports.Airport) error {
+	start := time.Now()
+	err := s.AirportStore.Upsert(ctx, airport)
+	observe("upsert", start, err)
+	return err
+}
+
+func (s *instrumentedStore) UpsertBatch(ctx context.Context, batch []*airports.Airport) error {
+	start := time.Now()
+	err := s.AirportStore.UpsertBatch(ctx, batch)
+	observe("upsert_batch", start, err)
+	return err
+}
+
+func (s *instrumentedStore) Get(ctx context.Context, iataCode string) (*airports.Airport, error) {
+	start := time.Now()
+	result, err := s.AirportStore.Get(ctx, iataCode)
+	observe("get", start, err)
+	return result, err
+}
+
+func (s *instrumentedStore) List(ctx context.Context, filter airports.ListFilter) ([]airports.Airport, error) {
+	start := time.Now()
+	result, err := s.AirportStore.List(ctx, filter)
+	observe("list", start, err)
+	return result, err
+}
+
+func (s *instrumentedStore) Delete(ctx context.Context, iataCode string) error {
+	start := time.Now()
+	err := s.AirportStore.Delete(ctx, iataCode)
+	observe("delete", start, err)
+	return err
+}
+
+func (s *instrumentedStore) Search(ctx context.Context, query string, limit int) ([]airports.SearchResult, error) {
+	start := time.Now()
+	result, err := s.AirportStore.Search(ctx, query, limit)
+	observe("search", start, err)
+	return result, err
+}
+
+func (s *instrumentedStore) ReplaceAll(ctx context.Context, batch []*airports.Airport) error {
+	start := time.Now()
+	err := s.AirportStore.ReplaceAll(ctx, batch)
+	observe("replace_all", start, err)
+	return err
+}
+
+func (s *instrumentedStore) PreviewUpsert(ctx context.Context, batch []*airports.Airport) (airports.Diff, error) {
+	start := time.Now()
+	result, err := s.AirportStore.PreviewUpsert(ctx, batch)
+	observe("preview_upsert", start, err)
+	return result, err
+}
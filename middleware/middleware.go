@@ -5,33 +5,570 @@
 package middleware
 
 import (
+	"fmt"
+	"io"
 	"log/slog"
+	"math/rand"
+	"net"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gorilla/handlers"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/tiagomelo/go-airports-service/auth"
+	"github.com/tiagomelo/go-airports-service/metrics"
+	"github.com/tiagomelo/go-airports-service/ratelimit"
+	"github.com/tiagomelo/go-airports-service/requestid"
+	"github.com/tiagomelo/go-airports-service/web"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// Logger is a middleware that logs the start and end of each HTTP request along with
-// some additional information.
-func Logger(log *slog.Logger, next http.Handler) http.Handler {
+// RequestID is a middleware that identifies each HTTP request, either by
+// the X-Request-ID header sent by the client, when valid, or by a newly
+// generated ID. The ID is stored in the request's context and echoed in the
+// X-Request-ID response header.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestid.Header)
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+		w.Header().Set(requestid.Header, id)
+		next.ServeHTTP(w, r.WithContext(requestid.NewContext(r.Context(), id)))
+	})
+}
+
+// For ease of unit testing.
+var (
+	// randFloat64 is a function that returns a random number in [0.0, 1.0).
+	randFloat64 = rand.Float64
+)
+
+// Logger is a middleware that writes an access log record for each HTTP
+// request once it completes, carrying its method, path, remote address,
+// user agent, response status, bytes read and written, and latency, along
+// with the subject of the caller once authenticated. Server errors are
+// logged at error level and client errors at warn level, all of them.
+// Successful requests are logged at info level, but only a sampleRate
+// fraction of them, between 0 and 1, to keep busy instances from flooding
+// the logs. The start of each request is logged at debug level.
+func Logger(log *slog.Logger, sampleRate float64, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now().UTC()
-		log.Info("request started",
+		r = r.WithContext(auth.NewCallerContext(r.Context()))
+		log.DebugContext(r.Context(), "request started",
 			slog.String("method", r.Method),
 			slog.String("path", r.URL.Path),
 			slog.String("remoteaddr", r.RemoteAddr),
 		)
-		next.ServeHTTP(w, r)
-		log.Info("request completed",
+		body := &countingReader{ReadCloser: r.Body}
+		if r.Body != nil {
+			r.Body = body
+		}
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		level := slog.LevelInfo
+		switch {
+		case rec.status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case rec.status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		case randFloat64() >= sampleRate:
+			return
+		}
+		log.LogAttrs(r.Context(), level, "request completed",
 			slog.String("method", r.Method),
 			slog.String("path", r.URL.Path),
 			slog.String("remoteaddr", r.RemoteAddr),
+			slog.String("useragent", r.UserAgent()),
+			slog.Int("status", rec.status),
+			slog.Int64("bytes_read", body.read),
+			slog.Int64("bytes_written", rec.written),
 			slog.Duration("since", time.Since(start)),
 		)
 	})
 }
 
+// Metrics is a middleware that records the number and duration of HTTP
+// requests per route, method and status. Routes are identified by their
+// path template, so it must run after routing, as mux.Router middlewares
+// do.
+func Metrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		metrics.ObserveRequest(routeTemplate(r), r.Method, rec.status, time.Since(start))
+	})
+}
+
+// tracerName is the name of the tracer the middlewares start spans from.
+const tracerName = "github.com/tiagomelo/go-airports-service/middleware"
+
+// Tracing is a middleware that starts a server span for each HTTP request,
+// continuing the trace the client propagated, if any. Spans are named
+// after the route's path template, so it must run after routing, as
+// mux.Router middlewares do, and carry the request ID, so it must run
+// after RequestID. Server errors set the span status to error.
+func Tracing(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		route := routeTemplate(r)
+		ctx, span := otel.Tracer(tracerName).Start(ctx, r.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.HTTPRoute(route),
+				semconv.URLPath(r.URL.Path),
+				semconv.UserAgentOriginal(r.UserAgent()),
+				attribute.String("request_id", requestid.FromContext(ctx)),
+			),
+		)
+		defer span.End()
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+		span.SetAttributes(semconv.HTTPResponseStatusCode(rec.status))
+		if rec.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rec.status))
+		}
+	})
+}
+
+// routeTemplate returns the path template of the route the request
+// matched, or unknown if none.
+func routeTemplate(r *http.Request) string {
+	if current := mux.CurrentRoute(r); current != nil {
+		if tmpl, err := current.GetPathTemplate(); err == nil {
+			return tmpl
+		}
+	}
+	return "unknown"
+}
+
+// responseRecorder is an http.ResponseWriter that records the status code
+// and the number of bytes of the response. It implements http.Flusher and
+// unwraps to the writer it wraps, so that handlers can still flush and
+// use http.ResponseController through it.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	written     int64
+	wroteHeader bool
+}
+
+// WriteHeader records the status code before writing it.
+func (r *responseRecorder) WriteHeader(code int) {
+	if !r.wroteHeader && code >= http.StatusOK {
+		r.status = code
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// Write records the number of bytes written.
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	n, err := r.ResponseWriter.Write(b)
+	r.written += int64(n)
+	return n, err
+}
+
+// Flush flushes the wrapped writer, if it supports flushing.
+func (r *responseRecorder) Flush() {
+	_ = r.FlushError()
+}
+
+// FlushError flushes the wrapped writer, returning an error if it does
+// not support flushing. http.ResponseController prefers it over Flush.
+func (r *responseRecorder) FlushError() error {
+	r.wroteHeader = true
+	return http.NewResponseController(r.ResponseWriter).Flush()
+}
+
+// Unwrap returns the wrapped writer, for http.ResponseController.
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// countingReader is an io.ReadCloser that counts the bytes read through it.
+type countingReader struct {
+	io.ReadCloser
+	read int64
+}
+
+// Read counts the bytes read.
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.read += int64(n)
+	return n, err
+}
+
+// Authorize is a middleware that lets through requests whose caller the
+// authenticator vouches for and was granted the given scope, carrying
+// their identity in the request's context. Requests without credentials
+// or with invalid ones are answered with 401 Unauthorized, and those
+// whose caller lacks the scope with 403 Forbidden.
+func Authorize(authenticator auth.Authenticator, scope auth.Scope, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := authenticator.Authenticate(r)
+		switch {
+		case errors.Is(err, auth.ErrNoCredentials):
+			web.RespondWithError(w, http.StatusUnauthorized, "authentication required")
+			return
+		case errors.Is(err, auth.ErrInvalidCredentials):
+			web.RespondWithError(w, http.StatusUnauthorized, err.Error())
+			return
+		case err != nil:
+			web.RespondWithError(w, http.StatusInternalServerError, errors.Wrap(err, "error authenticating request").Error())
+			return
+		}
+		// stored before checking the scope for denials to be attributed.
+		ctx := auth.NewContext(r.Context(), id)
+		if !id.HasScope(scope) {
+			web.RespondWithError(w, http.StatusForbidden, fmt.Sprintf("missing scope %s", scope))
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Audit is a middleware that writes an audit record for each HTTP request
+// once it completes, carrying its method, route, path and response status.
+// The subject of its caller is added by the logger's handler, as for any
+// record, or is anonymous if it was not authenticated. It is meant for the
+// routes that change data or expose it in bulk, and must run before
+// Authorize for requests it denies to be recorded as well. Unlike access
+// log records, audit records are never sampled.
+func Audit(log *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r = r.WithContext(auth.NewCallerContext(r.Context()))
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		attrs := []slog.Attr{
+			slog.String("method", r.Method),
+			slog.String("route", routeTemplate(r)),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", rec.status),
+		}
+		if auth.FromContext(r.Context()) == nil {
+			attrs = append(attrs, slog.String("subject", "anonymous"))
+		}
+		log.LogAttrs(r.Context(), slog.LevelInfo, "audit", attrs...)
+	})
+}
+
+// RateLimit is a middleware that lets each client send requests only as
+// fast as the limiter allows, answering the others with 429 Too Many
+// Requests. Clients are told how many requests they have left in the
+// RateLimit-* response headers, and how long to wait in the Retry-After
+// one once limited. Clients are identified by the subject of the caller,
+// so it must run after Authorize, or by their IP address when they were
+// not authenticated.
+func RateLimit(limiter *ratelimit.Limiter, next http.Handler) http.Handler {
+	return rateLimit(limiter, clientKey, next)
+}
+
+// IPRateLimit is a middleware that lets each IP address send requests only
+// as fast as the limiter allows, as RateLimit does for clients. It is meant
+// to run before Authorize, so that requests with missing or invalid
+// credentials are limited too.
+func IPRateLimit(limiter *ratelimit.Limiter, next http.Handler) http.Handler {
+	return rateLimit(limiter, ipKey, next)
+}
+
+// rateLimit lets requests through only as fast as the limiter allows for
+// the client key returns.
+func rateLimit(limiter *ratelimit.Limiter, key func(*http.Request) string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		res := limiter.Allow(key(r))
+		res.SetHeaders(w.Header())
+		if !res.Allowed {
+			web.RespondWithError(w, http.StatusTooManyRequests, "rate limit exceeded")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// IngestQuota is a middleware that carries, in the request's context, the
+// client's share of the limiter the records it ingests are taken from.
+// Handlers enforce it as they ingest records. Clients are identified as
+// by RateLimit.
+func IngestQuota(limiter *ratelimit.Limiter, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		quota := ratelimit.NewQuota(limiter, clientKey(r))
+		next.ServeHTTP(w, r.WithContext(ratelimit.NewContext(r.Context(), quota)))
+	})
+}
+
+// clientKey identifies the client that sent the request by the subject of
+// the caller, or by its IP address when it was not authenticated.
+func clientKey(r *http.Request) string {
+	if id := auth.FromContext(r.Context()); id != nil {
+		return id.Subject
+	}
+	return ipKey(r)
+}
+
+// ipKey identifies the client that sent the request by its IP address.
+func ipKey(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// MaxBodySize is a middleware that limits request bodies to maxBytes,
+// answering requests that declare a larger Content-Length with 413 Request
+// Entity Too Large right away. Reading past the limit of the others fails
+// with an *http.MaxBytesError, for handlers to answer likewise.
+func MaxBodySize(maxBytes int64, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > maxBytes {
+			web.RespondWithError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body too large: at most %d bytes", maxBytes))
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// SlowBodyError is returned when reading a request body that arrives
+// slower than MinThroughput allows.
+type SlowBodyError struct {
+	// MinRate is the minimum throughput, in bytes per second.
+	MinRate int64
+}
+
+func (e *SlowBodyError) Error() string {
+	return fmt.Sprintf("request body too slow: less than %d bytes per second", e.MinRate)
+}
+
+// MinThroughput is a middleware that aborts reading request bodies that
+// arrive slower than minRate bytes per second, as measured over each
+// window, failing the pending read with a *SlowBodyError. Only the time
+// the handler spends waiting for the body counts, so that handlers busy
+// doing something else in between reads do not get their clients aborted.
+// Windows in which the handler waited less than half the time are not
+// judged. Since slow clients are taken care of, the server's read timeout
+// is lifted for the request, so that long uploads can go on for as long as
+// they keep up.
+func MinThroughput(minRate int64, window time.Duration, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rc := http.NewResponseController(w)
+		if err := rc.SetReadDeadline(time.Time{}); err != nil {
+			// without deadlines, stalled reads cannot be aborted.
+			next.ServeHTTP(w, r)
+			return
+		}
+		body := &watchedBody{ReadCloser: r.Body, minRate: minRate}
+		r.Body = body
+		done := make(chan struct{})
+		defer close(done)
+		go body.watch(rc, window, done)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// watchedBody is a request body that keeps track of the bytes read
+// through it and of the time spent waiting for them.
+type watchedBody struct {
+	io.ReadCloser
+	minRate int64
+
+	mu      sync.Mutex
+	read    int64
+	waited  time.Duration
+	reading time.Time
+	done    bool
+	slow    bool
+}
+
+// Read reads from the body, failing with a *SlowBodyError once it was
+// found too slow.
+func (b *watchedBody) Read(p []byte) (int, error) {
+	b.mu.Lock()
+	b.reading = time.Now()
+	b.mu.Unlock()
+	n, err := b.ReadCloser.Read(p)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.read += int64(n)
+	b.waited += time.Since(b.reading)
+	b.reading = time.Time{}
+	if err != nil {
+		b.done = true
+		if b.slow {
+			return n, &SlowBodyError{MinRate: b.minRate}
+		}
+	}
+	return n, err
+}
+
+// progress returns the bytes read so far and the time spent waiting for
+// them, the pending read included, and whether the body was read through.
+func (b *watchedBody) progress() (read int64, waited time.Duration, done bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	waited = b.waited
+	if !b.reading.IsZero() {
+		waited += time.Since(b.reading)
+	}
+	return b.read, waited, b.done
+}
+
+// watch checks the throughput of the body once per window until done is
+// closed or the body is read through, setting a read deadline in the past
+// to abort the pending read once it is too slow.
+func (b *watchedBody) watch(rc *http.ResponseController, window time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(window)
+	defer ticker.Stop()
+	var (
+		lastRead   int64
+		lastWaited time.Duration
+	)
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+		read, waited, finished := b.progress()
+		if finished {
+			return
+		}
+		if waited-lastWaited >= window/2 && float64(read-lastRead) < float64(b.minRate)*(waited-lastWaited).Seconds() {
+			b.mu.Lock()
+			b.slow = true
+			b.mu.Unlock()
+			_ = rc.SetReadDeadline(time.Now())
+			return
+		}
+		lastRead, lastWaited = read, waited
+	}
+}
+
+// CORSOptions tell which cross-origin requests browsers are allowed to
+// send.
+type CORSOptions struct {
+	// AllowedOrigins are the origins requests are allowed from, "*"
+	// allowing any.
+	AllowedOrigins []string
+	// AllowedMethods are the methods requests are allowed to use.
+	AllowedMethods []string
+	// AllowedHeaders are the headers requests are allowed to carry, "*"
+	// allowing any.
+	AllowedHeaders []string
+	// AllowCredentials tells whether requests may carry credentials, such
+	// as cookies and the Authorization header.
+	AllowCredentials bool
+	// ExposedHeaders are the response headers, besides the safelisted
+	// ones, that pages are allowed to read.
+	ExposedHeaders []string
+	// MaxAge is how long browsers may cache preflight responses, left to
+	// them when zero.
+	MaxAge time.Duration
+}
+
+// CORS is a middleware that lets browsers send requests from the allowed
+// origins, by answering preflight OPTIONS requests and by setting the
+// Access-Control-Allow-* and Access-Control-Expose-Headers headers on the
+// responses to the others. Requests
+// from other origins, or preflights asking for methods or headers that are
+// not allowed, get no such headers, so that browsers keep the responses
+// from the pages that sent them. Since preflight requests are answered
+// here, the router must match OPTIONS requests for them to get through.
+func CORS(o CORSOptions, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		var (
+			requestMethod  = r.Header.Get("Access-Control-Request-Method")
+			requestHeaders = r.Header.Get("Access-Control-Request-Headers")
+			preflight      = r.Method == http.MethodOptions && requestMethod != ""
+			h              = w.Header()
+		)
+		h.Add("Vary", "Origin")
+		if !preflight {
+			if allowed(o.AllowedOrigins, origin) {
+				setAllowOrigin(h, o, origin)
+				if len(o.ExposedHeaders) > 0 {
+					h.Set("Access-Control-Expose-Headers", strings.Join(o.ExposedHeaders, ", "))
+				}
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+		h.Add("Vary", "Access-Control-Request-Method")
+		h.Add("Vary", "Access-Control-Request-Headers")
+		if allowed(o.AllowedOrigins, origin) && allowed(o.AllowedMethods, requestMethod) && allowedHeaders(o.AllowedHeaders, requestHeaders) {
+			setAllowOrigin(h, o, origin)
+			h.Set("Access-Control-Allow-Methods", strings.Join(o.AllowedMethods, ", "))
+			if requestHeaders != "" {
+				h.Set("Access-Control-Allow-Headers", requestHeaders)
+			}
+			if o.MaxAge > 0 {
+				h.Set("Access-Control-Max-Age", strconv.Itoa(int(o.MaxAge.Seconds())))
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// setAllowOrigin sets the headers allowing the given origin to read
+// responses.
+func setAllowOrigin(h http.Header, o CORSOptions, origin string) {
+	if slices.Contains(o.AllowedOrigins, "*") {
+		h.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		h.Set("Access-Control-Allow-Origin", origin)
+	}
+	if o.AllowCredentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// allowed reports whether value is in the list, "*" allowing any.
+func allowed(list []string, value string) bool {
+	for _, v := range list {
+		if v == "*" || v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// allowedHeaders reports whether every header in the comma-separated list
+// is in the allowed ones, regardless of case, "*" allowing any.
+func allowedHeaders(allowedList []string, headers string) bool {
+	for _, header := range strings.Split(headers, ",") {
+		header = strings.TrimSpace(header)
+		if header == "" {
+			continue
+		}
+		if !slices.ContainsFunc(allowedList, func(v string) bool {
+			return v == "*" || strings.EqualFold(v, header)
+		}) {
+			return false
+		}
+	}
+	return true
+}
+
 // Compress is a middleware that applies compression to HTTP responses.
 func Compress(next http.Handler) http.Handler {
 	return handlers.CompressHandler(next)
@@ -0,0 +1,748 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+	"github.com/tiagomelo/go-airports-service/auth"
+	"github.com/tiagomelo/go-airports-service/logger"
+	"github.com/tiagomelo/go-airports-service/metrics"
+	"github.com/tiagomelo/go-airports-service/ratelimit"
+	"github.com/tiagomelo/go-airports-service/requestid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestLogger(t *testing.T) {
+	testCases := []struct {
+		name           string
+		sampleRate     float64
+		random         float64
+		handler        http.HandlerFunc
+		expectFlushed  bool
+		expectedRecord map[string]any
+	}{
+		{
+			name:       "success",
+			sampleRate: 1,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.ReadAll(r.Body)
+				_, _ = w.Write([]byte("hello"))
+			},
+			expectedRecord: map[string]any{
+				"level":         "INFO",
+				"status":        float64(http.StatusOK),
+				"bytes_read":    float64(4),
+				"bytes_written": float64(5),
+			},
+		},
+		{
+			name:       "success not sampled",
+			sampleRate: 0.5,
+			random:     0.5,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte("hello"))
+			},
+		},
+		{
+			name:       "success sampled",
+			sampleRate: 0.5,
+			random:     0.4,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusCreated)
+			},
+			expectedRecord: map[string]any{
+				"level":         "INFO",
+				"status":        float64(http.StatusCreated),
+				"bytes_read":    float64(0),
+				"bytes_written": float64(0),
+			},
+		},
+		{
+			name:       "client error always logged",
+			sampleRate: 0,
+			random:     0.9,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "not found", http.StatusNotFound)
+			},
+			expectedRecord: map[string]any{
+				"level":         "WARN",
+				"status":        float64(http.StatusNotFound),
+				"bytes_read":    float64(0),
+				"bytes_written": float64(len("not found\n")),
+			},
+		},
+		{
+			name:       "server error always logged",
+			sampleRate: 0,
+			random:     0.9,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+				w.WriteHeader(http.StatusOK)
+			},
+			expectedRecord: map[string]any{
+				"level":         "ERROR",
+				"status":        float64(http.StatusInternalServerError),
+				"bytes_read":    float64(0),
+				"bytes_written": float64(0),
+			},
+		},
+		{
+			name:       "flushing",
+			sampleRate: 1,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if err := http.NewResponseController(w).Flush(); err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+				w.(http.Flusher).Flush()
+				_, _ = w.Write([]byte("hello"))
+			},
+			expectFlushed: true,
+			expectedRecord: map[string]any{
+				"level":         "INFO",
+				"status":        float64(http.StatusOK),
+				"bytes_read":    float64(0),
+				"bytes_written": float64(5),
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			originalRandFloat64 := randFloat64
+			defer func() {
+				randFloat64 = originalRandFloat64
+			}()
+			randFloat64 = func() float64 {
+				return tc.random
+			}
+			var buf bytes.Buffer
+			log := slog.New(slog.NewJSONHandler(&buf, nil))
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/airports", strings.NewReader("[{}]"))
+			req.Header.Set("User-Agent", "feed-importer/1.0")
+			rr := httptest.NewRecorder()
+			Logger(log, tc.sampleRate, tc.handler).ServeHTTP(rr, req)
+			require.Equal(t, tc.expectFlushed, rr.Flushed)
+
+			if tc.expectedRecord == nil {
+				require.Empty(t, buf.String())
+				return
+			}
+			var record map[string]any
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+			require.Equal(t, "request completed", record["msg"])
+			require.Equal(t, http.MethodPost, record["method"])
+			require.Equal(t, "/api/v1/airports", record["path"])
+			require.Equal(t, "feed-importer/1.0", record["useragent"])
+			require.Contains(t, record, "since")
+			for k, v := range tc.expectedRecord {
+				require.Equal(t, v, record[k], k)
+			}
+		})
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/airports/{iata_code:[A-Za-z]{3}}", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "not found", http.StatusNotFound)
+	})
+	router.Use(Metrics)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/airports/CDG", nil))
+	require.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Contains(t, rr.Body.String(), `http_requests_total{method="GET",route="/api/v1/airports/{iata_code:[A-Za-z]{3}}",status="404"} 1`)
+}
+
+func TestTracing(t *testing.T) {
+	testCases := []struct {
+		name           string
+		status         int
+		traceparent    string
+		expectedStatus codes.Code
+		expectedParent string
+	}{
+		{
+			name:           "success",
+			status:         http.StatusOK,
+			expectedStatus: codes.Unset,
+		},
+		{
+			name:           "client error",
+			status:         http.StatusNotFound,
+			expectedStatus: codes.Unset,
+		},
+		{
+			name:           "server error",
+			status:         http.StatusInternalServerError,
+			expectedStatus: codes.Error,
+		},
+		{
+			name:           "trace propagated by the client",
+			status:         http.StatusOK,
+			traceparent:    "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			expectedStatus: codes.Unset,
+			expectedParent: "00f067aa0ba902b7",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sr := tracetest.NewSpanRecorder()
+			originalProvider, originalPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+			defer func() {
+				otel.SetTracerProvider(originalProvider)
+				otel.SetTextMapPropagator(originalPropagator)
+			}()
+			otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)))
+			otel.SetTextMapPropagator(propagation.TraceContext{})
+
+			var handlerSpan trace.SpanContext
+			router := mux.NewRouter()
+			router.HandleFunc("/api/v1/airports/{iata_code:[A-Za-z]{3}}", func(w http.ResponseWriter, r *http.Request) {
+				handlerSpan = trace.SpanContextFromContext(r.Context())
+				w.WriteHeader(tc.status)
+			})
+			router.Use(RequestID, Tracing)
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/airports/CDG", nil)
+			req.Header.Set(requestid.Header, "abc-123")
+			if tc.traceparent != "" {
+				req.Header.Set("traceparent", tc.traceparent)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			require.Equal(t, tc.status, rr.Code)
+
+			spans := sr.Ended()
+			require.Len(t, spans, 1)
+			span := spans[0]
+			require.Equal(t, "GET /api/v1/airports/{iata_code:[A-Za-z]{3}}", span.Name())
+			require.Equal(t, trace.SpanKindServer, span.SpanKind())
+			require.Equal(t, tc.expectedStatus, span.Status().Code)
+			require.Equal(t, span.SpanContext(), handlerSpan)
+			attrs := map[attribute.Key]attribute.Value{}
+			for _, kv := range span.Attributes() {
+				attrs[kv.Key] = kv.Value
+			}
+			require.Equal(t, "/api/v1/airports/{iata_code:[A-Za-z]{3}}", attrs["http.route"].AsString())
+			require.Equal(t, "/api/v1/airports/CDG", attrs["url.path"].AsString())
+			require.Equal(t, "abc-123", attrs["request_id"].AsString())
+			require.Equal(t, int64(tc.status), attrs["http.response.status_code"].AsInt64())
+			if tc.expectedParent == "" {
+				require.False(t, span.Parent().IsValid())
+			} else {
+				require.Equal(t, tc.expectedParent, span.Parent().SpanID().String())
+				require.True(t, span.Parent().IsRemote())
+			}
+		})
+	}
+}
+
+type authenticatorFunc func(r *http.Request) (*auth.Identity, error)
+
+func (f authenticatorFunc) Authenticate(r *http.Request) (*auth.Identity, error) {
+	return f(r)
+}
+
+func TestAuthorize(t *testing.T) {
+	testCases := []struct {
+		name               string
+		identity           *auth.Identity
+		authErr            error
+		expectedStatusCode int
+		expectedOutput     string
+	}{
+		{
+			name:               "granted",
+			identity:           &auth.Identity{Subject: "api-key:0123456789abcdef", Scopes: []auth.Scope{auth.ScopeAirportsWrite}},
+			expectedStatusCode: http.StatusOK,
+			expectedOutput:     "api-key:0123456789abcdef",
+		},
+		{
+			name:               "granted through admin",
+			identity:           &auth.Identity{Subject: "api-key:bootstrap", Scopes: []auth.Scope{auth.ScopeAdmin}},
+			expectedStatusCode: http.StatusOK,
+			expectedOutput:     "api-key:bootstrap",
+		},
+		{
+			name:               "missing scope",
+			identity:           &auth.Identity{Subject: "api-key:0123456789abcdef", Scopes: []auth.Scope{auth.ScopeAirportsRead}},
+			expectedStatusCode: http.StatusForbidden,
+			expectedOutput:     `{"error":"missing scope airports:write"}`,
+		},
+		{
+			name:               "no credentials",
+			authErr:            auth.ErrNoCredentials,
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedOutput:     `{"error":"authentication required"}`,
+		},
+		{
+			name:               "invalid credentials",
+			authErr:            auth.ErrInvalidCredentials,
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedOutput:     `{"error":"invalid credentials"}`,
+		},
+		{
+			name:               "authenticator error",
+			authErr:            errors.New("store error"),
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedOutput:     `{"error":"error authenticating request: store error"}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			authenticator := authenticatorFunc(func(r *http.Request) (*auth.Identity, error) {
+				return tc.identity, tc.authErr
+			})
+			handler := Authorize(authenticator, auth.ScopeAirportsWrite, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(auth.FromContext(r.Context()).Subject))
+			}))
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/v1/airports", nil))
+			require.Equal(t, tc.expectedStatusCode, rr.Code)
+			require.Equal(t, tc.expectedOutput, strings.TrimSpace(rr.Body.String()))
+		})
+	}
+}
+
+func TestAudit(t *testing.T) {
+	testCases := []struct {
+		name           string
+		identity       *auth.Identity
+		authErr        error
+		expectedRecord map[string]any
+	}{
+		{
+			name:     "authorized",
+			identity: &auth.Identity{Subject: "jwt:alice", Scopes: []auth.Scope{auth.ScopeAirportsWrite}},
+			expectedRecord: map[string]any{
+				"status":  float64(http.StatusNoContent),
+				"subject": "jwt:alice",
+			},
+		},
+		{
+			name:     "forbidden",
+			identity: &auth.Identity{Subject: "jwt:bob", Scopes: []auth.Scope{auth.ScopeAirportsRead}},
+			expectedRecord: map[string]any{
+				"status":  float64(http.StatusForbidden),
+				"subject": "jwt:bob",
+			},
+		},
+		{
+			name:    "unauthenticated",
+			authErr: auth.ErrInvalidCredentials,
+			expectedRecord: map[string]any{
+				"status":  float64(http.StatusUnauthorized),
+				"subject": "anonymous",
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			log := logger.New(&buf, slog.LevelInfo)
+			authenticator := authenticatorFunc(func(r *http.Request) (*auth.Identity, error) {
+				return tc.identity, tc.authErr
+			})
+			router := mux.NewRouter()
+			router.Handle("/api/v1/airports/{iata_code}", Audit(log, Authorize(authenticator, auth.ScopeAirportsWrite, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			}))))
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/api/v1/airports/GRU", nil))
+
+			var record map[string]any
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+			require.Equal(t, "audit", record["msg"])
+			require.Equal(t, http.MethodDelete, record["method"])
+			require.Equal(t, "/api/v1/airports/{iata_code}", record["route"])
+			require.Equal(t, "/api/v1/airports/GRU", record["path"])
+			for k, v := range tc.expectedRecord {
+				require.Equal(t, v, record[k], k)
+			}
+		})
+	}
+}
+
+func TestLoggerSubject(t *testing.T) {
+	var buf bytes.Buffer
+	log := logger.New(&buf, slog.LevelInfo)
+	authenticator := authenticatorFunc(func(r *http.Request) (*auth.Identity, error) {
+		return &auth.Identity{Subject: "jwt:alice", Scopes: []auth.Scope{auth.ScopeAirportsRead}}, nil
+	})
+	handler := Logger(log, 1, Authorize(authenticator, auth.ScopeAirportsRead, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/airports", nil))
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	require.Equal(t, "request completed", record["msg"])
+	require.Equal(t, "jwt:alice", record["subject"])
+}
+
+func TestRateLimit(t *testing.T) {
+	limiter := ratelimit.NewLimiter(1, 2)
+	handler := RateLimit(limiter, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	serve := func(remoteAddr string, id *auth.Identity) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/airports", nil)
+		req.RemoteAddr = remoteAddr
+		if id != nil {
+			req = req.WithContext(auth.NewContext(req.Context(), id))
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+	alice := &auth.Identity{Subject: "jwt:alice"}
+
+	rr := serve("10.0.0.1:51234", nil)
+	require.Equal(t, http.StatusNoContent, rr.Code)
+	require.Equal(t, "2", rr.Header().Get("RateLimit-Limit"))
+	require.Equal(t, "1", rr.Header().Get("RateLimit-Remaining"))
+	require.Empty(t, rr.Header().Get("Retry-After"))
+	require.Equal(t, http.StatusNoContent, serve("10.0.0.1:51235", nil).Code)
+
+	rr = serve("10.0.0.1:51236", nil)
+	require.Equal(t, http.StatusTooManyRequests, rr.Code)
+	require.Equal(t, `{"error":"rate limit exceeded"}`, strings.TrimSpace(rr.Body.String()))
+	require.Equal(t, "0", rr.Header().Get("RateLimit-Remaining"))
+	require.Equal(t, "1", rr.Header().Get("Retry-After"))
+
+	require.Equal(t, http.StatusNoContent, serve("10.0.0.2:51234", nil).Code, "another IP address")
+	require.Equal(t, http.StatusNoContent, serve("10.0.0.1:51237", alice).Code, "an authenticated caller")
+	require.Equal(t, http.StatusNoContent, serve("10.0.0.3:51234", alice).Code, "the same caller from another IP address")
+	require.Equal(t, http.StatusTooManyRequests, serve("10.0.0.4:51234", alice).Code)
+}
+
+func TestIPRateLimit(t *testing.T) {
+	limiter := ratelimit.NewLimiter(1, 1)
+	handler := IPRateLimit(limiter, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	serve := func(remoteAddr string, id *auth.Identity) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/airports", nil)
+		req.RemoteAddr = remoteAddr
+		if id != nil {
+			req = req.WithContext(auth.NewContext(req.Context(), id))
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	require.Equal(t, http.StatusUnauthorized, serve("10.0.0.1:51234", nil))
+	require.Equal(t, http.StatusTooManyRequests, serve("10.0.0.1:51235", nil), "denied requests are limited too")
+	require.Equal(t, http.StatusTooManyRequests, serve("10.0.0.1:51236", &auth.Identity{Subject: "jwt:alice"}), "keyed by IP address even when authenticated")
+	require.Equal(t, http.StatusUnauthorized, serve("10.0.0.2:51234", nil), "another IP address")
+}
+
+func TestIngestQuota(t *testing.T) {
+	limiter := ratelimit.NewLimiter(1, 2)
+	var quota *ratelimit.Quota
+	handler := IngestQuota(limiter, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		quota = ratelimit.FromContext(r.Context())
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/airports", nil)
+	req = req.WithContext(auth.NewContext(req.Context(), &auth.Identity{Subject: "api-key:0123456789abcdef"}))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	require.NotNil(t, quota)
+	require.True(t, quota.TakeN(2).Allowed)
+	require.False(t, limiter.Allow("api-key:0123456789abcdef").Allowed, "taken from the caller's bucket")
+}
+
+func TestMaxBodySize(t *testing.T) {
+	testCases := []struct {
+		name               string
+		input              io.Reader
+		expectedStatusCode int
+		expectedOutput     string
+	}{
+		{
+			name:               "within limit",
+			input:              strings.NewReader("[{}]"),
+			expectedStatusCode: http.StatusOK,
+			expectedOutput:     "[{}]",
+		},
+		{
+			name:               "declared too large",
+			input:              strings.NewReader("[{}, {}]"),
+			expectedStatusCode: http.StatusRequestEntityTooLarge,
+			expectedOutput:     `{"error":"request body too large: at most 4 bytes"}`,
+		},
+		{
+			name:               "read too large",
+			input:              io.MultiReader(strings.NewReader("[{}, {}]")),
+			expectedStatusCode: http.StatusRequestEntityTooLarge,
+			expectedOutput:     "read 4 bytes: http: request body too large",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := MaxBodySize(4, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, err := io.ReadAll(r.Body)
+				if err != nil {
+					var maxBytesErr *http.MaxBytesError
+					require.ErrorAs(t, err, &maxBytesErr)
+					http.Error(w, fmt.Sprintf("read %d bytes: %v", len(b), err), http.StatusRequestEntityTooLarge)
+					return
+				}
+				_, _ = w.Write(b)
+			}))
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/v1/airports", tc.input))
+			require.Equal(t, tc.expectedStatusCode, rr.Code)
+			require.Equal(t, tc.expectedOutput, strings.TrimSpace(rr.Body.String()))
+		})
+	}
+}
+
+func TestMinThroughput(t *testing.T) {
+	testCases := []struct {
+		name               string
+		send               func(w *io.PipeWriter)
+		readInterval       time.Duration
+		expectedStatusCode int
+		expectedOutput     string
+	}{
+		{
+			name: "fast client",
+			send: func(w *io.PipeWriter) {
+				_, _ = w.Write([]byte("[{}, {}, {}]"))
+				_ = w.Close()
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedOutput:     "read 12 bytes",
+		},
+		{
+			name: "stalled client",
+			send: func(w *io.PipeWriter) {
+				_, _ = w.Write([]byte("[{}, "))
+			},
+			expectedStatusCode: http.StatusRequestTimeout,
+			expectedOutput:     "request body too slow: less than 1024 bytes per second",
+		},
+		{
+			name: "busy handler",
+			send: func(w *io.PipeWriter) {
+				_, _ = w.Write([]byte("[{}, {}, {}]"))
+				_ = w.Close()
+			},
+			readInterval:       150 * time.Millisecond,
+			expectedStatusCode: http.StatusOK,
+			expectedOutput:     "read 12 bytes",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(MinThroughput(1024, 100*time.Millisecond, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var read int
+				p := make([]byte, 4)
+				for {
+					time.Sleep(tc.readInterval)
+					n, err := r.Body.Read(p)
+					read += n
+					if err == io.EOF {
+						break
+					}
+					if err != nil {
+						var slowErr *SlowBodyError
+						require.ErrorAs(t, err, &slowErr)
+						http.Error(w, err.Error(), http.StatusRequestTimeout)
+						return
+					}
+				}
+				fmt.Fprintf(w, "read %d bytes", read)
+			})))
+			defer srv.Close()
+			pr, pw := io.Pipe()
+			defer pw.Close()
+			go tc.send(pw)
+			resp, err := http.Post(srv.URL, "application/json", pr)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			b, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+			require.Equal(t, tc.expectedOutput, strings.TrimSpace(string(b)))
+		})
+	}
+}
+
+func TestCORS(t *testing.T) {
+	options := CORSOptions{
+		AllowedOrigins:   []string{"https://admin.example.com"},
+		AllowedMethods:   []string{http.MethodGet, http.MethodPost},
+		AllowedHeaders:   []string{"Content-Type", "X-API-Key"},
+		AllowCredentials: true,
+		ExposedHeaders:   []string{"X-Request-ID", "Retry-After"},
+		MaxAge:           10 * time.Minute,
+	}
+	testCases := []struct {
+		name               string
+		options            CORSOptions
+		method             string
+		headers            map[string]string
+		expectedStatusCode int
+		expectedHeaders    map[string]string
+	}{
+		{
+			name:               "same origin",
+			options:            options,
+			method:             http.MethodGet,
+			expectedStatusCode: http.StatusOK,
+			expectedHeaders:    map[string]string{"Access-Control-Allow-Origin": "", "Vary": ""},
+		},
+		{
+			name:               "allowed origin",
+			options:            options,
+			method:             http.MethodGet,
+			headers:            map[string]string{"Origin": "https://admin.example.com"},
+			expectedStatusCode: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "https://admin.example.com",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Allow-Methods":     "",
+				"Access-Control-Expose-Headers":    "X-Request-ID, Retry-After",
+				"Vary":                             "Origin",
+			},
+		},
+		{
+			name:               "any origin",
+			options:            CORSOptions{AllowedOrigins: []string{"*"}},
+			method:             http.MethodGet,
+			headers:            map[string]string{"Origin": "https://elsewhere.example.com"},
+			expectedStatusCode: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "*",
+				"Access-Control-Allow-Credentials": "",
+				"Access-Control-Expose-Headers":    "",
+			},
+		},
+		{
+			name:               "disallowed origin",
+			options:            options,
+			method:             http.MethodGet,
+			headers:            map[string]string{"Origin": "https://evil.example.com"},
+			expectedStatusCode: http.StatusOK,
+			expectedHeaders:    map[string]string{"Access-Control-Allow-Origin": "", "Access-Control-Expose-Headers": "", "Vary": "Origin"},
+		},
+		{
+			name:    "preflight",
+			options: options,
+			method:  http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                         "https://admin.example.com",
+				"Access-Control-Request-Method":  http.MethodPost,
+				"Access-Control-Request-Headers": "content-type, x-api-key",
+			},
+			expectedStatusCode: http.StatusNoContent,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "https://admin.example.com",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Allow-Methods":     "GET, POST",
+				"Access-Control-Allow-Headers":     "content-type, x-api-key",
+				"Access-Control-Expose-Headers":    "",
+				"Access-Control-Max-Age":           "600",
+			},
+		},
+		{
+			name:    "preflight, any header",
+			options: CORSOptions{AllowedOrigins: []string{"*"}, AllowedMethods: []string{http.MethodGet}, AllowedHeaders: []string{"*"}},
+			method:  http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                         "https://admin.example.com",
+				"Access-Control-Request-Method":  http.MethodGet,
+				"Access-Control-Request-Headers": "x-custom",
+			},
+			expectedStatusCode: http.StatusNoContent,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":  "*",
+				"Access-Control-Allow-Headers": "x-custom",
+				"Access-Control-Max-Age":       "",
+			},
+		},
+		{
+			name:    "preflight, disallowed origin",
+			options: options,
+			method:  http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                        "https://evil.example.com",
+				"Access-Control-Request-Method": http.MethodPost,
+			},
+			expectedStatusCode: http.StatusNoContent,
+			expectedHeaders:    map[string]string{"Access-Control-Allow-Origin": "", "Access-Control-Allow-Methods": ""},
+		},
+		{
+			name:    "preflight, disallowed method",
+			options: options,
+			method:  http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                        "https://admin.example.com",
+				"Access-Control-Request-Method": http.MethodDelete,
+			},
+			expectedStatusCode: http.StatusNoContent,
+			expectedHeaders:    map[string]string{"Access-Control-Allow-Origin": "", "Access-Control-Allow-Methods": ""},
+		},
+		{
+			name:    "preflight, disallowed header",
+			options: options,
+			method:  http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                         "https://admin.example.com",
+				"Access-Control-Request-Method":  http.MethodPost,
+				"Access-Control-Request-Headers": "content-type, authorization",
+			},
+			expectedStatusCode: http.StatusNoContent,
+			expectedHeaders:    map[string]string{"Access-Control-Allow-Origin": "", "Access-Control-Allow-Headers": ""},
+		},
+		{
+			name:               "options, not a preflight",
+			options:            options,
+			method:             http.MethodOptions,
+			headers:            map[string]string{"Origin": "https://admin.example.com"},
+			expectedStatusCode: http.StatusMethodNotAllowed,
+			expectedHeaders:    map[string]string{"Access-Control-Allow-Origin": "https://admin.example.com"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := CORS(tc.options, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodOptions {
+					w.WriteHeader(http.StatusMethodNotAllowed)
+				}
+			}))
+			req := httptest.NewRequest(tc.method, "/api/v1/airports", nil)
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			require.Equal(t, tc.expectedStatusCode, rr.Code)
+			for k, v := range tc.expectedHeaders {
+				require.Equal(t, v, rr.Header().Get(k), k)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+// Package ratelimit limits how fast each client may do something, such as
+// sending requests or ingesting records, with a token bucket per client.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often buckets that refilled are dropped, so that
+// clients that went away are forgotten.
+const sweepInterval = time.Minute
+
+// For ease of unit testing.
+var (
+	// now is a function that returns the current time.
+	now = time.Now
+)
+
+// Result is the outcome of taking tokens from a client's bucket.
+type Result struct {
+	// Allowed tells whether the tokens were taken.
+	Allowed bool
+	// Limit is the size of the bucket.
+	Limit int
+	// Remaining is the number of tokens left in the bucket.
+	Remaining int
+	// Reset is how long the bucket takes to refill.
+	Reset time.Duration
+	// RetryAfter is how long the client has to wait for the tokens it
+	// was denied, zero when allowed.
+	RetryAfter time.Duration
+}
+
+// SetHeaders sets the RateLimit-Limit, RateLimit-Remaining and
+// RateLimit-Reset headers describing the result, along with Retry-After
+// when it was denied. Durations are rounded up to whole seconds.
+func (r Result) SetHeaders(h http.Header) {
+	h.Set("RateLimit-Limit", strconv.Itoa(r.Limit))
+	h.Set("RateLimit-Remaining", strconv.Itoa(r.Remaining))
+	h.Set("RateLimit-Reset", strconv.Itoa(seconds(r.Reset)))
+	if !r.Allowed {
+		h.Set("Retry-After", strconv.Itoa(seconds(r.RetryAfter)))
+	}
+}
+
+// seconds returns the duration in whole seconds, rounded up.
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// bucket holds the tokens a client has left as of the last time it was
+// updated.
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// Limiter keeps a token bucket per client, refilled at a constant rate up
+// to its size. It is safe for concurrent use.
+type Limiter struct {
+	rate  float64
+	burst int
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// NewLimiter creates a limiter whose buckets hold up to burst tokens and
+// are refilled with rate tokens per second.
+func NewLimiter(rate float64, burst int) *Limiter {
+	return &Limiter{
+		rate:      rate,
+		burst:     burst,
+		buckets:   make(map[string]*bucket),
+		lastSweep: now(),
+	}
+}
+
+// Allow takes a token from the given client's bucket.
+func (l *Limiter) Allow(key string) Result {
+	return l.AllowN(key, 1)
+}
+
+// AllowN takes n tokens from the given client's bucket, or none if it
+// does not have that many.
+func (l *Limiter) AllowN(key string, n int) Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	t := now()
+	if t.Sub(l.lastSweep) >= sweepInterval {
+		l.sweep(t)
+	}
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.burst), updated: t}
+		l.buckets[key] = b
+	}
+	b.tokens = l.refill(b, t)
+	b.updated = t
+	res := Result{Limit: l.burst}
+	if b.tokens >= float64(n) {
+		b.tokens -= float64(n)
+		res.Allowed = true
+	} else {
+		res.RetryAfter = l.duration(float64(n) - b.tokens)
+	}
+	res.Remaining = int(b.tokens)
+	res.Reset = l.duration(float64(l.burst) - b.tokens)
+	return res
+}
+
+// refill returns the tokens the bucket holds at the given time.
+func (l *Limiter) refill(b *bucket, t time.Time) float64 {
+	return math.Min(float64(l.burst), b.tokens+t.Sub(b.updated).Seconds()*l.rate)
+}
+
+// duration returns how long it takes to refill the given number of
+// tokens.
+func (l *Limiter) duration(tokens float64) time.Duration {
+	return time.Duration(tokens / l.rate * float64(time.Second))
+}
+
+// sweep drops the buckets that are full by the given time, which are no
+// different from the ones of new clients.
+func (l *Limiter) sweep(t time.Time) {
+	for key, b := range l.buckets {
+		if l.refill(b, t) >= float64(l.burst) {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = t
+}
+
+// Quota is a client's share of a limiter, such as the records it may
+// ingest.
+type Quota struct {
+	limiter *Limiter
+	key     string
+}
+
+// NewQuota returns the share of the limiter of the given client.
+func NewQuota(limiter *Limiter, key string) *Quota {
+	return &Quota{limiter: limiter, key: key}
+}
+
+// TakeN takes n tokens from the client's bucket, or none if it does not
+// have that many.
+func (q *Quota) TakeN(n int) Result {
+	return q.limiter.AllowN(q.key, n)
+}
+
+// ctxKey is the key quotas are stored under in a context.
+type ctxKey struct{}
+
+// NewContext returns a copy of ctx carrying the given quota.
+func NewContext(ctx context.Context, q *Quota) context.Context {
+	return context.WithValue(ctx, ctxKey{}, q)
+}
+
+// FromContext returns the quota ctx carries, or nil.
+func FromContext(ctx context.Context) *Quota {
+	q, _ := ctx.Value(ctxKey{}).(*Quota)
+	return q
+}
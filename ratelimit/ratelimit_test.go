@@ -0,0 +1,84 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter(t *testing.T) {
+	originalNow := now
+	defer func() {
+		now = originalNow
+	}()
+	current := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	now = func() time.Time {
+		return current
+	}
+	// 2 tokens per second, up to 4.
+	l := NewLimiter(2, 4)
+
+	require.Equal(t, Result{Allowed: true, Limit: 4, Remaining: 3, Reset: 500 * time.Millisecond}, l.Allow("alice"))
+	require.Equal(t, Result{Allowed: true, Limit: 4, Remaining: 0, Reset: 2 * time.Second}, l.AllowN("alice", 3))
+	require.Equal(t, Result{Limit: 4, Remaining: 0, Reset: 2 * time.Second, RetryAfter: 500 * time.Millisecond}, l.Allow("alice"))
+	require.Equal(t, Result{Allowed: true, Limit: 4, Remaining: 3, Reset: 500 * time.Millisecond}, l.Allow("bob"), "buckets are per client")
+
+	current = current.Add(750 * time.Millisecond)
+	require.Equal(t, Result{Allowed: true, Limit: 4, Remaining: 0, Reset: 1750 * time.Millisecond}, l.Allow("alice"))
+	require.Equal(t, Result{Limit: 4, Remaining: 0, Reset: 1750 * time.Millisecond, RetryAfter: 5*time.Second - 250*time.Millisecond}, l.AllowN("alice", 10), "more than the bucket holds")
+
+	current = current.Add(time.Hour)
+	require.Equal(t, Result{Allowed: true, Limit: 4, Remaining: 3, Reset: 500 * time.Millisecond}, l.Allow("alice"), "refilled up to its size")
+	require.Len(t, l.buckets, 1, "refilled buckets swept")
+}
+
+func TestResultSetHeaders(t *testing.T) {
+	testCases := []struct {
+		name            string
+		input           Result
+		expectedHeaders http.Header
+	}{
+		{
+			name:  "allowed",
+			input: Result{Allowed: true, Limit: 10, Remaining: 9, Reset: 100 * time.Millisecond},
+			expectedHeaders: http.Header{
+				"Ratelimit-Limit":     {"10"},
+				"Ratelimit-Remaining": {"9"},
+				"Ratelimit-Reset":     {"1"},
+			},
+		},
+		{
+			name:  "denied",
+			input: Result{Limit: 10, Remaining: 0, Reset: 5 * time.Second, RetryAfter: 1500 * time.Millisecond},
+			expectedHeaders: http.Header{
+				"Ratelimit-Limit":     {"10"},
+				"Ratelimit-Remaining": {"0"},
+				"Ratelimit-Reset":     {"5"},
+				"Retry-After":         {"2"},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := http.Header{}
+			tc.input.SetHeaders(h)
+			require.Equal(t, tc.expectedHeaders, h)
+		})
+	}
+}
+
+func TestQuota(t *testing.T) {
+	require.Nil(t, FromContext(context.TODO()))
+	q := NewQuota(NewLimiter(1, 2), "alice")
+	ctx := NewContext(context.TODO(), q)
+	require.Equal(t, q, FromContext(ctx))
+	require.True(t, FromContext(ctx).TakeN(2).Allowed)
+	require.False(t, FromContext(ctx).TakeN(1).Allowed)
+}
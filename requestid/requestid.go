@@ -0,0 +1,63 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+// Package requestid carries the ID of the request being served, so that
+// responses and log records can be correlated with each other.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// Header is the header request IDs are accepted from and echoed in.
+const Header = "X-Request-ID"
+
+// maxLength is the maximum length of request IDs accepted from clients.
+const maxLength = 128
+
+// For ease of unit testing.
+var (
+	// randRead is a function that fills a byte slice with random bytes.
+	randRead = rand.Read
+)
+
+// ctxKey is the key request IDs are stored under in a context.
+type ctxKey struct{}
+
+// NewContext returns a copy of ctx carrying the given request ID.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext returns the request ID ctx carries, or an empty string.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+// New generates a random request ID.
+func New() string {
+	b := make([]byte, 16)
+	// crypto/rand never fails on the platforms Go supports.
+	_, _ = randRead(b)
+	return hex.EncodeToString(b)
+}
+
+// Valid reports whether a request ID received from a client can be used
+// as is: it must be non-empty, up to maxLength characters long and made
+// of printable ASCII characters other than spaces, so that it cannot
+// forge log records or headers.
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < '!' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package requestid
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestContext(t *testing.T) {
+	ctx := context.TODO()
+	require.Empty(t, FromContext(ctx))
+	require.Equal(t, "abc", FromContext(NewContext(ctx, "abc")))
+}
+
+func TestNew(t *testing.T) {
+	originalRandRead := randRead
+	defer func() {
+		randRead = originalRandRead
+	}()
+	randRead = func(b []byte) (int, error) {
+		for i := range b {
+			b[i] = byte(i)
+		}
+		return len(b), nil
+	}
+	require.Equal(t, "000102030405060708090a0b0c0d0e0f", New())
+}
+
+func TestValid(t *testing.T) {
+	testCases := []struct {
+		name     string
+		id       string
+		expected bool
+	}{
+		{name: "uuid", id: "6f1c0e52-8f1e-4c1a-9d43-2f4b8f3c7a10", expected: true},
+		{name: "generated", id: New(), expected: true},
+		{name: "empty", id: "", expected: false},
+		{name: "too long", id: strings.Repeat("a", maxLength+1), expected: false},
+		{name: "space", id: "abc def", expected: false},
+		{name: "line break", id: "abc\ndef", expected: false},
+		{name: "non ascii", id: "abcé", expected: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, Valid(tc.id))
+		})
+	}
+}
@@ -0,0 +1,249 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+// Package snapshots keeps point-in-time copies of the whole airports
+// dataset, so that risky supplier feeds can be reviewed against them and
+// rolled back if needed.
+package snapshots
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tiagomelo/go-airports-service/db/airports"
+)
+
+// IDPattern is the pattern snapshot IDs follow: the UTC time they were
+// taken at, down to the nanosecond, so that they sort chronologically.
+const IDPattern = `[0-9]{8}T[0-9]{6}\.[0-9]{9}Z`
+
+const (
+	// idLayout is the time layout snapshot IDs are formatted with.
+	idLayout = "20060102T150405.000000000Z"
+	// fileSuffix is the suffix of snapshot files.
+	fileSuffix = ".ndjson.gz"
+)
+
+// ErrNotFound is returned when a snapshot does not exist.
+var ErrNotFound = errors.New("snapshot not found")
+
+var idRegexp = regexp.MustCompile(`^` + IDPattern + `$`)
+
+// For ease of unit testing.
+var (
+	// now is a function that returns the current time.
+	now = time.Now
+)
+
+// Snapshot describes a point-in-time copy of the airports dataset.
+type Snapshot struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Airports  int       `json:"airports"`
+}
+
+// Store keeps snapshots as gzip-compressed NDJSON files within a
+// directory, one per snapshot: the first line describes the snapshot and
+// each of the following ones holds an airport along with its localized
+// names. The first line is compressed on its own, so that it can be
+// written once all airports are.
+type Store struct {
+	dir string
+}
+
+// NewStore creates a Store keeping snapshots within dir, which is created
+// if needed.
+func NewStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, errors.Wrapf(err, "creating snapshots directory %s", dir)
+	}
+	return &Store{dir: dir}, nil
+}
+
+// Create takes a snapshot of all airports in the given airport store.
+// Airports are written to a temporary file as they are exported, so that
+// they are never all held in memory.
+func (s *Store) Create(ctx context.Context, store airports.AirportStore) (*Snapshot, error) {
+	body, err := os.CreateTemp(s.dir, "snapshot.tmp-*")
+	if err != nil {
+		return nil, errors.Wrap(err, "creating snapshot file")
+	}
+	defer os.Remove(body.Name())
+	defer body.Close()
+	bufWriter := bufio.NewWriter(body)
+	gzWriter := gzip.NewWriter(bufWriter)
+	enc := json.NewEncoder(gzWriter)
+	count := 0
+	err = store.Export(ctx, func(a airports.Airport) error {
+		count++
+		return errors.Wrap(enc.Encode(a), "writing snapshot")
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "exporting airports")
+	}
+	if err := gzWriter.Close(); err != nil {
+		return nil, errors.Wrap(err, "writing snapshot")
+	}
+	if err := bufWriter.Flush(); err != nil {
+		return nil, errors.Wrap(err, "writing snapshot")
+	}
+	createdAt := now().UTC()
+	snapshot := &Snapshot{
+		ID:        createdAt.Format(idLayout),
+		CreatedAt: createdAt,
+		Airports:  count,
+	}
+	if err := s.write(snapshot, body); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// write writes a snapshot to a temporary file, which is then renamed, so
+// that a snapshot file is either complete or missing. The description of
+// the snapshot is compressed on its own, as the first gzip member of the
+// file, followed by body, which holds the already compressed airports.
+func (s *Store) write(snapshot *Snapshot, body io.ReadSeeker) error {
+	f, err := os.CreateTemp(s.dir, snapshot.ID+".tmp-*")
+	if err != nil {
+		return errors.Wrap(err, "creating snapshot file")
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	bufWriter := bufio.NewWriter(f)
+	gzWriter := gzip.NewWriter(bufWriter)
+	if err := json.NewEncoder(gzWriter).Encode(snapshot); err != nil {
+		return errors.Wrap(err, "writing snapshot")
+	}
+	if err := gzWriter.Close(); err != nil {
+		return errors.Wrap(err, "writing snapshot")
+	}
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		return errors.Wrap(err, "writing snapshot")
+	}
+	if _, err := io.Copy(bufWriter, body); err != nil {
+		return errors.Wrap(err, "writing snapshot")
+	}
+	if err := bufWriter.Flush(); err != nil {
+		return errors.Wrap(err, "writing snapshot")
+	}
+	if err := f.Sync(); err != nil {
+		return errors.Wrap(err, "syncing snapshot file")
+	}
+	if err := f.Close(); err != nil {
+		return errors.Wrap(err, "closing snapshot file")
+	}
+	if err := os.Rename(f.Name(), s.path(snapshot.ID)); err != nil {
+		return errors.Wrap(err, "renaming snapshot file")
+	}
+	return nil
+}
+
+// List returns all snapshots, oldest first.
+func (s *Store) List() ([]Snapshot, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading snapshots directory")
+	}
+	snapshots := []Snapshot{}
+	for _, e := range entries {
+		id, ok := strings.CutSuffix(e.Name(), fileSuffix)
+		if !ok || !idRegexp.MatchString(id) {
+			continue
+		}
+		snapshot, err := s.read(id, nil)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, *snapshot)
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].ID < snapshots[j].ID
+	})
+	return snapshots, nil
+}
+
+// Get returns the snapshot with the given ID along with its airports, or
+// ErrNotFound.
+func (s *Store) Get(id string) (*Snapshot, []airports.Airport, error) {
+	all := []airports.Airport{}
+	snapshot, err := s.read(id, func(a airports.Airport) {
+		all = append(all, a)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return snapshot, all, nil
+}
+
+// Delete deletes the snapshot with the given ID, or returns ErrNotFound.
+func (s *Store) Delete(id string) error {
+	if !idRegexp.MatchString(id) {
+		return ErrNotFound
+	}
+	if err := os.Remove(s.path(id)); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return ErrNotFound
+		}
+		return errors.Wrap(err, "deleting snapshot file")
+	}
+	return nil
+}
+
+// read reads the snapshot with the given ID, calling fn for each of its
+// airports. When fn is nil, only the snapshot description is read.
+func (s *Store) read(id string, fn func(airports.Airport)) (*Snapshot, error) {
+	if !idRegexp.MatchString(id) {
+		return nil, ErrNotFound
+	}
+	f, err := os.Open(s.path(id))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return nil, errors.Wrap(err, "opening snapshot file")
+	}
+	defer f.Close()
+	gzReader, err := gzip.NewReader(bufio.NewReader(f))
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading snapshot %s", id)
+	}
+	defer gzReader.Close()
+	dec := json.NewDecoder(gzReader)
+	var snapshot Snapshot
+	if err := dec.Decode(&snapshot); err != nil {
+		return nil, errors.Wrapf(err, "reading snapshot %s", id)
+	}
+	if fn == nil {
+		return &snapshot, nil
+	}
+	for {
+		var a airports.Airport
+		err := dec.Decode(&a)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading snapshot %s", id)
+		}
+		fn(a)
+	}
+	return &snapshot, nil
+}
+
+// path returns the path of the file holding the snapshot with the given
+// ID.
+func (s *Store) path(id string) string {
+	return filepath.Join(s.dir, id+fileSuffix)
+}
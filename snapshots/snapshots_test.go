@@ -0,0 +1,95 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package snapshots
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tiagomelo/go-airports-service/db/airports"
+)
+
+type failingStore struct {
+	*airports.MemoryStore
+}
+
+func (s *failingStore) Export(ctx context.Context, fn func(airports.Airport) error) error {
+	return errors.New("database error")
+}
+
+func TestStore(t *testing.T) {
+	ctx := context.TODO()
+	originalNow := now
+	defer func() {
+		now = originalNow
+	}()
+	times := []time.Time{
+		time.Date(2025, 1, 2, 3, 4, 5, 6, time.UTC),
+		time.Date(2025, 1, 2, 3, 4, 5, 7, time.UTC),
+	}
+	now = func() time.Time {
+		t := times[0]
+		times = times[1:]
+		return t
+	}
+
+	source := airports.NewMemoryStore()
+	cdg := airports.Airport{
+		Name:     "Charles De Gaulle",
+		City:     "Paris",
+		Country:  "France",
+		IataCode: "CDG",
+		Geoloc:   &airports.Geoloc{Lat: 49.012779, Lng: 2.55},
+		Names:    []airports.LocalizedName{{Lang: "fr", Value: "Roissy", Type: airports.NameTypeAlias}},
+	}
+	gru := airports.Airport{Name: "Guarulhos", City: "Sao Paulo", Country: "Brazil", IataCode: "GRU"}
+	require.NoError(t, source.UpsertBatch(ctx, []*airports.Airport{&gru, &cdg}))
+
+	dir := filepath.Join(t.TempDir(), "snapshots")
+	store, err := NewStore(dir)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "unrelated.txt"), nil, 0o644))
+
+	first, err := store.Create(ctx, source)
+	require.NoError(t, err)
+	require.Equal(t, &Snapshot{ID: "20250102T030405.000000006Z", CreatedAt: time.Date(2025, 1, 2, 3, 4, 5, 6, time.UTC), Airports: 2}, first)
+
+	require.NoError(t, source.Delete(ctx, "GRU"))
+	second, err := store.Create(ctx, source)
+	require.NoError(t, err)
+	require.Equal(t, 1, second.Airports)
+
+	snapshots, err := store.List()
+	require.NoError(t, err)
+	require.Equal(t, []Snapshot{*first, *second}, snapshots)
+
+	snapshot, all, err := store.Get(first.ID)
+	require.NoError(t, err)
+	require.Equal(t, first, snapshot)
+	require.Equal(t, []airports.Airport{cdg, gru}, all)
+
+	_, _, err = store.Get("20250102T030405.000000099Z")
+	require.ErrorIs(t, err, ErrNotFound)
+	_, _, err = store.Get("../../etc/passwd")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, store.Delete(first.ID))
+	require.ErrorIs(t, store.Delete(first.ID), ErrNotFound)
+	require.ErrorIs(t, store.Delete("../unrelated"), ErrNotFound)
+	snapshots, err = store.List()
+	require.NoError(t, err)
+	require.Equal(t, []Snapshot{*second}, snapshots)
+
+	_, err = store.Create(ctx, &failingStore{source})
+	require.EqualError(t, err, "exporting airports: database error")
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+}
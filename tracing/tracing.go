@@ -0,0 +1,118 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+// Package tracing sets up the service's OpenTelemetry tracing: the tracer
+// provider every package starts its spans from, the exporter spans are
+// sent through, and the propagation of trace context across services.
+package tracing
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// Exporters spans can be sent through.
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// ServiceName is the name spans are reported under.
+const ServiceName = "airports-service"
+
+// Config holds the tracing settings.
+type Config struct {
+	// Exporter is the exporter spans are sent through: none, stdout or
+	// otlp.
+	Exporter string
+	// OTLPEndpoint is the URL of the OTLP/HTTP collector spans are sent
+	// to, such as http://localhost:4318. When empty, the standard
+	// OTEL_EXPORTER_OTLP_* environment variables apply.
+	OTLPEndpoint string
+	// SampleRatio is the fraction of traces started by the service that
+	// are sampled, between 0 and 1. Traces started by the caller follow
+	// its sampling decision.
+	SampleRatio float64
+}
+
+// Validate checks the tracing settings.
+func (c Config) Validate() error {
+	switch c.Exporter {
+	case ExporterNone, ExporterStdout, ExporterOTLP:
+	default:
+		return errors.Errorf("invalid trace exporter %q: expected one of none, stdout or otlp", c.Exporter)
+	}
+	if c.SampleRatio < 0 || c.SampleRatio > 1 {
+		return errors.Errorf("invalid trace sample ratio %v: expected a number between 0 and 1", c.SampleRatio)
+	}
+	return nil
+}
+
+// For ease of unit testing.
+var (
+	// stdout is where the stdout exporter writes spans to.
+	stdout io.Writer = os.Stdout
+)
+
+// Setup installs the global tracer provider and propagator according to
+// the given settings. The returned function flushes pending spans and
+// shuts the provider down; it must be called before the service exits.
+// With the none exporter, spans are not recorded at all, but incoming
+// trace context is still propagated.
+func Setup(ctx context.Context, c Config) (func(context.Context) error, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+	if c.Exporter == ExporterNone {
+		return func(context.Context) error { return nil }, nil
+	}
+	exporter, err := newExporter(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(ServiceName))),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(c.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// newExporter creates the span exporter the settings select.
+func newExporter(ctx context.Context, c Config) (sdktrace.SpanExporter, error) {
+	switch c.Exporter {
+	case ExporterStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(stdout))
+		if err != nil {
+			return nil, errors.Wrap(err, "creating stdout trace exporter")
+		}
+		return exporter, nil
+	default:
+		var opts []otlptracehttp.Option
+		if c.OTLPEndpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(c.OTLPEndpoint))
+		}
+		exporter, err := otlptracehttp.New(ctx, opts...)
+		if err != nil {
+			return nil, errors.Wrap(err, "creating OTLP trace exporter")
+		}
+		return exporter, nil
+	}
+}
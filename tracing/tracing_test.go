@@ -0,0 +1,133 @@
+// Copyright (c) 2025 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+)
+
+func TestConfigValidate(t *testing.T) {
+	testCases := []struct {
+		name          string
+		config        Config
+		expectedError error
+	}{
+		{
+			name:   "none",
+			config: Config{Exporter: ExporterNone, SampleRatio: 1},
+		},
+		{
+			name:   "otlp",
+			config: Config{Exporter: ExporterOTLP, SampleRatio: 0.25},
+		},
+		{
+			name:          "invalid exporter",
+			config:        Config{Exporter: "jaeger", SampleRatio: 1},
+			expectedError: errors.New(`invalid trace exporter "jaeger": expected one of none, stdout or otlp`),
+		},
+		{
+			name:          "invalid sample ratio",
+			config:        Config{Exporter: ExporterStdout, SampleRatio: 1.5},
+			expectedError: errors.New("invalid trace sample ratio 1.5: expected a number between 0 and 1"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.config.Validate()
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf(`expected no error, got "%v"`, err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf(`expected error "%v", got nil`, tc.expectedError)
+				}
+			}
+		})
+	}
+}
+
+func TestSetup(t *testing.T) {
+	testCases := []struct {
+		name          string
+		config        Config
+		expectedSpans bool
+		expectedError error
+	}{
+		{
+			name:   "none",
+			config: Config{Exporter: ExporterNone, SampleRatio: 1},
+		},
+		{
+			name:          "stdout",
+			config:        Config{Exporter: ExporterStdout, SampleRatio: 1},
+			expectedSpans: true,
+		},
+		{
+			name:   "stdout, nothing sampled",
+			config: Config{Exporter: ExporterStdout, SampleRatio: 0},
+		},
+		{
+			name:   "otlp",
+			config: Config{Exporter: ExporterOTLP, OTLPEndpoint: "http://localhost:4318", SampleRatio: 1},
+		},
+		{
+			name:          "invalid exporter",
+			config:        Config{Exporter: "jaeger", SampleRatio: 1},
+			expectedError: errors.New(`invalid trace exporter "jaeger": expected one of none, stdout or otlp`),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			restoreGlobals(t)
+			var buf bytes.Buffer
+			stdout = &buf
+			shutdown, err := Setup(context.Background(), tc.config)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf(`expected no error, got "%v"`, err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+				return
+			}
+			if tc.expectedError != nil {
+				t.Fatalf(`expected error "%v", got nil`, tc.expectedError)
+			}
+			require.ElementsMatch(t, []string{"traceparent", "tracestate", "baggage"}, otel.GetTextMapPropagator().Fields())
+			if tc.config.Exporter == ExporterOTLP {
+				// no span is started, so that nothing is sent to the
+				// collector.
+				require.NoError(t, shutdown(context.Background()))
+				return
+			}
+			_, span := otel.Tracer("test").Start(context.Background(), "test span")
+			span.End()
+			require.NoError(t, shutdown(context.Background()))
+			if tc.expectedSpans {
+				require.Contains(t, buf.String(), `"Name":"test span"`)
+			} else {
+				require.Empty(t, buf.String())
+			}
+		})
+	}
+}
+
+// restoreGlobals restores the global tracer provider, propagator and
+// stdout writer once the test is over.
+func restoreGlobals(t *testing.T) {
+	provider, propagator, w := otel.GetTracerProvider(), otel.GetTextMapPropagator(), stdout
+	t.Cleanup(func() {
+		otel.SetTracerProvider(provider)
+		otel.SetTextMapPropagator(propagator)
+		stdout = w
+	})
+}
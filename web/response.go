@@ -7,6 +7,8 @@ package web
 import (
 	"encoding/json"
 	"net/http"
+
+	"github.com/tiagomelo/go-airports-service/requestid"
 )
 
 // for ease of unit testing.
@@ -19,9 +21,14 @@ var (
 	}
 )
 
-// RespondWithError responds a json with an error message.
+// RespondWithError responds a json with an error message, along with the
+// request ID the request ID middleware set in the response headers, if any.
 func RespondWithError(w http.ResponseWriter, code int, message string) {
-	Respond(w, code, map[string]string{"error": message})
+	payload := map[string]string{"error": message}
+	if id := w.Header().Get(requestid.Header); id != "" {
+		payload["request_id"] = id
+	}
+	Respond(w, code, payload)
 }
 
 // Respond responds a json with a payload.
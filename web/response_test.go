@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	"github.com/tiagomelo/go-airports-service/requestid"
 )
 
 func TestRespond(t *testing.T) {
@@ -110,6 +111,39 @@ func TestRespondAfterFlush(t *testing.T) {
 	}
 }
 
+func TestRespondWithError(t *testing.T) {
+	tests := []struct {
+		name         string
+		requestID    string
+		expectedBody string
+	}{
+		{
+			name:         "without request ID",
+			expectedBody: `{"error":"airport not found"}`,
+		},
+		{
+			name:         "with request ID",
+			requestID:    "abc",
+			expectedBody: `{"error":"airport not found","request_id":"abc"}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			if tc.requestID != "" {
+				recorder.Header().Set(requestid.Header, tc.requestID)
+			}
+			RespondWithError(recorder, http.StatusNotFound, "airport not found")
+			result := recorder.Result()
+			defer result.Body.Close()
+			require.Equal(t, http.StatusNotFound, result.StatusCode)
+			body := readResponseBody(t, result)
+			require.JSONEq(t, tc.expectedBody, body)
+		})
+	}
+}
+
 // readResponseBody reads and returns the response body as a string.
 func readResponseBody(t *testing.T, result *http.Response) string {
 	body, err := io.ReadAll(result.Body)